@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"library-management/service/covers"
+	svc "library-management/service/handler"
+	"library-management/service/marc"
+	"library-management/service/models"
+	"library-management/service/notify"
+	"library-management/service/repository"
+	"library-management/service/repository/db"
+)
+
+func runCommand(database *sqlx.DB, name string, args []string) error {
+	switch name {
+	case "migrate":
+		return runMigrate(database, args)
+	case "admin":
+		return runAdmin(database, args)
+	case "purge":
+		return runPurge(database, args)
+	case "marc":
+		return runMARC(database, args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func runMigrate(database *sqlx.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down|status")
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp(ctx, database)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		n, err := svc.RekeyAuthors(ctx, repository.NewRepo(database))
+		if n > 0 {
+			fmt.Printf("rekeyed %d authors\n", n)
+		}
+		return err
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		reverted, err := db.MigrateDown(ctx, database, *steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := db.MigrationStatuses(ctx, database)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", st.Version, st.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func runAdmin(database *sqlx.DB, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return fmt.Errorf("usage: admin create -username NAME [-password PASS] [-roles head_librarian]")
+	}
+
+	fs := flag.NewFlagSet("admin create", flag.ContinueOnError)
+	username := fs.String("username", "", "admin username")
+	password := fs.String("password", "", "admin password (defaults to $ADMIN_PASSWORD, then stdin)")
+	roles := fs.String("roles", "head_librarian", "comma separated roles to grant")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	pass := *password
+	if pass == "" {
+		pass = os.Getenv("ADMIN_PASSWORD")
+	}
+	if pass == "" {
+		fmt.Print("password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		pass = strings.TrimRight(line, "\r\n")
+	}
+
+	if _, err := db.MigrateUp(context.Background(), database); err != nil {
+		return err
+	}
+	var roleNames []string
+	for _, name := range strings.Split(*roles, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			roleNames = append(roleNames, name)
+		}
+	}
+	id, err := svc.CreateStaff(context.Background(), repository.NewRepo(database), *username, pass, roleNames)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("created admin %q (id %d)\n", *username, id)
+	return nil
+}
+
+func runPurge(database *sqlx.DB, args []string) error {
+	def, err := purgeAfterDaysEnv()
+	if err != nil {
+		return err
+	}
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	days := fs.Int("days", def, "purge records soft deleted more than this many days ago")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := covers.FromEnv()
+	if err != nil {
+		return err
+	}
+	res, err := svc.PurgeDeleted(context.Background(), repository.NewRepo(database), store, *days)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("anonymized %d members, purged %d books, %d failed\n", res.MembersAnonymized, res.BooksPurged, res.Failed)
+	return nil
+}
+
+func runMARC(database *sqlx.DB, args []string) error {
+	usage := fmt.Errorf("usage: marc import [-format marc|xml] [-dry-run] FILE | marc export -ids 1,2,3 [-format xml|marc] [-o FILE]")
+	if len(args) == 0 {
+		return usage
+	}
+	switch args[0] {
+	case "import":
+		fs := flag.NewFlagSet("marc import", flag.ContinueOnError)
+		format := fs.String("format", "", "marc or xml (detected when empty)")
+		dryRun := fs.Bool("dry-run", false, "report what would change without saving")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return usage
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rr, err := marc.NewReader(f, *format)
+		if err != nil {
+			return err
+		}
+		report, err := svc.ImportMARC(context.Background(), repository.NewRepo(database), rr, *dryRun)
+		if err != nil {
+			return err
+		}
+		for _, row := range report.Rows {
+			if row.Action == models.ImportSkipped {
+				fmt.Printf("record %d skipped: %s\n", row.Row, row.Error)
+			}
+		}
+		if *dryRun {
+			fmt.Print("dry run: ")
+		}
+		fmt.Printf("%d created, %d updated, %d skipped\n", report.Created, report.Updated, report.Skipped)
+		if report.Error != "" {
+			return fmt.Errorf("import stopped at %s", report.Error)
+		}
+		return nil
+	case "export":
+		fs := flag.NewFlagSet("marc export", flag.ContinueOnError)
+		idList := fs.String("ids", "", "comma separated book ids")
+		format := fs.String("format", marc.FormatXML, "xml or marc")
+		out := fs.String("o", "", "output file (default stdout)")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		var ids []int64
+		for _, s := range strings.Split(*idList, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("bad id %q", s)
+			}
+			ids = append(ids, id)
+		}
+
+		dst := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			dst = f
+		}
+		w, err := marc.NewWriter(dst, *format)
+		if err != nil {
+			return err
+		}
+		return svc.ExportMARC(repository.NewRepo(database), ids, w)
+	default:
+		return usage
+	}
+}
+
+// runSMTPSink starts the stand-in mail server and prints what it receives.
+// Point the service at it with NOTIFIER=smtp SMTP_HOST=127.0.0.1 SMTP_PORT=2525.
+func runSMTPSink(args []string) error {
+	fs := flag.NewFlagSet("smtp-sink", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:2525", "listen address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	sink, err := notify.ListenSMTPSink(*addr)
+	if err != nil {
+		return err
+	}
+	sink.Deliver = func(m notify.Message) {
+		fmt.Printf("--- to %s\nSubject: %s\n\n%s\n", m.To, m.Subject, m.Body)
+	}
+	fmt.Printf("smtp sink listening on %s\n", sink.Addr())
+	return sink.Serve()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"library-management/service/covers"
+	"library-management/service/events"
+	svc "library-management/service/handler"
+	"library-management/service/jobs"
+	"library-management/service/notify"
+	"library-management/service/repository"
+	"library-management/service/search"
+	"library-management/service/webhook"
+)
+
+// startJobs launches the background jobs unless JOBS_ENABLED=false.
+//
+//	NOTICE_INTERVAL         how often loans are scanned (default 1h)
+//	NOTICE_DUE_SOON_DAYS    reminder lead time in days (default 2)
+//	NOTICE_ESCALATION_DAYS  days late for each overdue notice (default 1,7,14)
+//	HOLD_EXPIRY_INTERVAL    how often uncollected holds expire (default 15m)
+//	WEBHOOK_INTERVAL        how often queued webhook deliveries are sent (default 5s)
+//	EVENTS_INTERVAL         how often the outbox is dispatched (default 1s)
+//	EVENTS_FILE             also append every event to this NDJSON file
+//	PURGE_INTERVAL          how often soft deleted records are purged (default 24h)
+//	PURGE_AFTER_DAYS        days a record stays restorable (default 365)
+//	SUGGEST_INTERVAL        how often the suggestion index is rebuilt (default 10m)
+func startJobs(ctx context.Context, database *sqlx.DB, bus *events.Bus, suggester *search.Suggester, store covers.Storage) (*jobs.Runner, error) {
+	runner := jobs.NewRunner()
+	if os.Getenv("JOBS_ENABLED") == "false" {
+		return runner, nil
+	}
+
+	notifier, err := notify.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := noticeConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	noticeEvery, err := durationEnv("NOTICE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	holdEvery, err := durationEnv("HOLD_EXPIRY_INTERVAL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookEvery, err := durationEnv("WEBHOOK_INTERVAL", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	eventsEvery, err := durationEnv("EVENTS_INTERVAL", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeEvery, err := durationEnv("PURGE_INTERVAL", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	purgeAfter, err := purgeAfterDaysEnv()
+	if err != nil {
+		return nil, err
+	}
+	suggestEvery, err := durationEnv("SUGGEST_INTERVAL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatcher := events.NewDispatcher(repository.NewRepo(database),
+		svc.NewWebhookSink(repository.NewRepo(database)), bus)
+	if path := os.Getenv("EVENTS_FILE"); path != "" {
+		sink, err := events.NewFileSink(path)
+		if err != nil {
+			return nil, err
+		}
+		dispatcher.AddSink(sink)
+	}
+
+	runner.Add("notices", noticeEvery, func(ctx context.Context) error {
+		sent, err := svc.SendNotices(ctx, repository.NewRepo(database), notifier, cfg)
+		if sent > 0 {
+			log.Printf("sent %d loan notices via %s\n", sent, notifier.Channel())
+		}
+		return err
+	})
+	runner.Add("expire-holds", holdEvery, func(ctx context.Context) error {
+		expired, err := svc.ExpireHolds(ctx, repository.NewRepo(database))
+		if expired > 0 {
+			log.Printf("expired %d holds\n", expired)
+		}
+		return err
+	})
+	runner.Add("outbox", eventsEvery, func(ctx context.Context) error {
+		_, err := dispatcher.Dispatch(ctx)
+		return err
+	})
+	sender := webhook.NewSender()
+	runner.Add("webhooks", webhookEvery, func(ctx context.Context) error {
+		_, err := svc.DeliverWebhooks(ctx, repository.NewRepo(database), sender)
+		return err
+	})
+	runner.Add("purge", purgeEvery, func(ctx context.Context) error {
+		res, err := svc.PurgeDeleted(ctx, repository.NewRepo(database), store, purgeAfter)
+		if res.MembersAnonymized > 0 || res.BooksPurged > 0 || res.Failed > 0 {
+			log.Printf("anonymized %d members, purged %d books, %d failed\n", res.MembersAnonymized, res.BooksPurged, res.Failed)
+		}
+		return err
+	})
+	// catalog events rebuild the suggestion index too, but only for changes
+	// dispatched by this instance
+	runner.Add("suggest-index", suggestEvery, func(ctx context.Context) error {
+		return suggester.Rebuild()
+	})
+	runner.Start(ctx)
+	return runner, nil
+}
+
+func noticeConfigFromEnv() (svc.NoticeConfig, error) {
+	cfg := svc.DefaultNoticeConfig()
+	if s := os.Getenv("NOTICE_DUE_SOON_DAYS"); s != "" {
+		days, err := strconv.Atoi(s)
+		if err != nil || days < 0 {
+			return cfg, fmt.Errorf("NOTICE_DUE_SOON_DAYS: bad value %q", s)
+		}
+		cfg.DueSoonDays = days
+	}
+	if s := os.Getenv("NOTICE_ESCALATION_DAYS"); s != "" {
+		cfg.EscalationDays = nil
+		for _, part := range strings.Split(s, ",") {
+			days, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || days < 1 {
+				return cfg, fmt.Errorf("NOTICE_ESCALATION_DAYS: bad value %q", part)
+			}
+			cfg.EscalationDays = append(cfg.EscalationDays, days)
+		}
+	}
+	return cfg, nil
+}
+
+func purgeAfterDaysEnv() (int, error) {
+	s := os.Getenv("PURGE_AFTER_DAYS")
+	if s == "" {
+		return 365, nil
+	}
+	days, err := strconv.Atoi(s)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("PURGE_AFTER_DAYS: bad value %q", s)
+	}
+	return days, nil
+}
+
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	s := os.Getenv(name)
+	if s == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s: bad duration %q", name, s)
+	}
+	return d, nil
+}
@@ -1,27 +1,81 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
 
+	"library-management/service/covers"
+	"library-management/service/events"
+	svc "library-management/service/handler"
 	"library-management/service/libhttp"
+	"library-management/service/metadata"
+	"library-management/service/models"
+	"library-management/service/repository"
 	"library-management/service/repository/db"
+	"library-management/service/search"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "smtp-sink" {
+		if err := runSMTPSink(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	database, err := db.ConnectDB()
 	if err != nil {
 		log.Fatal("failed connect db:", err)
 	}
 	log.Println("connected to db")
-	if err := db.EnsureSchema(database); err != nil {
-		log.Fatal("ensure schema:", err)
+
+	if len(os.Args) > 1 {
+		if err := runCommand(database, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	applied, err := db.MigrateUp(context.Background(), database)
+	if err != nil {
+		log.Fatal("migrate schema:", err)
+	}
+	for _, m := range applied {
+		log.Printf("applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	if n, err := svc.RekeyAuthors(context.Background(), repository.NewRepo(database)); err != nil {
+		log.Printf("rekey authors: %v", err)
+	} else if n > 0 {
+		log.Printf("rekeyed %d authors\n", n)
+	}
+
+	store, err := covers.FromEnv()
+	if err != nil {
+		log.Fatal("cover storage:", err)
+	}
+
+	suggester := search.NewSuggester(repository.NewRepo(database).BookRepo.GetCatalogEntries)
+	suggester.Invalidate()
+
+	bus := events.NewBus()
+	bus.Subscribe(func(models.Event) { suggester.Invalidate() },
+		models.EventBookCreated, models.EventBookUpdated, models.EventBookDeleted, models.EventBookRestored,
+		models.EventAuthorUpdated, models.EventAuthorMerged)
+	if _, err := startJobs(context.Background(), database, bus, suggester, store); err != nil {
+		log.Fatal("start jobs:", err)
 	}
+
+	provider, err := metadata.FromEnv()
+	if err != nil {
+		log.Fatal("metadata provider:", err)
+	}
+
 	r := gin.Default()
 
-	libhttp.RegisterRoutes(r, database)
+	libhttp.RegisterRoutes(r, database, suggester, provider, store)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -0,0 +1,358 @@
+// Package bulk reads and writes spreadsheet-style files, CSV or JSON Lines,
+// as rows of named fields. Header columns are matched to fields by name,
+// by one of a field's aliases, or by an explicit mapping.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var ErrFormat = errors.New("format must be csv or jsonl")
+
+// ListSep separates the values of a list field (authors, subjects) in one
+// cell.
+const ListSep = ";"
+
+// Field is a column an import understands. Aliases are other header names
+// it goes by, compared like the name: case, spaces and dashes aside.
+type Field struct {
+	Name    string
+	Aliases []string
+}
+
+// Row is one data line. N counts data lines from 1; Values holds only the
+// fields the file has a column (or JSON key) for.
+type Row struct {
+	N      int
+	Values map[string]string
+}
+
+func (r Row) Get(field string) string {
+	return strings.TrimSpace(r.Values[field])
+}
+
+// List splits a list field on ListSep, dropping empty parts.
+func (r Row) List(field string) []string {
+	var out []string
+	for _, v := range strings.Split(r.Values[field], ListSep) {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// headerKey is how header names and aliases are compared.
+func headerKey(s string) string {
+	s = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(s, "\uFEFF")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(s)
+}
+
+// mapper resolves header names to fields.
+type mapper struct {
+	known   map[string]string
+	mapping map[string]string
+	ignored map[string]bool
+}
+
+// newMapper checks mapping, which takes a header name to a field name, or
+// to "" to ignore that column.
+func newMapper(fields []Field, mapping map[string]string) (*mapper, error) {
+	m := &mapper{known: map[string]string{}, mapping: map[string]string{}, ignored: map[string]bool{}}
+	for _, f := range fields {
+		m.known[headerKey(f.Name)] = f.Name
+		for _, a := range f.Aliases {
+			m.known[headerKey(a)] = f.Name
+		}
+	}
+	names := map[string]bool{}
+	for _, f := range fields {
+		names[f.Name] = true
+	}
+	for column, field := range mapping {
+		if field != "" && !names[field] {
+			return nil, fmt.Errorf("mapping for %q: unknown field %q", column, field)
+		}
+		m.mapping[headerKey(column)] = field
+	}
+	return m, nil
+}
+
+// field returns the field a column holds, or "" for one to ignore.
+func (m *mapper) field(column string) string {
+	key := headerKey(column)
+	field, ok := m.mapping[key]
+	if !ok {
+		field = m.known[key]
+	}
+	if field == "" && strings.TrimSpace(column) != "" {
+		m.ignored[column] = true
+	}
+	return field
+}
+
+// Reader yields the rows of a file and io.EOF after the last. A row that
+// can't be read comes back as a *RowError and the next Read goes on after
+// it.
+type Reader interface {
+	Read() (Row, error)
+	// Ignored lists the columns seen so far that matched no field.
+	Ignored() []string
+}
+
+// NewReader reads a CSV file, whose first line is the header, or a JSON
+// Lines file of one object per line.
+func NewReader(r io.Reader, format string, fields []Field, mapping map[string]string) (Reader, error) {
+	m, err := newMapper(fields, mapping)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r, m)
+	case FormatJSONL:
+		return &jsonlReader{s: newLineScanner(r), m: m}, nil
+	}
+	return nil, ErrFormat
+}
+
+func ignored(m *mapper) []string {
+	out := make([]string, 0, len(m.ignored))
+	for c := range m.ignored {
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+type csvReader struct {
+	r       *csv.Reader
+	m       *mapper
+	columns []string
+	n       int
+}
+
+func newCSVReader(r io.Reader, m *mapper) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty, expected a header line")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(header))
+	seen := map[string]string{}
+	for i, h := range header {
+		field := m.field(h)
+		if field == "" {
+			continue
+		}
+		if other, dup := seen[field]; dup {
+			return nil, fmt.Errorf("columns %q and %q are both %s", other, h, field)
+		}
+		seen[field] = h
+		columns[i] = field
+	}
+	if len(seen) == 0 {
+		return nil, errors.New("no column in the header matches a known field")
+	}
+	return &csvReader{r: cr, m: m, columns: columns}, nil
+}
+
+func (cr *csvReader) Read() (Row, error) {
+	for {
+		rec, err := cr.r.Read()
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			// the reader has moved past the bad line, so the rest of
+			// the file can still be read
+			cr.n++
+			return Row{}, &RowError{N: cr.n, Err: fmt.Errorf("line %d: %w", perr.Line, perr.Err)}
+		}
+		if err != nil {
+			return Row{}, err
+		}
+		if blank(rec) {
+			continue
+		}
+		cr.n++
+		row := Row{N: cr.n, Values: map[string]string{}}
+		for i, field := range cr.columns {
+			if field == "" {
+				continue
+			}
+			if i < len(rec) {
+				row.Values[field] = rec[i]
+			} else {
+				row.Values[field] = ""
+			}
+		}
+		return row, nil
+	}
+}
+
+// blank reports a line of empty cells, as spreadsheets leave at the end.
+func blank(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (cr *csvReader) Ignored() []string {
+	return ignored(cr.m)
+}
+
+// maxLine caps one JSON Lines record.
+const maxLine = 1 << 20
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64<<10), maxLine)
+	return s
+}
+
+type jsonlReader struct {
+	s *bufio.Scanner
+	m *mapper
+	n int
+}
+
+// RowError is a data line that could not be read; reading can go on with
+// the next one.
+type RowError struct {
+	N   int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.N, e.Err)
+}
+
+func (jr *jsonlReader) Read() (Row, error) {
+	for jr.s.Scan() {
+		line := bytes.TrimSpace(jr.s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		jr.n++
+		var obj map[string]interface{}
+		d := json.NewDecoder(bytes.NewReader(line))
+		d.UseNumber()
+		if err := d.Decode(&obj); err != nil {
+			return Row{}, &RowError{N: jr.n, Err: errors.New("not a JSON object")}
+		}
+		row := Row{N: jr.n, Values: map[string]string{}}
+		for key, v := range obj {
+			field := jr.m.field(key)
+			if field == "" {
+				continue
+			}
+			s, err := cell(v)
+			if err != nil {
+				return Row{}, &RowError{N: jr.n, Err: fmt.Errorf("%s: %v", key, err)}
+			}
+			row.Values[field] = s
+		}
+		return row, nil
+	}
+	if err := jr.s.Err(); err != nil {
+		return Row{}, err
+	}
+	return Row{}, io.EOF
+}
+
+func (jr *jsonlReader) Ignored() []string {
+	return ignored(jr.m)
+}
+
+// cell flattens a JSON value the way it would be written in a CSV cell;
+// arrays are joined with ListSep.
+func cell(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			if _, nested := e.([]interface{}); nested {
+				return "", errors.New("nested arrays are not supported")
+			}
+			s, err := cell(e)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, ListSep+" "), nil
+	}
+	return "", errors.New("objects are not supported")
+}
+
+// Writer writes rows with a fixed set of columns.
+type Writer struct {
+	columns []string
+	csv     *csv.Writer
+	json    *json.Encoder
+	bw      *bufio.Writer
+}
+
+func NewWriter(w io.Writer, format string, columns []string) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &Writer{columns: columns, csv: cw}, nil
+	case FormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &Writer{columns: columns, json: json.NewEncoder(bw), bw: bw}, nil
+	}
+	return nil, ErrFormat
+}
+
+// Write writes one row; values are in column order.
+func (w *Writer) Write(values []string) error {
+	if w.csv != nil {
+		return w.csv.Write(values)
+	}
+	obj := make(map[string]string, len(values))
+	for i, v := range values {
+		obj[w.columns[i]] = v
+	}
+	return w.json.Encode(obj)
+}
+
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return w.bw.Flush()
+}
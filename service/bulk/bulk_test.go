@@ -0,0 +1,74 @@
+package bulk
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var testFields = []Field{{Name: "isbn"}, {Name: "title"}}
+
+func TestCSVReaderSkipsMalformedRows(t *testing.T) {
+	in := "isbn,title\n" +
+		"111,First\n" +
+		"222,\"Unterminated \"quote\n" +
+		"333,Third\n"
+	rr, err := NewReader(strings.NewReader(in), FormatCSV, testFields, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	var bad []int
+	for {
+		row, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		var rerr *RowError
+		if errors.As(err, &rerr) {
+			bad = append(bad, rerr.N)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("read stopped: %v", err)
+		}
+		titles = append(titles, row.Get("title"))
+	}
+	if strings.Join(titles, ",") != "First,Third" {
+		t.Errorf("titles = %v, want First and Third", titles)
+	}
+	if len(bad) != 1 || bad[0] != 2 {
+		t.Errorf("bad rows = %v, want [2]", bad)
+	}
+}
+
+func TestJSONLReaderSkipsMalformedRows(t *testing.T) {
+	in := `{"isbn": "111", "title": "First"}` + "\n" +
+		"not json\n" +
+		`{"isbn": "333", "title": "Third"}` + "\n"
+	rr, err := NewReader(strings.NewReader(in), FormatJSONL, testFields, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for {
+		row, err := rr.Read()
+		if err == io.EOF {
+			break
+		}
+		var rerr *RowError
+		if errors.As(err, &rerr) {
+			rows = append(rows, "error")
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row.Get("title"))
+	}
+	if got := strings.Join(rows, ","); got != "First,error,Third" {
+		t.Errorf("rows = %s, want First,error,Third", got)
+	}
+}
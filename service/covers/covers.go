@@ -0,0 +1,167 @@
+// Package covers checks uploaded cover images, renders their thumbnails
+// and keeps the files in a Storage.
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	MaxBytes = 5 << 20
+	// maxPixels keeps a small file that claims huge dimensions from
+	// taking all the memory when decoded: the decoded image and its
+	// flattened copy take up to 8 bytes a pixel, 200 MB at this size.
+	maxPixels = 25_000_000
+	minSide   = 32
+
+	thumbnailQuality = 85
+)
+
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+)
+
+// Size is a thumbnail that fits in Width x Height.
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+const Original = "original"
+
+var Sizes = []Size{
+	{Name: "small", Width: 80, Height: 120},
+	{Name: "medium", Width: 200, Height: 300},
+	{Name: "large", Width: 400, Height: 600},
+}
+
+// IsSize reports whether name is a thumbnail size or Original.
+func IsSize(name string) bool {
+	if name == Original {
+		return true
+	}
+	for _, s := range Sizes {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Image is a checked upload and its thumbnails, which are JPEG whatever
+// the original was.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+	Thumbnails  map[string][]byte
+}
+
+// Process checks that data is a JPEG or PNG image of sensible dimensions
+// and renders every thumbnail size.
+func Process(data []byte) (*Image, error) {
+	if len(data) == 0 {
+		return nil, errors.New("image is empty")
+	}
+	if len(data) > MaxBytes {
+		return nil, fmt.Errorf("image is larger than %d MB", MaxBytes>>20)
+	}
+	ct := http.DetectContentType(data)
+	if ct != TypeJPEG && ct != TypePNG {
+		return nil, errors.New("image must be a JPEG or PNG")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image can't be read: %v", err)
+	}
+	if cfg.Width < minSide || cfg.Height < minSide {
+		return nil, fmt.Errorf("image must be at least %dx%d pixels", minSide, minSide)
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, errors.New("image has too many pixels")
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image can't be read: %v", err)
+	}
+
+	img := &Image{ContentType: ct, Width: cfg.Width, Height: cfg.Height, Data: data, Thumbnails: map[string][]byte{}}
+	flat := flatten(src)
+	for _, s := range Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(flat, s.Width, s.Height), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return nil, err
+		}
+		img.Thumbnails[s.Name] = buf.Bytes()
+	}
+	return img, nil
+}
+
+// flatten draws src on white, as JPEG has no transparency.
+func flatten(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+// resize scales src down to fit in w x h, keeping its proportions, by
+// averaging the source pixels under each target pixel. Smaller images are
+// left as they are.
+func resize(src *image.RGBA, w, h int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= w && sh <= h {
+		return src
+	}
+	if sw*h > sh*w {
+		h = max(1, sh*w/sw)
+	} else {
+		w = max(1, sw*h/sh)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
+
+// Key is where the file of one size of a cover is stored. The etag makes a
+// replaced cover's files distinct from the old ones.
+func Key(bookID int64, etag, size, contentType string) string {
+	ext := "jpg"
+	if size == Original && contentType == TypePNG {
+		ext = "png"
+	}
+	return fmt.Sprintf("books/%d/%s-%s.%s", bookID, etag, size, ext)
+}
@@ -0,0 +1,156 @@
+package covers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// pngHeader is the start of a PNG that claims w x h pixels, which is all
+// DecodeConfig reads.
+func pngHeader(w, h uint32) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], w)
+	binary.BigEndian.PutUint32(ihdr[8:], h)
+	ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = binary.BigEndian.AppendUint32(out, 13)
+	out = append(out, ihdr...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(ihdr))
+}
+
+func TestProcessRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "empty"},
+		{"too large", make([]byte, MaxBytes+1), "larger than"},
+		{"not an image", []byte("%PDF-1.7 not a cover"), "JPEG or PNG"},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), "JPEG or PNG"},
+		{"truncated", encodePNG(t, 40, 40)[:60], "can't be read"},
+		{"too small", encodePNG(t, 31, 100), "at least 32x32"},
+		{"too many pixels", pngHeader(6000, 5000), "too many pixels"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Process(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, %v; want an error containing %q", img, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	img, err := Process(encodePNG(t, 300, 150))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ContentType != TypePNG || img.Width != 300 || img.Height != 150 {
+		t.Errorf("got %s %dx%d, want image/png 300x150", img.ContentType, img.Width, img.Height)
+	}
+	want := map[string][2]int{"small": {80, 40}, "medium": {200, 100}, "large": {300, 150}}
+	for _, s := range Sizes {
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(img.Thumbnails[s.Name]))
+		if err != nil {
+			t.Fatalf("%s: %v", s.Name, err)
+		}
+		if format != "jpeg" || cfg.Width != want[s.Name][0] || cfg.Height != want[s.Name][1] {
+			t.Errorf("%s: %s %dx%d, want jpeg %dx%d", s.Name, format, cfg.Width, cfg.Height, want[s.Name][0], want[s.Name][1])
+		}
+	}
+}
+
+func TestProcessFlattensOnWhite(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64)) // fully transparent
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(img.Thumbnails["small"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := thumb.At(10, 10).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel came out as %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		sw, sh, w, h int
+		wantW, wantH int
+	}{
+		{1000, 1500, 80, 120, 80, 120},
+		{1000, 1000, 80, 120, 80, 80},
+		{1200, 600, 200, 300, 200, 100},
+		{600, 1800, 200, 300, 100, 300},
+		{5000, 1, 80, 120, 80, 1},
+		{1, 5000, 80, 120, 1, 120},
+		{60, 90, 80, 120, 60, 90},
+	}
+	for _, tt := range tests {
+		src := image.NewRGBA(image.Rect(0, 0, tt.sw, tt.sh))
+		got := resize(src, tt.w, tt.h).Bounds()
+		if got.Min != (image.Point{}) || got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("resize %dx%d into %dx%d = %v, want %dx%d", tt.sw, tt.sh, tt.w, tt.h, got, tt.wantW, tt.wantH)
+		}
+		if got.Dx() > tt.w || got.Dy() > tt.h {
+			t.Errorf("resize %dx%d into %dx%d = %v, larger than the box", tt.sw, tt.sh, tt.w, tt.h, got)
+		}
+	}
+}
+
+func TestResizeAverages(t *testing.T) {
+	// a 2x1 image of black and white pixels scales to one grey pixel
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(1, 0, color.White)
+	src.Set(0, 0, color.Black)
+	got := resize(src, 1, 1)
+	if c := got.RGBAAt(0, 0); c.R != 127 || c.G != 127 || c.B != 127 || c.A != 255 {
+		t.Errorf("got %v, want grey", c)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		size, contentType, want string
+	}{
+		{Original, TypePNG, "books/7/abc-original.png"},
+		{Original, TypeJPEG, "books/7/abc-original.jpg"},
+		{"small", TypePNG, "books/7/abc-small.jpg"},
+	}
+	for _, tt := range tests {
+		if got := Key(7, "abc", tt.size, tt.contentType); got != tt.want {
+			t.Errorf("Key(%s, %s) = %s, want %s", tt.size, tt.contentType, got, tt.want)
+		}
+	}
+}
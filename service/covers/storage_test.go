@@ -0,0 +1,54 @@
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStoragePathRejectsTraversal(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "..", "../secret", "books/../../secret", "/etc/passwd", "books/7/../x.jpg"} {
+		if _, err := s.path(key); err == nil {
+			t.Errorf("path(%q) was accepted", key)
+		}
+		if err := s.Put(key, []byte("x")); err == nil {
+			t.Errorf("Put(%q) was accepted", key)
+		}
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := "books/7/abc-small.jpg"
+	if _, err := s.Get(key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get before Put: %v, want ErrNotFound", err)
+	}
+	if err := s.Put(key, []byte("jpeg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "books", "7", "abc-small.jpg")); err != nil {
+		t.Errorf("file not under the storage dir: %v", err)
+	}
+	if got, err := s.Get(key); err != nil || !bytes.Equal(got, []byte("jpeg")) {
+		t.Errorf("Get = %q, %v", got, err)
+	}
+	if err := s.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(key); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+	if _, err := s.Get(key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v, want ErrNotFound", err)
+	}
+}
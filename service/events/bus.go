@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"library-management/service/models"
+)
+
+// Bus is an in-process sink: subscribers in this process are called
+// synchronously for each dispatched event. It only sees events dispatched
+// by this instance, so anything that must observe every event should use
+// a durable sink instead.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []subscription
+}
+
+type subscription struct {
+	types map[string]bool
+	fn    func(models.Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers fn for the given event types, or for every event when
+// none are given.
+func (b *Bus) Subscribe(fn func(models.Event), types ...string) {
+	sub := subscription{fn: fn}
+	if len(types) > 0 {
+		sub.types = map[string]bool{}
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+}
+
+func (b *Bus) Name() string { return "bus" }
+
+func (b *Bus) Publish(ctx context.Context, e models.Event) error {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.types != nil && !sub.types[e.Type] {
+			continue
+		}
+		call(sub.fn, e)
+	}
+	return nil
+}
+
+func call(fn func(models.Event), e models.Event) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("event bus subscriber panicked on %s: %v", e.Type, p)
+		}
+	}()
+	fn(e)
+}
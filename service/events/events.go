@@ -0,0 +1,117 @@
+// Package events dispatches the domain events recorded in the outbox table
+// to sinks. Delivery is at least once: an event is marked dispatched only
+// after every sink accepted it, so a failing sink means the others may see
+// it again. Consumers should deduplicate on Event.ID.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+const (
+	dispatchBatch = 100
+	// MaxAttempts is how often an event is offered to the sinks before it
+	// is marked dead and left out of dispatching.
+	MaxAttempts = 12
+	baseRetry   = 5 * time.Second
+	maxRetry    = 10 * time.Minute
+)
+
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e models.Event) error
+}
+
+type Dispatcher struct {
+	repo  *repository.Repo
+	sinks []Sink
+}
+
+func NewDispatcher(repo *repository.Repo, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{repo: repo, sinks: sinks}
+}
+
+func (d *Dispatcher) AddSink(s Sink) {
+	d.sinks = append(d.sinks, s)
+}
+
+// Dispatch publishes pending events in order until none are left or a sink
+// fails. The failing event stays pending, with its error recorded, and
+// holds back the events after it so sinks see them in order. It is retried
+// after RetryAfter; once it has failed MaxAttempts times it is marked dead,
+// keeping its last error, and the events behind it go ahead.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := d.dispatchBatch(ctx)
+		total += n
+		if err != nil || n < dispatchBatch {
+			return total, err
+		}
+	}
+}
+
+func (d *Dispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	dispatched := 0
+	var sinkErr error
+	err := d.repo.WithTx(ctx, func(tx *repository.Repo) error {
+		pending, err := tx.OutboxRepo.GetPendingForUpdate(dispatchBatch)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, e := range pending {
+			if e.NextAttemptAt != nil && e.NextAttemptAt.After(now) {
+				return nil
+			}
+			if sinkErr = d.publish(ctx, e); sinkErr != nil {
+				attempts := e.Attempts + 1
+				if attempts < MaxAttempts {
+					return tx.OutboxRepo.MarkFailed(e.Seq, sinkErr.Error(), now.Add(RetryAfter(attempts)))
+				}
+				log.Printf("outbox: event %d (%s) is dead after %d attempts: %v", e.Seq, e.Type, attempts, sinkErr)
+				if err := tx.OutboxRepo.MarkDead(e.Seq, sinkErr.Error()); err != nil {
+					return err
+				}
+				sinkErr = nil
+				continue
+			}
+			if err := tx.OutboxRepo.MarkDispatched(e.Seq, time.Now()); err != nil {
+				return err
+			}
+			dispatched++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return dispatched, sinkErr
+}
+
+// RetryAfter is the wait before an event is offered again after the given
+// number of failed attempts: 5s doubling up to 10m.
+func RetryAfter(attempts int) time.Duration {
+	d := baseRetry
+	for i := 1; i < attempts && d < maxRetry; i++ {
+		d *= 2
+	}
+	return min(d, maxRetry)
+}
+
+func (d *Dispatcher) publish(ctx context.Context, e models.Event) error {
+	var errs []error
+	for _, s := range d.sinks {
+		if err := s.Publish(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s sink: %w", s.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
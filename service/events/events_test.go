@@ -0,0 +1,35 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{5, 80 * time.Second},
+		{7, 320 * time.Second},
+		{8, 10 * time.Minute},
+		{MaxAttempts, 10 * time.Minute},
+		{1000, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := RetryAfter(tt.attempts); got != tt.want {
+			t.Errorf("RetryAfter(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+
+	var total time.Duration
+	for n := 1; n < MaxAttempts; n++ {
+		total += RetryAfter(n)
+	}
+	if total < 30*time.Minute || total > 2*time.Hour {
+		t.Errorf("an event is dead %v after its first failure, want between 30m and 2h", total)
+	}
+}
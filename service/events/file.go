@@ -0,0 +1,43 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"library-management/service/models"
+)
+
+// FileSink appends every event as one JSON line (NDJSON).
+type FileSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{f: f}, nil
+}
+
+func (s *FileSink) Name() string { return "file" }
+
+func (s *FileSink) Publish(ctx context.Context, e models.Event) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.f.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+const maxAuditPage = 1000
+
+// AuditSnapshot loads the current state of an audited entity as JSON, or
+// nil when it doesn't exist (yet, or any more). The entity type is the
+// prefix of the audit action, e.g. "book" for "book.update".
+func AuditSnapshot(r *repository.Repo, entity, key string) (json.RawMessage, error) {
+	if key == "" {
+		return nil, nil
+	}
+	id, _ := strconv.ParseInt(key, 10, 64)
+
+	var v interface{}
+	var err error
+	switch entity {
+	case "book":
+		v, err = r.BookRepo.GetByIDWithDeleted(id)
+	case "copy":
+		v, err = r.CopyRepo.GetByID(id)
+	case "author":
+		v, err = r.AuthorRepo.GetByID(id)
+	case "member":
+		v, err = r.MemberRepo.GetByIDWithDeleted(id)
+	case "issue":
+		v, err = r.IssueRepo.GetByID(id)
+	case "import":
+		v, err = r.ImportRepo.GetByID(id)
+	case "hold":
+		v, err = r.HoldRepo.GetByID(id)
+	case "fine":
+		v, err = GetFine(r, id)
+	case "balance":
+		v, err = MemberBalance(r, id)
+	case "policy":
+		v, err = r.PolicyRepo.GetByCategory(key)
+	case "staff":
+		v, err = GetStaff(r, id)
+	case "role":
+		v, err = r.RoleRepo.GetByID(id)
+	case "webhook":
+		v, err = GetWebhook(r, id)
+	case "webhook_delivery":
+		v, err = r.WebhookRepo.GetDelivery(id)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// RecordAudit appends an entry, filling in the diff of its snapshots.
+func RecordAudit(r *repository.Repo, e *models.AuditEntry) error {
+	if e.EntityType == "" {
+		e.EntityType, _, _ = strings.Cut(e.Action, ".")
+	}
+	diff, err := jsonDiff(e.Before, e.After)
+	if err != nil {
+		return err
+	}
+	e.Diff = diff
+	_, err = r.AuditRepo.Append(e)
+	return err
+}
+
+func ListAudit(r *repository.Repo, f models.AuditFilter) ([]models.AuditEntry, error) {
+	if f.Limit <= 0 || f.Limit > maxAuditPage {
+		f.Limit = 100
+	}
+	return r.AuditRepo.Find(f)
+}
+
+// jsonDiff compares the top-level fields of two JSON objects. A missing
+// side (entity created or deleted) shows up as null.
+func jsonDiff(before, after json.RawMessage) (json.RawMessage, error) {
+	if len(before) == 0 && len(after) == 0 {
+		return nil, nil
+	}
+	var b, a map[string]interface{}
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &b); err != nil {
+			return nil, nil
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &a); err != nil {
+			return nil, nil
+		}
+	}
+
+	type change struct {
+		From interface{} `json:"from"`
+		To   interface{} `json:"to"`
+	}
+	diff := map[string]change{}
+	for k, from := range b {
+		if to, ok := a[k]; !ok || !reflect.DeepEqual(from, to) {
+			diff[k] = change{From: from, To: a[k]}
+		}
+	}
+	for k, to := range a {
+		if _, ok := b[k]; !ok {
+			diff[k] = change{To: to}
+		}
+	}
+	if len(diff) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(diff)
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+const (
+	DefaultSessionTTL = 12 * time.Hour
+	minPasswordLength = 8
+)
+
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidSession     = errors.New("invalid or expired session")
+)
+
+func HashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", errors.New("password must be at least 8 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CreateAdmin(r *repository.Repo, username, password string) (int64, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return 0, errors.New("username is required")
+	}
+
+	existing, err := r.AdminRepo.GetByUsername(username)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		return 0, errors.New("username already taken")
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return 0, err
+	}
+	return r.AdminRepo.Create(&models.Admin{Username: username, PasswordHash: hash})
+}
+
+// Login verifies the credentials and issues an opaque session token. Only
+// the SHA-256 of the token is stored, so a leaked sessions table can't be
+// replayed.
+func Login(r *repository.Repo, username, password string, ttl time.Duration) (string, time.Time, error) {
+	admin, err := r.AdminRepo.GetByUsername(username)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if admin == nil {
+		// keep timing similar to the wrong-password path
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.PasswordHash), []byte(password)); err != nil {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+	if !admin.Active {
+		return "", time.Time{}, ErrInvalidCredentials
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	_, err = r.SessionRepo.Create(&models.AdminSession{
+		AdminID:   admin.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+func Authenticate(r *repository.Repo, token string) (*models.Admin, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+	session, err := r.SessionRepo.GetActiveByTokenHash(hashToken(token), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrInvalidSession
+	}
+
+	admin, err := r.AdminRepo.GetByID(session.AdminID)
+	if err != nil {
+		return nil, err
+	}
+	if admin == nil || !admin.Active {
+		return nil, ErrInvalidSession
+	}
+	if err := loadAccess(r, admin); err != nil {
+		return nil, err
+	}
+	return admin, nil
+}
+
+func Logout(r *repository.Repo, token string) error {
+	revoked, err := r.SessionRepo.Revoke(hashToken(token), time.Now())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+func LogoutEverywhere(r *repository.Repo, adminID int64) error {
+	return r.SessionRepo.RevokeAllForAdmin(adminID, time.Now())
+}
+
+func newSessionToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,429 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// maxAuthorLine is the size of books.author.
+const maxAuthorLine = 255
+
+// authorKey is what two spellings of one name have in common: the letters
+// and digits, lowercased. Migration 0019 could only strip common
+// punctuation in SQL; RekeyAuthors brings its keys in line.
+func authorKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// authorLine renders contributors for books.author: the authors, or when a
+// book has none, everyone with their role ("Ann Lee (editor)").
+func authorLine(contributors []models.Contributor) string {
+	var names []string
+	for _, c := range contributors {
+		if c.Role == models.RoleAuthor {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, c := range contributors {
+			names = append(names, fmt.Sprintf("%s (%s)", c.Name, c.Role))
+		}
+	}
+	line := strings.Join(names, ", ")
+	if len(line) > maxAuthorLine && len(names) > 0 {
+		line = names[0] + " et al."
+	}
+	return line
+}
+
+// checkContributors trims names and defaults roles. Contributors still
+// need resolveContributors before they can be stored.
+func checkContributors(contributors []models.Contributor) error {
+	if len(contributors) == 0 {
+		return errors.New("a book needs at least one author or contributor")
+	}
+	for i := range contributors {
+		c := &contributors[i]
+		c.Name = strings.Join(strings.Fields(c.Name), " ")
+		if c.Role == "" {
+			c.Role = models.RoleAuthor
+		}
+		if !models.IsContributorRole(c.Role) {
+			return fmt.Errorf("role must be one of %s", strings.Join(models.ContributorRoles, ", "))
+		}
+		if c.AuthorID == 0 && authorKey(c.Name) == "" {
+			return errors.New("each contributor needs an author_id or a name")
+		}
+		if len(c.Name) > 255 {
+			return errors.New("contributor name is too long")
+		}
+	}
+	return nil
+}
+
+// resolveContributors points every contributor at an author, creating
+// authors for names not seen before, and drops repeats of the same author
+// in the same role.
+func resolveContributors(tx *repository.Repo, contributors []models.Contributor) ([]models.Contributor, error) {
+	type credit struct {
+		author int64
+		role   string
+	}
+	seen := map[credit]bool{}
+	out := make([]models.Contributor, 0, len(contributors))
+	for _, c := range contributors {
+		a, err := findOrCreateAuthor(tx, c)
+		if err != nil {
+			return nil, err
+		}
+		if seen[credit{a.ID, c.Role}] {
+			continue
+		}
+		seen[credit{a.ID, c.Role}] = true
+		out = append(out, models.Contributor{AuthorID: a.ID, Name: a.Name, Role: c.Role})
+	}
+	return out, nil
+}
+
+func findOrCreateAuthor(tx *repository.Repo, c models.Contributor) (*models.Author, error) {
+	if c.AuthorID != 0 {
+		a, err := tx.AuthorRepo.GetByID(c.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		if a == nil {
+			return nil, fmt.Errorf("author %d not found", c.AuthorID)
+		}
+		return a, nil
+	}
+	key := authorKey(c.Name)
+	a, err := tx.AuthorRepo.GetByKey(key)
+	if err != nil || a != nil {
+		return a, err
+	}
+	id, err := tx.AuthorRepo.Create(c.Name, key)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Author{ID: id, Name: c.Name}, nil
+}
+
+// refreshAuthorLines rebuilds books.author for books whose contributors'
+// names changed.
+func refreshAuthorLines(tx *repository.Repo, bookIDs []int64) error {
+	for _, id := range bookIDs {
+		contributors, err := tx.BookRepo.GetContributors(id)
+		if err != nil {
+			return err
+		}
+		if err := tx.BookRepo.SetAuthor(id, authorLine(contributors)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ListAuthors(r *repository.Repo, f models.AuthorFilter) (models.Page[models.Author], error) {
+	pageDefaults(&f.ListOptions, "name")
+	f.Query = strings.TrimSpace(f.Query)
+	return r.AuthorRepo.List(f)
+}
+
+// GetAuthor returns an author with every book they contributed to.
+func GetAuthor(r *repository.Repo, id int64) (*models.AuthorDetail, error) {
+	a, err := r.AuthorRepo.GetByID(id)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	works, err := r.AuthorRepo.GetWorks(id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthorDetail{Author: *a, Works: works}, nil
+}
+
+// RenameAuthor corrects an author's name on all their books at once. A
+// name that is another author's spelling has to be merged instead.
+func RenameAuthor(ctx context.Context, r *repository.Repo, id int64, name string) error {
+	name = strings.Join(strings.Fields(name), " ")
+	key := authorKey(name)
+	if key == "" {
+		return errors.New("name is required")
+	}
+	if len(name) > 255 {
+		return errors.New("name is too long")
+	}
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		a, err := tx.AuthorRepo.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			return errors.New("author not found")
+		}
+		other, err := tx.AuthorRepo.GetByKey(key)
+		if err != nil {
+			return err
+		}
+		if other != nil && other.ID != id {
+			return fmt.Errorf("author %d is already named %s, merge them instead", other.ID, other.Name)
+		}
+
+		if err := tx.AuthorRepo.Update(id, name, key); err != nil {
+			return err
+		}
+		books, err := tx.AuthorRepo.GetBookIDs(id)
+		if err != nil {
+			return err
+		}
+		if err := refreshAuthorLines(tx, books); err != nil {
+			return err
+		}
+		a.Name = name
+		return publish(tx, models.EventAuthorUpdated, id, a)
+	})
+}
+
+// MergeAuthors folds the authors in from into author id: their books are
+// credited to id in the same roles and they are deleted.
+func MergeAuthors(ctx context.Context, r *repository.Repo, id int64, from []int64) (*models.Author, error) {
+	if len(from) == 0 {
+		return nil, errors.New("author_ids is required")
+	}
+	var merged *models.Author
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		target, err := tx.AuthorRepo.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			return errors.New("author not found")
+		}
+
+		var books []int64
+		for _, fromID := range from {
+			if fromID == id {
+				return errors.New("cannot merge an author into itself")
+			}
+			a, err := tx.AuthorRepo.GetByIDForUpdate(fromID)
+			if err != nil {
+				return err
+			}
+			if a == nil {
+				return fmt.Errorf("author %d not found", fromID)
+			}
+			ids, err := tx.AuthorRepo.GetBookIDs(fromID)
+			if err != nil {
+				return err
+			}
+			books = append(books, ids...)
+			if err := tx.AuthorRepo.MoveContributions(fromID, id); err != nil {
+				return err
+			}
+			if err := tx.AuthorRepo.Delete(fromID); err != nil {
+				return err
+			}
+		}
+		if err := refreshAuthorLines(tx, books); err != nil {
+			return err
+		}
+
+		if merged, err = tx.AuthorRepo.GetByID(id); err != nil {
+			return err
+		}
+		return publish(tx, models.EventAuthorMerged, id, map[string]interface{}{
+			"author":   merged,
+			"merged":   from,
+			"book_ids": books,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// FindDuplicateAuthors groups authors that are probably one person:
+// "Tolkien, J.R.R." and "J.R.R. Tolkien" (inverted), or "J. Tolkien" and
+// "John Tolkien" (initials). Merging is left to a librarian.
+// authorRekey is one author whose name_key is not authorKey of its name:
+// it takes key, or when another author holds key already, is merged into
+// that one.
+type authorRekey struct {
+	id   int64
+	name string
+	key  string
+	into int64
+}
+
+func planAuthorRekeys(authors []models.Author) []authorRekey {
+	holder := make(map[string]int64, len(authors))
+	for _, a := range authors {
+		holder[a.NameKey] = a.ID
+	}
+	var plan []authorRekey
+	for _, a := range authors {
+		key := authorKey(a.Name)
+		if key == a.NameKey || key == "" {
+			continue
+		}
+		step := authorRekey{id: a.ID, name: a.Name, key: key, into: holder[key]}
+		if step.into == 0 {
+			holder[key] = a.ID
+		}
+		if holder[a.NameKey] == a.ID {
+			delete(holder, a.NameKey)
+		}
+		plan = append(plan, step)
+	}
+	return plan
+}
+
+// RekeyAuthors sets every author's name_key to authorKey of the name. An
+// author whose key another one holds is merged into it, as the catalog
+// would have matched the two. It returns how many authors were rekeyed or
+// merged, and does nothing once every key is right.
+func RekeyAuthors(ctx context.Context, r *repository.Repo) (int, error) {
+	authors, err := r.AuthorRepo.GetKeys()
+	if err != nil {
+		return 0, err
+	}
+	plan := planAuthorRekeys(authors)
+	for i, step := range plan {
+		if step.into != 0 {
+			_, err = MergeAuthors(ctx, r, step.into, []int64{step.id})
+		} else {
+			err = r.AuthorRepo.Update(step.id, step.name, step.key)
+		}
+		if err != nil {
+			return i, fmt.Errorf("rekey author %d: %w", step.id, err)
+		}
+	}
+	return len(plan), nil
+}
+
+func FindDuplicateAuthors(r *repository.Repo) ([]models.AuthorDuplicates, error) {
+	authors, err := r.AuthorRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []models.AuthorDuplicates{}
+	grouped := map[int64]bool{}
+	add := func(reason string, members []models.Author) {
+		var fresh []models.Author
+		for _, a := range members {
+			if !grouped[a.ID] {
+				fresh = append(fresh, a)
+			}
+		}
+		if len(fresh) < 2 {
+			return
+		}
+		for _, a := range fresh {
+			grouped[a.ID] = true
+		}
+		groups = append(groups, models.AuthorDuplicates{Reason: reason, Authors: fresh})
+	}
+
+	byKey := map[string][]models.Author{}
+	var keys []string
+	for _, a := range authors {
+		k := authorKey(invertName(a.Name))
+		if byKey[k] == nil {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], a)
+	}
+	for _, k := range keys {
+		add("inverted", byKey[k])
+	}
+
+	bySurname := map[string][]models.Author{}
+	var surnames []string
+	for _, a := range authors {
+		parts := nameParts(invertName(a.Name))
+		if len(parts) < 2 {
+			continue
+		}
+		s := parts[len(parts)-1]
+		if bySurname[s] == nil {
+			surnames = append(surnames, s)
+		}
+		bySurname[s] = append(bySurname[s], a)
+	}
+	sort.Strings(surnames)
+	for _, s := range surnames {
+		same := bySurname[s]
+		for i := range same {
+			group := []models.Author{same[i]}
+			for j := i + 1; j < len(same); j++ {
+				if initialsMatch(same[i].Name, same[j].Name) {
+					group = append(group, same[j])
+				}
+			}
+			add("initials", group)
+		}
+	}
+	return groups, nil
+}
+
+// invertName turns "Tolkien, J.R.R." into "J.R.R. Tolkien".
+func invertName(name string) string {
+	last, first, ok := strings.Cut(name, ",")
+	if !ok || strings.Contains(first, ",") || strings.TrimSpace(first) == "" {
+		return name
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// nameParts splits a name into lowercase words, with each letter of
+// run-together initials ("JRR", "J.R.R.") as its own word.
+func nameParts(name string) []string {
+	var parts []string
+	for _, f := range strings.Fields(name) {
+		if strings.Contains(strings.TrimSuffix(f, "."), ".") {
+			for _, p := range strings.Split(f, ".") {
+				if p != "" {
+					parts = append(parts, strings.ToLower(p))
+				}
+			}
+			continue
+		}
+		if p := authorKey(f); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// initialsMatch reports whether two names differ only in spelling given
+// names out or as initials.
+func initialsMatch(a, b string) bool {
+	pa, pb := nameParts(invertName(a)), nameParts(invertName(b))
+	if len(pa) != len(pb) {
+		return false
+	}
+	for i := range pa {
+		x, y := []rune(pa[i]), []rune(pb[i])
+		if string(x) == string(y) {
+			continue
+		}
+		if len(x) > 1 && len(y) > 1 || x[0] != y[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"library-management/service/models"
+)
+
+func TestAuthorKey(t *testing.T) {
+	tests := map[string]string{
+		"J.R.R. Tolkien":         "jrrtolkien",
+		"J. R. R. Tolkien":       "jrrtolkien",
+		"Tolkien, J. R. R.":      "tolkienjrr",
+		"Simon & Schuster":       "simonschuster",
+		"O’Brien: A/B":           "obrienab",
+		"Gabriel García Márquez": "gabrielgarcíamárquez",
+		"Ø. Ekeland (1944-)":     "øekeland1944",
+		" -.;() ":                "",
+	}
+	for name, want := range tests {
+		if got := authorKey(name); got != want {
+			t.Errorf("authorKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPlanAuthorRekeys(t *testing.T) {
+	// name_key as migration 0019 computed it in SQL
+	authors := []models.Author{
+		{ID: 1, Name: "Simon & Schuster", NameKey: "simon&schuster"},
+		{ID: 2, Name: "Simon Schuster", NameKey: "simonschuster"},
+		{ID: 3, Name: "Dana: Collected", NameKey: "dana:collected"},
+		{ID: 4, Name: "Ursula K. Le Guin", NameKey: "ursulakleguin"},
+		{ID: 5, Name: "A/B", NameKey: "a/b"},
+		{ID: 6, Name: "A\\B", NameKey: "a\\b"},
+	}
+	want := []authorRekey{
+		{id: 1, name: "Simon & Schuster", key: "simonschuster", into: 2},
+		{id: 3, name: "Dana: Collected", key: "danacollected"},
+		{id: 5, name: "A/B", key: "ab"},
+		{id: 6, name: "A\\B", key: "ab", into: 5},
+	}
+	if got := planAuthorRekeys(authors); !reflect.DeepEqual(got, want) {
+		t.Errorf("plan = %+v\nwant %+v", got, want)
+	}
+	if got := planAuthorRekeys([]models.Author{{ID: 4, Name: "Ursula K. Le Guin", NameKey: "ursulakleguin"}}); len(got) != 0 {
+		t.Errorf("plan for keys already right = %+v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// BlockError is returned by IssueBook when the member may not borrow. Staff
+// holding circulation:override can retry with an IssueOverride.
+type BlockError struct {
+	Reasons []models.BlockReason
+}
+
+func (e *BlockError) Error() string {
+	msgs := make([]string, len(e.Reasons))
+	for i, r := range e.Reasons {
+		msgs[i] = r.Message
+	}
+	return "circulation blocked: " + strings.Join(msgs, "; ")
+}
+
+func (e *BlockError) Codes() string {
+	codes := make([]string, len(e.Reasons))
+	for i, r := range e.Reasons {
+		codes[i] = r.Code
+	}
+	return strings.Join(codes, ",")
+}
+
+type IssueOverride struct {
+	StaffID       int64
+	Justification string
+}
+
+func CirculationBlocks(r *repository.Repo, memberID int64) ([]models.BlockReason, error) {
+	member, err := r.MemberRepo.GetByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, fmt.Errorf("member not found")
+	}
+	policy, err := policyForMember(r, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return circulationBlocks(r, member, policy)
+}
+
+func circulationBlocks(r *repository.Repo, member *models.Member, policy *models.LoanPolicy) ([]models.BlockReason, error) {
+	reasons := []models.BlockReason{}
+
+	if member.ExpiresAt != nil && *member.ExpiresAt != "" {
+		expires, err := parseDate(*member.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if expires.Before(today()) {
+			reasons = append(reasons, models.BlockReason{
+				Code:    models.BlockMembershipExpired,
+				Message: "membership expired on " + expires.Format("2006-01-02"),
+			})
+		}
+	}
+
+	loans, err := r.IssueRepo.CountActiveByMember(member.ID)
+	if err != nil {
+		return nil, err
+	}
+	if loans >= policy.MaxLoans {
+		reasons = append(reasons, models.BlockReason{
+			Code:    models.BlockLoanLimit,
+			Message: fmt.Sprintf("member has reached the limit of %d loans", policy.MaxLoans),
+		})
+	}
+
+	if policy.BlockOnOverdue {
+		overdue, err := r.IssueRepo.CountOverdueByMember(member.ID, today().Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		if overdue > 0 {
+			reasons = append(reasons, models.BlockReason{
+				Code:    models.BlockOverdueLoans,
+				Message: fmt.Sprintf("member has %d overdue loans", overdue),
+			})
+		}
+	}
+
+	balance, err := MemberBalance(r, member.ID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Outstanding > policy.MaxFineBalance {
+		reasons = append(reasons, models.BlockReason{
+			Code:    models.BlockFinesExceeded,
+			Message: fmt.Sprintf("unpaid fines of %.2f exceed %.2f", balance.Outstanding, policy.MaxFineBalance),
+		})
+	}
+	return reasons, nil
+}
+
+func ListOverrides(r *repository.Repo, memberID int64) ([]models.CirculationOverride, error) {
+	return r.OverrideRepo.GetByMember(memberID)
+}
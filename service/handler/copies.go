@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+func ListCopies(r *repository.Repo, bookID int64) ([]models.BookCopy, error) {
+	return r.CopyRepo.GetByBook(bookID)
+}
+
+func GetCopyByBarcode(r *repository.Repo, barcode string) (*models.BookCopy, error) {
+	return r.CopyRepo.GetByBarcode(barcode)
+}
+
+// AddCopy registers a physical copy. Without a barcode one is generated as
+// B<book id>-<n>, matching the copies created when counters were migrated.
+func AddCopy(ctx context.Context, r *repository.Repo, bookID int64, c *models.BookCopy) (int64, error) {
+	var id int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		var err error
+		id, err = addCopy(tx, bookID, c)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func addCopy(r *repository.Repo, bookID int64, c *models.BookCopy) (int64, error) {
+	book, err := r.BookRepo.GetByIDForUpdate(bookID)
+	if err != nil {
+		return 0, err
+	}
+	if book == nil {
+		return 0, errors.New("book not found")
+	}
+
+	c.BookID = bookID
+	c.Barcode = strings.TrimSpace(c.Barcode)
+	if c.Barcode == "" {
+		n, err := r.CopyRepo.CountByBook(bookID)
+		if err != nil {
+			return 0, err
+		}
+		c.Barcode = fmt.Sprintf("B%d-%d", bookID, n+1)
+	}
+	existing, err := r.CopyRepo.GetByBarcode(c.Barcode)
+	if err != nil {
+		return 0, err
+	}
+	if existing != nil {
+		return 0, fmt.Errorf("barcode %s already in use", c.Barcode)
+	}
+
+	c.Status = models.CopyAvailable
+	if c.Condition == "" {
+		c.Condition = "good"
+	}
+	id, err := r.CopyRepo.Create(c)
+	if err != nil {
+		return 0, err
+	}
+	// a new copy goes to the hold queue first, if there is one
+	if err := releaseCopy(r, bookID, id, models.CopyAvailable); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateCopy edits shelf data and lets staff move a copy between the
+// non-circulating statuses; on_loan and on_hold are only ever set by
+// circulation. Fields the update leaves nil are kept.
+func UpdateCopy(ctx context.Context, r *repository.Repo, id int64, input *models.CopyUpdate) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		return updateCopy(tx, id, input)
+	})
+}
+
+func updateCopy(r *repository.Repo, id int64, input *models.CopyUpdate) error {
+	existing, err := r.CopyRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("copy not found")
+	}
+
+	// everything is checked before anything is saved
+	if input.Barcode != nil {
+		barcode := strings.TrimSpace(*input.Barcode)
+		if barcode == "" {
+			return errors.New("barcode cannot be empty")
+		}
+		if barcode != existing.Barcode {
+			other, err := r.CopyRepo.GetByBarcode(barcode)
+			if err != nil {
+				return err
+			}
+			if other != nil {
+				return fmt.Errorf("barcode %s already in use", barcode)
+			}
+		}
+		existing.Barcode = barcode
+	}
+	if input.Condition != nil {
+		if *input.Condition == "" {
+			return errors.New("condition cannot be empty")
+		}
+		existing.Condition = *input.Condition
+	}
+	changeStatus := input.Status != nil && *input.Status != existing.Status
+	if changeStatus {
+		switch *input.Status {
+		case models.CopyAvailable, models.CopyDamaged, models.CopyLost, models.CopyWithdrawn:
+		default:
+			return fmt.Errorf("status %q cannot be set directly", *input.Status)
+		}
+		switch existing.Status {
+		case models.CopyOnLoan:
+			return errors.New("copy is on loan; return it first")
+		case models.CopyOnHold:
+			return errors.New("copy is held for a member; cancel the hold first")
+		}
+	}
+	if input.AccessionNo != nil {
+		existing.AccessionNo = input.AccessionNo
+		if *input.AccessionNo == "" {
+			existing.AccessionNo = nil
+		}
+	}
+	if input.Location != nil {
+		existing.Location = *input.Location
+	}
+
+	if err := r.CopyRepo.Update(existing); err != nil {
+		return err
+	}
+
+	if !changeStatus {
+		return nil
+	}
+	if *input.Status == models.CopyAvailable {
+		return releaseCopy(r, existing.BookID, id, existing.Status)
+	}
+	ok, err := r.CopyRepo.SetStatus(id, existing.Status, *input.Status)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("copy status changed concurrently, retry")
+	}
+	return nil
+}
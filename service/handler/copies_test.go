@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// copyStore implements the CopyRepo methods updateCopy calls and records
+// the writes.
+type copyStore struct {
+	repository.CopyRepo
+	copies  map[int64]*models.BookCopy
+	updated []models.BookCopy
+	status  []string
+}
+
+func (s *copyStore) GetByID(id int64) (*models.BookCopy, error) {
+	if c, ok := s.copies[id]; ok {
+		cp := *c
+		return &cp, nil
+	}
+	return nil, nil
+}
+
+func (s *copyStore) GetByBarcode(barcode string) (*models.BookCopy, error) {
+	for _, c := range s.copies {
+		if c.Barcode == barcode {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *copyStore) Update(c *models.BookCopy) error {
+	s.updated = append(s.updated, *c)
+	return nil
+}
+
+func (s *copyStore) SetStatus(id int64, from, to string) (bool, error) {
+	s.status = append(s.status, to)
+	return true, nil
+}
+
+func str(s string) *string { return &s }
+
+func TestUpdateCopyValidatesBeforeSaving(t *testing.T) {
+	shelf := str("Shelf 9")
+	tests := []struct {
+		name  string
+		id    int64
+		input models.CopyUpdate
+	}{
+		{"status set by circulation", 1, models.CopyUpdate{Location: shelf, Status: str(models.CopyOnLoan)}},
+		{"unknown status", 1, models.CopyUpdate{Location: shelf, Status: str("shredded")}},
+		{"copy on loan", 2, models.CopyUpdate{Location: shelf, Status: str(models.CopyDamaged)}},
+		{"copy on hold", 3, models.CopyUpdate{Location: shelf, Status: str(models.CopyLost)}},
+		{"barcode taken", 1, models.CopyUpdate{Barcode: str(" B1-2 "), Location: shelf}},
+		{"empty barcode", 1, models.CopyUpdate{Barcode: str(" "), Location: shelf}},
+		{"empty condition", 1, models.CopyUpdate{Condition: str(""), Location: shelf}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &copyStore{copies: map[int64]*models.BookCopy{
+				1: {ID: 1, BookID: 1, Barcode: "B1-1", Status: models.CopyAvailable, Location: "Shelf 1"},
+				2: {ID: 2, BookID: 1, Barcode: "B1-2", Status: models.CopyOnLoan, Location: "Shelf 1"},
+				3: {ID: 3, BookID: 1, Barcode: "B1-3", Status: models.CopyOnHold, Location: "Shelf 1"},
+			}}
+			err := updateCopy(&repository.Repo{CopyRepo: store}, tt.id, &tt.input)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if len(store.updated) != 0 || len(store.status) != 0 {
+				t.Errorf("saved %+v and statuses %v before failing", store.updated, store.status)
+			}
+		})
+	}
+}
+
+func TestUpdateCopy(t *testing.T) {
+	accession := "ACC-1"
+	tests := []struct {
+		name  string
+		input models.CopyUpdate
+		want  models.BookCopy
+	}{
+		{
+			name:  "every field",
+			input: models.CopyUpdate{Barcode: str(" B1-9 "), AccessionNo: str("ACC-2"), Condition: str("worn"), Location: str("Repair desk")},
+			want:  models.BookCopy{Barcode: "B1-9", AccessionNo: str("ACC-2"), Condition: "worn", Location: "Repair desk"},
+		},
+		{
+			name:  "only condition",
+			input: models.CopyUpdate{Condition: str("worn")},
+			want:  models.BookCopy{Barcode: "B1-1", AccessionNo: &accession, Condition: "worn", Location: "Shelf 1"},
+		},
+		{
+			name: "nothing",
+			want: models.BookCopy{Barcode: "B1-1", AccessionNo: &accession, Condition: "good", Location: "Shelf 1"},
+		},
+		{
+			name:  "clear accession number and location",
+			input: models.CopyUpdate{AccessionNo: str(""), Location: str("")},
+			want:  models.BookCopy{Barcode: "B1-1", Condition: "good"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &copyStore{copies: map[int64]*models.BookCopy{
+				1: {ID: 1, BookID: 1, Barcode: "B1-1", AccessionNo: &accession, Status: models.CopyAvailable, Condition: "good", Location: "Shelf 1"},
+			}}
+			if err := updateCopy(&repository.Repo{CopyRepo: store}, 1, &tt.input); err != nil {
+				t.Fatal(err)
+			}
+			if len(store.updated) != 1 {
+				t.Fatalf("updated %d times, want once", len(store.updated))
+			}
+			got := store.updated[0]
+			if got.Barcode != tt.want.Barcode || got.Condition != tt.want.Condition || got.Location != tt.want.Location ||
+				!reflect.DeepEqual(got.AccessionNo, tt.want.AccessionNo) {
+				t.Errorf("saved %+v, want %+v", got, tt.want)
+			}
+			if len(store.status) != 0 {
+				t.Errorf("statuses set = %v, want none", store.status)
+			}
+		})
+	}
+}
+
+func TestUpdateCopyStatus(t *testing.T) {
+	store := &copyStore{copies: map[int64]*models.BookCopy{
+		1: {ID: 1, BookID: 1, Barcode: "B1-1", Status: models.CopyAvailable, Condition: "good", Location: "Shelf 1"},
+	}}
+	input := &models.CopyUpdate{Location: str("Repair desk"), Status: str(models.CopyDamaged)}
+	if err := updateCopy(&repository.Repo{CopyRepo: store}, 1, input); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.updated[0]; got.Location != "Repair desk" || got.Barcode != "B1-1" {
+		t.Errorf("saved %+v", got)
+	}
+	if len(store.status) != 1 || store.status[0] != models.CopyDamaged {
+		t.Errorf("statuses set = %v, want [damaged]", store.status)
+	}
+}
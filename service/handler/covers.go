@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+
+	"library-management/service/covers"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// coverFiles lists the storage keys of every size of a cover.
+func coverFiles(c *models.BookCover) []string {
+	keys := []string{covers.Key(c.BookID, c.ETag, covers.Original, c.ContentType)}
+	for _, s := range covers.Sizes {
+		keys = append(keys, covers.Key(c.BookID, c.ETag, s.Name, c.ContentType))
+	}
+	return keys
+}
+
+// coverURLs is where each size of a cover is served.
+func coverURLs(c *models.BookCover) map[string]string {
+	urls := map[string]string{covers.Original: fmt.Sprintf("/books/%d/cover?size=%s", c.BookID, covers.Original)}
+	for _, s := range covers.Sizes {
+		urls[s.Name] = fmt.Sprintf("/books/%d/cover?size=%s", c.BookID, s.Name)
+	}
+	return urls
+}
+
+func removeCoverFiles(store covers.Storage, c *models.BookCover) {
+	for _, key := range coverFiles(c) {
+		if err := store.Delete(key); err != nil {
+			log.Printf("cover of book %d: %v", c.BookID, err)
+		}
+	}
+}
+
+// SetCover checks an uploaded image, stores it with its thumbnails and
+// makes it the book's cover. The files of a replaced cover are removed
+// once the new one is saved.
+func SetCover(ctx context.Context, r *repository.Repo, store covers.Storage, bookID int64, data []byte) (*models.BookCover, error) {
+	b, err := r.BookRepo.GetByID(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, errors.New("book not found")
+	}
+	img, err := covers.Process(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	cover := &models.BookCover{
+		BookID:      bookID,
+		ETag:        hex.EncodeToString(sum[:8]),
+		ContentType: img.ContentType,
+		Width:       img.Width,
+		Height:      img.Height,
+		Bytes:       len(data),
+	}
+
+	if err := store.Put(covers.Key(bookID, cover.ETag, covers.Original, cover.ContentType), img.Data); err != nil {
+		return nil, err
+	}
+	for _, s := range covers.Sizes {
+		if err := store.Put(covers.Key(bookID, cover.ETag, s.Name, cover.ContentType), img.Thumbnails[s.Name]); err != nil {
+			removeCoverFiles(store, cover)
+			return nil, err
+		}
+	}
+
+	var old *models.BookCover
+	err = r.WithTx(ctx, func(tx *repository.Repo) error {
+		b, err := tx.BookRepo.GetByIDForUpdate(bookID)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return errors.New("book not found")
+		}
+		if old, err = tx.BookRepo.GetCover(bookID); err != nil {
+			return err
+		}
+		if err := tx.BookRepo.SetCover(cover); err != nil {
+			return err
+		}
+		saved, err := tx.BookRepo.GetCover(bookID)
+		if err != nil {
+			return err
+		}
+		cover = saved
+		return publish(tx, models.EventBookCoverChanged, bookID, map[string]interface{}{"book_id": bookID, "cover": cover})
+	})
+	if err != nil {
+		if old == nil || old.ETag != cover.ETag {
+			removeCoverFiles(store, cover)
+		}
+		return nil, err
+	}
+	if old != nil && old.ETag != cover.ETag {
+		removeCoverFiles(store, old)
+	}
+	cover.URLs = coverURLs(cover)
+	return cover, nil
+}
+
+// GetCover returns a book's cover and the file of one size of it, or nil
+// when the book has no cover.
+func GetCover(r *repository.Repo, store covers.Storage, bookID int64, size string) (*models.BookCover, []byte, error) {
+	if !covers.IsSize(size) {
+		return nil, nil, fmt.Errorf("unknown cover size %q", size)
+	}
+	cover, err := r.BookRepo.GetCover(bookID)
+	if err != nil || cover == nil {
+		return nil, nil, err
+	}
+	data, err := store.Get(covers.Key(bookID, cover.ETag, size, cover.ContentType))
+	if errors.Is(err, covers.ErrNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return cover, data, nil
+}
+
+func DeleteCover(ctx context.Context, r *repository.Repo, store covers.Storage, bookID int64) error {
+	var old *models.BookCover
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		var err error
+		if old, err = tx.BookRepo.GetCover(bookID); err != nil {
+			return err
+		}
+		if old == nil {
+			return errors.New("book has no cover")
+		}
+		if err := tx.BookRepo.DeleteCover(bookID); err != nil {
+			return err
+		}
+		return publish(tx, models.EventBookCoverChanged, bookID, map[string]interface{}{"book_id": bookID, "cover": nil})
+	})
+	if err != nil {
+		return err
+	}
+	removeCoverFiles(store, old)
+	return nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"library-management/service/isbn"
+	"library-management/service/metadata"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+var (
+	ErrInvalidISBN  = errors.New("not a valid ISBN-10 or ISBN-13")
+	ErrLookupFailed = errors.New("metadata lookup failed")
+)
+
+// DraftBook fills in a new book from what p knows about an ISBN, or returns
+// nil when nothing is known. Authors already in the catalog are matched,
+// so the draft credits them rather than creating a second spelling.
+func DraftBook(ctx context.Context, r *repository.Repo, p metadata.MetadataProvider, code string) (*models.BookDraft, error) {
+	code, err := isbn.Normalize(code)
+	if err != nil {
+		return nil, ErrInvalidISBN
+	}
+	rec, err := p.Lookup(ctx, code)
+	if errors.Is(err, metadata.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLookupFailed, err)
+	}
+
+	d := &models.BookDraft{
+		Title:           strings.TrimSpace(rec.Title),
+		ISBN:            code,
+		Publisher:       strings.TrimSpace(rec.Publisher),
+		PublicationYear: rec.Year,
+		Contributors:    []models.Contributor{},
+		CoverURL:        rec.CoverURL,
+		Source:          rec.Source,
+	}
+	for _, name := range rec.Authors {
+		name = strings.Join(strings.Fields(name), " ")
+		if authorKey(name) == "" {
+			continue
+		}
+		c := models.Contributor{Name: name, Role: models.RoleAuthor}
+		for _, key := range []string{authorKey(name), authorKey(invertName(name))} {
+			a, err := r.AuthorRepo.GetByKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if a != nil {
+				c.AuthorID, c.Name = a.ID, a.Name
+				break
+			}
+		}
+		d.Contributors = append(d.Contributors, c)
+	}
+	d.Author = authorLine(d.Contributors)
+
+	existing, err := r.BookRepo.GetByISBN(code)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		d.ExistingBookID = &existing.ID
+	}
+	return d, nil
+}
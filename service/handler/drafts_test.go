@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"library-management/service/metadata"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// Only the methods DraftBook calls are implemented; the embedded
+// interfaces panic on anything else.
+type draftAuthors struct {
+	repository.AuthorRepo
+	byKey map[string]*models.Author
+}
+
+func (a draftAuthors) GetByKey(key string) (*models.Author, error) {
+	return a.byKey[key], nil
+}
+
+type draftBooks struct {
+	repository.BookRepo
+	byISBN map[string]*models.Book
+}
+
+func (b draftBooks) GetByISBN(isbn string) (*models.Book, error) {
+	return b.byISBN[isbn], nil
+}
+
+type draftProvider struct {
+	rec *metadata.Record
+	err error
+	got string
+}
+
+func (p *draftProvider) Name() string { return "test" }
+
+func (p *draftProvider) Lookup(ctx context.Context, isbn string) (*metadata.Record, error) {
+	p.got = isbn
+	return p.rec, p.err
+}
+
+func TestDraftBook(t *testing.T) {
+	r := &repository.Repo{
+		AuthorRepo: draftAuthors{byKey: map[string]*models.Author{
+			"jrrtolkien":         {ID: 7, Name: "J.R.R. Tolkien"},
+			"christophertolkien": {ID: 8, Name: "Christopher Tolkien"},
+		}},
+		BookRepo: draftBooks{byISBN: map[string]*models.Book{
+			"9780261102217": {ID: 42},
+		}},
+	}
+	year := 1937
+	p := &draftProvider{rec: &metadata.Record{
+		ISBN:      "9780261102217",
+		Title:     "  The Hobbit ",
+		Authors:   []string{"J. R. R.  Tolkien", "Tolkien, Christopher", "Alan  Lee", " "},
+		Publisher: "HarperCollins ",
+		Year:      &year,
+		CoverURL:  "http://covers.example/hobbit.jpg",
+		Source:    "test",
+	}}
+
+	d, err := DraftBook(context.Background(), r, p, "0-261-10221-4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.got != "9780261102217" {
+		t.Errorf("provider asked for %q, want the ISBN-13", p.got)
+	}
+	existing := int64(42)
+	want := &models.BookDraft{
+		Title:           "The Hobbit",
+		Author:          "J.R.R. Tolkien, Christopher Tolkien, Alan Lee",
+		ISBN:            "9780261102217",
+		Publisher:       "HarperCollins",
+		PublicationYear: &year,
+		Contributors: []models.Contributor{
+			{AuthorID: 7, Name: "J.R.R. Tolkien", Role: models.RoleAuthor},
+			{AuthorID: 8, Name: "Christopher Tolkien", Role: models.RoleAuthor},
+			{Name: "Alan Lee", Role: models.RoleAuthor},
+		},
+		CoverURL:       "http://covers.example/hobbit.jpg",
+		Source:         "test",
+		ExistingBookID: &existing,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("DraftBook =\n%+v\nwant\n%+v", d, want)
+	}
+}
+
+func TestDraftBookLookupOutcomes(t *testing.T) {
+	r := &repository.Repo{
+		AuthorRepo: draftAuthors{},
+		BookRepo:   draftBooks{},
+	}
+	ctx := context.Background()
+
+	if _, err := DraftBook(ctx, r, &draftProvider{}, "0-261-10221-5"); !errors.Is(err, ErrInvalidISBN) {
+		t.Errorf("bad check digit: got %v, want ErrInvalidISBN", err)
+	}
+
+	d, err := DraftBook(ctx, r, &draftProvider{err: metadata.ErrNotFound}, "9780261102217")
+	if d != nil || err != nil {
+		t.Errorf("unknown isbn: got %+v, %v; want nil, nil", d, err)
+	}
+
+	_, err = DraftBook(ctx, r, &draftProvider{err: errors.New("timeout")}, "9780261102217")
+	if !errors.Is(err, ErrLookupFailed) {
+		t.Errorf("provider failure: got %v, want ErrLookupFailed", err)
+	}
+
+	d, err = DraftBook(ctx, r, &draftProvider{rec: &metadata.Record{Title: "New Book"}}, "9780261102217")
+	if err != nil || d.ExistingBookID != nil || len(d.Contributors) != 0 || d.Author != "" {
+		t.Errorf("new book without authors: got %+v, %v", d, err)
+	}
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// publish records a domain event in the outbox. It must run in the
+// transaction that makes the change, so the event exists exactly when the
+// change was committed; the dispatcher delivers it afterwards. The
+// aggregate type is the part of the event type before the dot.
+func publish(tx *repository.Repo, eventType string, aggregateID int64, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	id, err := randomHex(12)
+	if err != nil {
+		return err
+	}
+	aggregate, _, _ := strings.Cut(eventType, ".")
+	_, err = tx.OutboxRepo.Append(&models.Event{
+		ID:            "evt_" + id,
+		Type:          eventType,
+		AggregateType: aggregate,
+		AggregateID:   aggregateID,
+		Data:          payload,
+		CreatedAt:     time.Now().UTC(),
+	})
+	return err
+}
+
+// ListEvents reads the outbox after the given sequence number, so
+// consumers can poll it and resume where they stopped.
+func ListEvents(r *repository.Repo, afterSeq int64, eventType, aggregateType string, aggregateID int64, limit int) ([]models.Event, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return r.OutboxRepo.GetAfter(afterSeq, eventType, aggregateType, aggregateID, limit)
+}
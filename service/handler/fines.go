@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func newReceiptNo() (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("R%s-%s", time.Now().Format("20060102"), strings.ToUpper(hex.EncodeToString(buf))), nil
+}
+
+func validPaymentMethod(method string) bool {
+	for _, m := range models.PaymentMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func chargeFine(tx *repository.Repo, f *models.Fine) (*models.Fine, error) {
+	f.Amount = roundCents(f.Amount)
+	if f.Amount <= 0 {
+		return nil, errors.New("fine amount must be positive")
+	}
+	id, err := tx.FineRepo.Create(f)
+	if err != nil {
+		return nil, err
+	}
+	fine, err := tx.FineRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := publish(tx, models.EventFineAssessed, id, fine); err != nil {
+		return nil, err
+	}
+	return fine, nil
+}
+
+// FineCharge is a manual charge such as a damaged or lost item.
+type FineCharge struct {
+	MemberID int64
+	IssueID  *int64
+	Kind     string
+	Amount   float64
+	Note     string
+	StaffID  int64
+}
+
+func ChargeFine(ctx context.Context, r *repository.Repo, c FineCharge) (*models.Fine, error) {
+	switch c.Kind {
+	case models.FineLost, models.FineDamaged, models.FineOther:
+	default:
+		return nil, fmt.Errorf("unknown fine kind %q", c.Kind)
+	}
+
+	var fine *models.Fine
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		member, err := tx.MemberRepo.GetByID(c.MemberID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return errors.New("member not found")
+		}
+		if c.IssueID != nil {
+			issue, err := tx.IssueRepo.GetByID(*c.IssueID)
+			if err != nil {
+				return err
+			}
+			if issue == nil || issue.MemberID != c.MemberID {
+				return errors.New("issue record not found for this member")
+			}
+		}
+		fine, err = chargeFine(tx, &models.Fine{
+			MemberID:  c.MemberID,
+			IssueID:   c.IssueID,
+			Kind:      c.Kind,
+			Amount:    c.Amount,
+			Note:      c.Note,
+			CreatedBy: &c.StaffID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fine, nil
+}
+
+// MarkLost closes a loan whose copy will not come back: the copy is marked
+// lost, any overdue fine is charged, and a replacement charge is added.
+func MarkLost(ctx context.Context, r *repository.Repo, issueID int64, replacement float64, staffID int64) ([]models.Fine, error) {
+	var fines []models.Fine
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		issue, err := tx.IssueRepo.GetByIDForUpdate(issueID)
+		if err != nil {
+			return err
+		}
+		if issue == nil {
+			return errors.New("issue record not found")
+		}
+
+		overdue, err := closeLoan(tx, issue)
+		if err != nil {
+			return err
+		}
+		if overdue != nil {
+			fines = append(fines, *overdue)
+		}
+
+		if issue.CopyID != nil {
+			ok, err := tx.CopyRepo.SetStatus(*issue.CopyID, models.CopyOnLoan, models.CopyLost)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errors.New("copy was not on loan")
+			}
+		}
+
+		if replacement > 0 {
+			lost, err := chargeFine(tx, &models.Fine{
+				MemberID:  issue.MemberID,
+				IssueID:   &issue.ID,
+				Kind:      models.FineLost,
+				Amount:    replacement,
+				Note:      "replacement for lost copy",
+				CreatedBy: &staffID,
+			})
+			if err != nil {
+				return err
+			}
+			fines = append(fines, *lost)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fines, nil
+}
+
+func GetFine(r *repository.Repo, id int64) (*models.Fine, error) {
+	f, err := r.FineRepo.GetByID(id)
+	if err != nil || f == nil {
+		return nil, err
+	}
+	if f.Transactions, err = r.FineRepo.GetTransactions(id); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func ListMemberFines(r *repository.Repo, memberID int64) ([]models.Fine, error) {
+	return r.FineRepo.GetByMember(memberID)
+}
+
+func MemberBalance(r *repository.Repo, memberID int64) (*models.Balance, error) {
+	fines, err := r.FineRepo.GetOpenByMember(memberID)
+	if err != nil {
+		return nil, err
+	}
+	b := &models.Balance{MemberID: memberID, OpenFines: fines}
+	for i := range fines {
+		b.Outstanding += fines[i].Outstanding()
+	}
+	b.Outstanding = roundCents(b.Outstanding)
+	return b, nil
+}
+
+// PayFine records a full or partial payment against one fine.
+func PayFine(ctx context.Context, r *repository.Repo, fineID int64, amount float64, method string, staffID int64) (*models.Receipt, error) {
+	var receipt *models.Receipt
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		f, err := tx.FineRepo.GetByIDForUpdate(fineID)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return errors.New("fine not found")
+		}
+		receipt, err = collect(tx, f.MemberID, []models.Fine{*f}, amount, method, staffID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// PayBalance spreads a payment over a member's open fines, oldest first.
+func PayBalance(ctx context.Context, r *repository.Repo, memberID int64, amount float64, method string, staffID int64) (*models.Receipt, error) {
+	var receipt *models.Receipt
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		fines, err := tx.FineRepo.GetOpenByMemberForUpdate(memberID)
+		if err != nil {
+			return err
+		}
+		receipt, err = collect(tx, memberID, fines, amount, method, staffID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+func collect(tx *repository.Repo, memberID int64, fines []models.Fine, amount float64, method string, staffID int64) (*models.Receipt, error) {
+	amount = roundCents(amount)
+	if amount <= 0 {
+		return nil, errors.New("payment amount must be positive")
+	}
+	if !validPaymentMethod(method) {
+		return nil, fmt.Errorf("payment method must be one of %s", strings.Join(models.PaymentMethods, ", "))
+	}
+
+	var outstanding float64
+	for i := range fines {
+		if fines[i].Status == models.FineOpen {
+			outstanding += fines[i].Outstanding()
+		}
+	}
+	outstanding = roundCents(outstanding)
+	if outstanding <= 0 {
+		return nil, errors.New("nothing is outstanding")
+	}
+	if amount > outstanding {
+		return nil, fmt.Errorf("payment of %.2f exceeds outstanding %.2f", amount, outstanding)
+	}
+
+	receiptNo, err := newReceiptNo()
+	if err != nil {
+		return nil, err
+	}
+
+	left := amount
+	for i := range fines {
+		f := &fines[i]
+		if left <= 0 {
+			break
+		}
+		if f.Status != models.FineOpen {
+			continue
+		}
+		part := roundCents(math.Min(left, f.Outstanding()))
+		if part <= 0 {
+			continue
+		}
+		if err := applyTransaction(tx, f, models.FineTransaction{
+			Kind:      models.TxnPayment,
+			Amount:    part,
+			Method:    method,
+			ReceiptNo: &receiptNo,
+			StaffID:   &staffID,
+		}); err != nil {
+			return nil, err
+		}
+		left = roundCents(left - part)
+	}
+
+	return Receipt(tx, receiptNo)
+}
+
+// WaiveFine forgives amount of a fine, or everything outstanding when
+// amount is zero. A reason is mandatory.
+func WaiveFine(ctx context.Context, r *repository.Repo, fineID int64, amount float64, reason string, staffID int64) (*models.Fine, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to waive a fine")
+	}
+
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		f, err := tx.FineRepo.GetByIDForUpdate(fineID)
+		if err != nil {
+			return err
+		}
+		if f == nil {
+			return errors.New("fine not found")
+		}
+		if f.Status != models.FineOpen {
+			return errors.New("fine is already settled")
+		}
+
+		outstanding := roundCents(f.Outstanding())
+		if amount == 0 {
+			amount = outstanding
+		}
+		amount = roundCents(amount)
+		if amount <= 0 || amount > outstanding {
+			return fmt.Errorf("waiver must be between 0 and %.2f", outstanding)
+		}
+
+		return applyTransaction(tx, f, models.FineTransaction{
+			Kind:    models.TxnWaiver,
+			Amount:  amount,
+			Reason:  reason,
+			StaffID: &staffID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetFine(r, fineID)
+}
+
+func applyTransaction(tx *repository.Repo, f *models.Fine, t models.FineTransaction) error {
+	var paid, waived float64
+	if t.Kind == models.TxnPayment {
+		paid = t.Amount
+	} else {
+		waived = t.Amount
+	}
+
+	ok, err := tx.FineRepo.Apply(f.ID, paid, waived)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("fine is already settled")
+	}
+
+	t.FineID = f.ID
+	t.MemberID = f.MemberID
+	if _, err := tx.FineRepo.AddTransaction(&t); err != nil {
+		return err
+	}
+
+	event := models.EventFinePaid
+	if t.Kind == models.TxnWaiver {
+		event = models.EventFineWaived
+	}
+	err = publish(tx, event, f.ID, map[string]interface{}{
+		"fine_id":    f.ID,
+		"member_id":  f.MemberID,
+		"issue_id":   f.IssueID,
+		"amount":     t.Amount,
+		"method":     t.Method,
+		"receipt_no": t.ReceiptNo,
+		"reason":     t.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	if paid > 0 && f.IssueID != nil {
+		return tx.IssueRepo.AddFinePaid(*f.IssueID, paid)
+	}
+	return nil
+}
+
+func Receipt(r *repository.Repo, receiptNo string) (*models.Receipt, error) {
+	lines, err := r.FineRepo.GetTransactionsByReceipt(receiptNo)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	rc := &models.Receipt{
+		ReceiptNo: receiptNo,
+		MemberID:  lines[0].MemberID,
+		Method:    lines[0].Method,
+		StaffID:   lines[0].StaffID,
+		IssuedAt:  lines[0].CreatedAt,
+		Lines:     lines,
+	}
+	for _, l := range lines {
+		rc.Total += l.Amount
+	}
+	rc.Total = roundCents(rc.Total)
+	return rc, nil
+}
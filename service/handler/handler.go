@@ -1,179 +1,839 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"library-management/service/isbn"
 	"library-management/service/models"
 	"library-management/service/repository"
+	"library-management/service/search"
 )
 
-const finePerDay = 10.0
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func pageDefaults(opts *models.ListOptions, sort string) {
+	if opts.Limit <= 0 {
+		opts.Limit = defaultPageSize
+	}
+	if opts.Limit > maxPageSize {
+		opts.Limit = maxPageSize
+	}
+	if opts.Sort == "" {
+		opts.Sort = sort
+	}
+}
 
-func ListBooks(r *repository.Repo) ([]models.Book, error) {
-	return r.BookRepo.GetAll()
+func ListBooks(r *repository.Repo, f models.BookFilter) (models.Page[models.Book], error) {
+	pageDefaults(&f.ListOptions, "-id")
+	return r.BookRepo.List(f)
 }
 
-func SearchBooks(r *repository.Repo, query string) ([]models.Book, error) {
-	return r.BookRepo.Search(query)
+const (
+	defaultSearchHits = 20
+	maxSearchHits     = 100
+)
+
+// SearchBooks runs a catalog query (see search.Parse for the syntax) and
+// returns the best matches first, with facet counts over all matches.
+func SearchBooks(idx search.SearchIndex, query string, filter models.FacetFilter, limit int) (*search.Result, error) {
+	if limit <= 0 {
+		limit = defaultSearchHits
+	}
+	if limit > maxSearchHits {
+		limit = maxSearchHits
+	}
+	return idx.Search(search.Request{Query: search.Parse(query), Filter: filter, Limit: limit, Facets: true})
+}
+
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 25
+)
+
+// SuggestBooks completes a partly typed title or author from the in-memory
+// suggestion index.
+func SuggestBooks(suggester *search.Suggester, prefix string, limit int) []search.Suggestion {
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+	return suggester.Complete(prefix, limit)
 }
 
 func GetBook(r *repository.Repo, id int64) (*models.Book, error) {
-	return r.BookRepo.GetByID(id)
+	b, err := r.BookRepo.GetByID(id)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	if b.Subjects, err = r.BookRepo.GetSubjects(id); err != nil {
+		return nil, err
+	}
+	if b.Contributors, err = r.BookRepo.GetContributors(id); err != nil {
+		return nil, err
+	}
+	return b, nil
 }
 
-func CreateBook(r *repository.Repo, b *models.Book) (int64, error) {
-	if b.Available == 0 && b.Copies > 0 {
-		b.Available = b.Copies
+// checkBookDetails validates and normalizes the descriptive fields of a
+// book: format defaults to print, language is a lowercase ISO 639 code and
+// subjects are trimmed and deduplicated. Contributors are checked when
+// given.
+func checkBookDetails(b *models.Book) error {
+	if b.Contributors != nil {
+		if err := checkContributors(b.Contributors); err != nil {
+			return err
+		}
+	}
+
+	if b.Format == "" {
+		b.Format = models.FormatPrint
+	}
+	if !models.IsBookFormat(b.Format) {
+		return fmt.Errorf("format must be one of %s", strings.Join(models.BookFormats, ", "))
 	}
-	return r.BookRepo.Create(b)
+
+	b.Language = strings.ToLower(strings.TrimSpace(b.Language))
+	if b.Language != "" {
+		if len(b.Language) < 2 || len(b.Language) > 3 || strings.Trim(b.Language, "abcdefghijklmnopqrstuvwxyz") != "" {
+			return errors.New("language must be an ISO 639 code such as en or fre")
+		}
+	}
+
+	if b.PublicationYear != nil && (*b.PublicationYear < 1 || *b.PublicationYear > time.Now().Year()+1) {
+		return errors.New("publication_year is out of range")
+	}
+
+	if b.ISBN != nil {
+		s := strings.TrimSpace(*b.ISBN)
+		if s == "" {
+			b.ISBN = nil
+		} else {
+			n, err := isbn.Normalize(s)
+			if err != nil {
+				return fmt.Errorf("isbn %s is not a valid ISBN-10 or ISBN-13", s)
+			}
+			b.ISBN = &n
+		}
+	}
+	if b.PageCount != nil && *b.PageCount < 1 {
+		return errors.New("page_count must be positive")
+	}
+
+	b.Publisher = strings.TrimSpace(b.Publisher)
+	b.Edition = strings.TrimSpace(b.Edition)
+	b.Description = strings.TrimSpace(b.Description)
+	b.CallNumber = strings.Join(strings.Fields(b.CallNumber), " ")
+	if len(b.Publisher) > 255 {
+		return errors.New("publisher is too long")
+	}
+	if len(b.Edition) > 64 {
+		return errors.New("edition is too long")
+	}
+	if len(b.CallNumber) > 64 {
+		return errors.New("call_number is too long")
+	}
+
+	if b.Subjects != nil {
+		seen := map[string]bool{}
+		subjects := []string{}
+		for _, s := range b.Subjects {
+			s = strings.Join(strings.Fields(s), " ")
+			if s == "" || seen[strings.ToLower(s)] {
+				continue
+			}
+			seen[strings.ToLower(s)] = true
+			subjects = append(subjects, s)
+		}
+		b.Subjects = subjects
+	}
+	return nil
 }
 
-func UpdateBook(r *repository.Repo, id int64, input *models.Book) error {
-	existing, err := r.BookRepo.GetByID(id)
+// checkISBNFree fails when another book, deleted or not, has the ISBN of b.
+func checkISBNFree(r *repository.Repo, b *models.Book) error {
+	if b.ISBN == nil {
+		return nil
+	}
+	other, err := r.BookRepo.GetByISBN(*b.ISBN)
 	if err != nil {
 		return err
 	}
-	if existing == nil {
-		return errors.New("book not found")
+	if other == nil || other.ID == b.ID {
+		return nil
+	}
+	if other.DeletedAt != nil {
+		return fmt.Errorf("isbn %s belongs to deleted book %d, restore it instead", *b.ISBN, other.ID)
+	}
+	return fmt.Errorf("isbn %s already in use by book %d", *b.ISBN, other.ID)
+}
+
+// CreateBook credits the book to b.Contributors, or when there are none to
+// b.Author as its one author.
+func CreateBook(ctx context.Context, r *repository.Repo, b *models.Book) (int64, error) {
+	if len(b.Contributors) == 0 {
+		b.Contributors = []models.Contributor{{Name: b.Author, Role: models.RoleAuthor}}
+	}
+	if err := checkBookDetails(b); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		if err := checkISBNFree(tx, b); err != nil {
+			return err
+		}
+		var err error
+		if b.Contributors, err = resolveContributors(tx, b.Contributors); err != nil {
+			return err
+		}
+		b.Author = authorLine(b.Contributors)
+		id, err = tx.BookRepo.Create(b)
+		if err != nil {
+			return err
+		}
+		if err := tx.BookRepo.SetSubjects(id, b.Subjects); err != nil {
+			return err
+		}
+		if err := tx.BookRepo.SetContributors(id, b.Contributors); err != nil {
+			return err
+		}
+
+		if len(b.Barcodes) > 0 {
+			for _, barcode := range b.Barcodes {
+				if _, err := addCopy(tx, id, &models.BookCopy{Barcode: barcode}); err != nil {
+					return err
+				}
+			}
+		} else {
+			for i := 0; i < b.Copies; i++ {
+				if _, err := addCopy(tx, id, &models.BookCopy{}); err != nil {
+					return err
+				}
+			}
+		}
+
+		created, err := tx.BookRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		created.Subjects = b.Subjects
+		created.Contributors = b.Contributors
+		return publish(tx, models.EventBookCreated, id, created)
+	})
+	if err != nil {
+		return 0, err
 	}
+	return id, nil
+}
+
+func UpdateBook(ctx context.Context, r *repository.Repo, id int64, input *models.Book) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.BookRepo.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("book not found")
+		}
 
-	existing.Title = input.Title
-	existing.Author = input.Author
-	existing.Copies = input.Copies
-	existing.Available = input.Available
+		existing.Title = input.Title
+		// a changed author line stands for one author; several are set
+		// through contributors
+		existing.Contributors = input.Contributors
+		if existing.Contributors == nil && input.Author != "" && input.Author != existing.Author {
+			existing.Contributors = []models.Contributor{{Name: input.Author, Role: models.RoleAuthor}}
+		}
+		if input.Language != "" {
+			existing.Language = input.Language
+		}
+		if input.Format != "" {
+			existing.Format = input.Format
+		}
+		if input.PublicationYear != nil {
+			existing.PublicationYear = input.PublicationYear
+		}
+		if input.ISBN != nil {
+			existing.ISBN = input.ISBN
+		}
+		if input.Publisher != "" {
+			existing.Publisher = input.Publisher
+		}
+		if input.Edition != "" {
+			existing.Edition = input.Edition
+		}
+		if input.PageCount != nil {
+			existing.PageCount = input.PageCount
+		}
+		if input.Description != "" {
+			existing.Description = input.Description
+		}
+		if input.CallNumber != "" {
+			existing.CallNumber = input.CallNumber
+		}
+		existing.Subjects = input.Subjects
+		if err := checkBookDetails(existing); err != nil {
+			return err
+		}
+		if err := checkISBNFree(tx, existing); err != nil {
+			return err
+		}
+		if existing.Contributors != nil {
+			if existing.Contributors, err = resolveContributors(tx, existing.Contributors); err != nil {
+				return err
+			}
+			existing.Author = authorLine(existing.Contributors)
+		}
 
-	return r.BookRepo.Update(existing)
+		if err := tx.BookRepo.Update(existing); err != nil {
+			return err
+		}
+		// subjects and contributors are only replaced when the request
+		// lists them
+		if existing.Contributors != nil {
+			if err := tx.BookRepo.SetContributors(id, existing.Contributors); err != nil {
+				return err
+			}
+		} else if existing.Contributors, err = tx.BookRepo.GetContributors(id); err != nil {
+			return err
+		}
+		if existing.Subjects != nil {
+			if err := tx.BookRepo.SetSubjects(id, existing.Subjects); err != nil {
+				return err
+			}
+		} else if existing.Subjects, err = tx.BookRepo.GetSubjects(id); err != nil {
+			return err
+		}
+		return publish(tx, models.EventBookUpdated, id, existing)
+	})
 }
 
-func DeleteBook(r *repository.Repo, id int64) error {
-	return r.BookRepo.Delete(id)
+func DeleteBook(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.BookRepo.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("book not found")
+		}
+		onLoan, err := tx.IssueRepo.CountActiveByBook(id)
+		if err != nil {
+			return err
+		}
+		if onLoan > 0 {
+			return fmt.Errorf("book has %d copies on loan", onLoan)
+		}
+		if err := cancelOpenHolds(tx, tx.HoldRepo.GetQueueByBook, id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if _, err := tx.BookRepo.SoftDelete(id, now); err != nil {
+			return err
+		}
+		existing.DeletedAt = &now
+		return publish(tx, models.EventBookDeleted, id, existing)
+	})
 }
 
-func ListMembers(r *repository.Repo) ([]models.Member, error) {
-	return r.MemberRepo.GetAll()
+func ListDeletedBooks(r *repository.Repo) ([]models.Book, error) {
+	return r.BookRepo.GetDeleted()
+}
+
+func RestoreBook(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		restored, err := tx.BookRepo.Restore(id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return errors.New("deleted book not found")
+		}
+		b, err := tx.BookRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return publish(tx, models.EventBookRestored, id, b)
+	})
+}
+
+func ListMembers(r *repository.Repo, f models.MemberFilter) (models.Page[models.Member], error) {
+	pageDefaults(&f.ListOptions, "-id")
+	return r.MemberRepo.List(f)
 }
 
 func GetMember(r *repository.Repo, id int64) (*models.Member, error) {
 	return r.MemberRepo.GetByID(id)
 }
 
-func CreateMember(r *repository.Repo, m *models.Member) (int64, error) {
-	return r.MemberRepo.Create(m)
+// memberExpiry checks an expiry date sent as YYYY-MM-DD or in the RFC 3339
+// form members are read back in, and returns it as YYYY-MM-DD. An empty
+// date means the membership doesn't expire.
+func memberExpiry(s *string) (*string, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	d, err := parseDate(*s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: expires_at must be YYYY-MM-DD", ErrInvalidMember)
+	}
+	v := d.Format("2006-01-02")
+	return &v, nil
 }
 
-func UpdateMember(r *repository.Repo, id int64, input *models.Member) error {
-	existing, err := r.MemberRepo.GetByID(id)
+func CreateMember(ctx context.Context, r *repository.Repo, m *models.Member) (int64, error) {
+	if m.Category == "" {
+		m.Category = DefaultMemberCategory
+	}
+	expires, err := memberExpiry(m.ExpiresAt)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	m.ExpiresAt = expires
+	if err := checkCategory(r, m.Category); err != nil {
+		return 0, err
 	}
-	if existing == nil {
-		return errors.New("member not found")
+
+	var id int64
+	err = r.WithTx(ctx, func(tx *repository.Repo) error {
+		var err error
+		id, err = tx.MemberRepo.Create(m)
+		if err != nil {
+			return err
+		}
+		m.ID = id
+		return publish(tx, models.EventMemberCreated, id, m)
+	})
+	if err != nil {
+		return 0, err
 	}
+	return id, nil
+}
+
+func UpdateMember(ctx context.Context, r *repository.Repo, id int64, input *models.Member) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.MemberRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("member not found")
+		}
+
+		existing.Name = input.Name
+		existing.Email = input.Email
+		existing.RollNo = input.RollNo
+		if existing.ExpiresAt, err = memberExpiry(input.ExpiresAt); err != nil {
+			return err
+		}
+		if input.Category != "" && input.Category != existing.Category {
+			if err := checkCategory(tx, input.Category); err != nil {
+				return err
+			}
+			existing.Category = input.Category
+		}
 
-	existing.Name = input.Name
-	existing.Email = input.Email
-	existing.RollNo = input.RollNo
+		if err := tx.MemberRepo.Update(existing); err != nil {
+			return err
+		}
+		return publish(tx, models.EventMemberUpdated, id, existing)
+	})
+}
 
-	return r.MemberRepo.Update(existing)
+func DeleteMember(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.MemberRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("member not found")
+		}
+		loans, err := tx.IssueRepo.CountActiveByMember(id)
+		if err != nil {
+			return err
+		}
+		if loans > 0 {
+			return fmt.Errorf("member has %d books on loan", loans)
+		}
+		balance, err := MemberBalance(tx, id)
+		if err != nil {
+			return err
+		}
+		if balance.Outstanding > 0 {
+			return fmt.Errorf("member owes %.2f in fines", balance.Outstanding)
+		}
+		if err := cancelOpenHolds(tx, tx.HoldRepo.GetByMember, id); err != nil {
+			return err
+		}
+
+		if _, err := tx.MemberRepo.SoftDelete(id, time.Now()); err != nil {
+			return err
+		}
+		return publish(tx, models.EventMemberDeleted, id, map[string]interface{}{"member_id": id, "roll_no": existing.RollNo})
+	})
 }
 
-func DeleteMember(r *repository.Repo, id int64) error {
-	return r.MemberRepo.Delete(id)
+func ListDeletedMembers(r *repository.Repo) ([]models.Member, error) {
+	return r.MemberRepo.GetDeleted()
 }
 
-func IssueBook(r *repository.Repo, bookID, memberID int64, dueDays int) (int64, error) {
+// RestoreMember undoes a soft delete. Members that have already been
+// anonymized cannot be restored.
+func RestoreMember(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		restored, err := tx.MemberRepo.Restore(id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return errors.New("deleted member not found or already anonymized")
+		}
+		m, err := tx.MemberRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		return publish(tx, models.EventMemberRestored, id, m)
+	})
+}
 
-	book, err := r.BookRepo.GetByID(bookID)
+// cancelOpenHolds cancels the waiting and ready holds returned by list.
+// Waiting holds go first so a ready hold's copy is not handed on to a hold
+// that is about to be cancelled too.
+func cancelOpenHolds(tx *repository.Repo, list func(int64) ([]models.Hold, error), id int64) error {
+	holds, err := list(id)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	if book == nil {
-		return 0, errors.New("book not found")
+	for _, status := range []string{models.HoldWaiting, models.HoldReady} {
+		for i := range holds {
+			if holds[i].Status != status {
+				continue
+			}
+			if err := closeHold(tx, &holds[i], models.HoldCancelled); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
+
+// IssueRequest identifies what to lend either by a scanned Barcode or by
+// BookID, in which case the first available copy is used. DueDays overrides
+// the loan period of the member's category when positive. Override lets a
+// blocked member borrow anyway and is recorded with its justification.
+type IssueRequest struct {
+	BookID   int64
+	Barcode  string
+	MemberID int64
+	DueDays  int
+	Override *IssueOverride
+}
+
+func IssueBook(ctx context.Context, r *repository.Repo, req IssueRequest) (int64, error) {
+	var issueID int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		var item *models.BookCopy
+		if req.Barcode != "" {
+			c, err := tx.CopyRepo.GetByBarcodeForUpdate(req.Barcode)
+			if err != nil {
+				return err
+			}
+			if c == nil {
+				return errors.New("copy not found")
+			}
+			if req.BookID != 0 && req.BookID != c.BookID {
+				return errors.New("barcode belongs to a different book")
+			}
+			item = c
+			req.BookID = c.BookID
+		}
+
+		book, err := tx.BookRepo.GetByIDForUpdate(req.BookID)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			return errors.New("book not found")
+		}
+
+		member, err := tx.MemberRepo.GetByID(req.MemberID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return errors.New("member not found")
+		}
+		policy, err := policyForMember(tx, req.MemberID)
+		if err != nil {
+			return err
+		}
+
+		reasons, err := circulationBlocks(tx, member, policy)
+		if err != nil {
+			return err
+		}
+		var blocked *BlockError
+		if len(reasons) > 0 {
+			blocked = &BlockError{Reasons: reasons}
+			if req.Override == nil {
+				return blocked
+			}
+			if strings.TrimSpace(req.Override.Justification) == "" {
+				return errors.New("an override needs a justification")
+			}
+		}
+
+		active, err := tx.IssueRepo.GetActiveByBookAndMember(req.BookID, req.MemberID)
+		if err != nil {
+			return err
+		}
+		if active != nil {
+			return errors.New("this member already has this book issued")
+		}
+
+		hold, err := tx.HoldRepo.GetOpenByBookAndMemberForUpdate(req.BookID, req.MemberID)
+		if err != nil {
+			return err
+		}
+		var heldCopyID int64
+		if hold != nil && hold.Status == models.HoldReady && hold.CopyID != nil {
+			heldCopyID = *hold.CopyID
+		}
+
+		if item == nil && heldCopyID != 0 {
+			item, err = tx.CopyRepo.GetByID(heldCopyID)
+			if err != nil {
+				return err
+			}
+		}
+		if item == nil {
+			item, err = tx.CopyRepo.ClaimAvailable(req.BookID)
+			if err != nil {
+				return err
+			}
+			if item == nil {
+				return errors.New("no available copies")
+			}
+		}
+
+		switch item.Status {
+		case models.CopyAvailable:
+		case models.CopyOnHold:
+			if item.ID != heldCopyID {
+				return errors.New("copy is held for another member")
+			}
+		default:
+			return errors.New("copy is not available")
+		}
+
+		ok, err := tx.CopyRepo.SetStatus(item.ID, item.Status, models.CopyOnLoan)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("copy is not available")
+		}
+
+		if hold != nil {
+			if _, err := tx.HoldRepo.Close(hold.ID, models.HoldFulfilled, time.Now()); err != nil {
+				return err
+			}
+			// the member took a different copy off the shelf; pass the one
+			// set aside for them to the next in line
+			if heldCopyID != 0 && heldCopyID != item.ID {
+				if err := releaseCopy(tx, req.BookID, heldCopyID, models.CopyOnHold); err != nil {
+					return err
+				}
+			}
+		}
+
+		dueDays := policy.LoanDays
+		if req.DueDays > 0 {
+			dueDays = req.DueDays
+		}
+		dueDateStr := today().AddDate(0, 0, dueDays).Format("2006-01-02")
+
+		issue := &models.Issue{
+			BookID:   req.BookID,
+			CopyID:   &item.ID,
+			MemberID: req.MemberID,
+			DueDate:  &dueDateStr,
+		}
+
+		issueID, err = tx.IssueRepo.Create(issue)
+		if err != nil {
+			return err
+		}
+
+		if blocked != nil {
+			_, err = tx.OverrideRepo.Create(&models.CirculationOverride{
+				IssueID:       &issueID,
+				MemberID:      req.MemberID,
+				BookID:        req.BookID,
+				StaffID:       &req.Override.StaffID,
+				Reasons:       blocked.Codes(),
+				Justification: strings.TrimSpace(req.Override.Justification),
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-	member, err := r.MemberRepo.GetByID(memberID)
+		issue.ID = issueID
+		return publish(tx, models.EventBookIssued, issue.BookID, issueEvent(issue, item.Barcode, nil))
+	})
 	if err != nil {
 		return 0, err
 	}
-	if member == nil {
-		return 0, errors.New("member not found")
-	}
+	return issueID, nil
+}
 
-	active, err := r.IssueRepo.GetActiveByBookAndMember(bookID, memberID)
+// ReturnBook closes a loan. The returned fine is the overdue charge
+// assessed on return, or nil when the book came back in time.
+func ReturnBook(ctx context.Context, r *repository.Repo, issueID int64) (*models.Fine, error) {
+	var fine *models.Fine
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		issue, err := tx.IssueRepo.GetByIDForUpdate(issueID)
+		if err != nil {
+			return err
+		}
+		if issue == nil {
+			return errors.New("issue record not found")
+		}
+		fine, err = returnIssue(tx, issue)
+		return err
+	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if active != nil {
-		return 0, errors.New("this member already has this book issued")
+	return fine, nil
+}
+
+// ReturnByBarcode closes the open loan of the scanned copy.
+func ReturnByBarcode(ctx context.Context, r *repository.Repo, barcode string) (int64, *models.Fine, error) {
+	var issueID int64
+	var fine *models.Fine
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		item, err := tx.CopyRepo.GetByBarcodeForUpdate(barcode)
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			return errors.New("copy not found")
+		}
+		issue, err := tx.IssueRepo.GetActiveByCopyForUpdate(item.ID)
+		if err != nil {
+			return err
+		}
+		if issue == nil {
+			return errors.New("copy is not on loan")
+		}
+		issueID = issue.ID
+		fine, err = returnIssue(tx, issue)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
 	}
+	return issueID, fine, nil
+}
 
-	ok, err := r.BookRepo.ChangeAvailability(bookID, -1)
+func returnIssue(tx *repository.Repo, issue *models.Issue) (*models.Fine, error) {
+	fine, err := closeLoan(tx, issue)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if !ok {
-		return 0, errors.New("no available copies")
+	if err := publish(tx, models.EventBookReturned, issue.BookID, issueEvent(issue, "", fine)); err != nil {
+		return nil, err
 	}
-
-	var dueDateStr *string
-	if dueDays > 0 {
-		d := time.Now().AddDate(0, 0, dueDays).Format("2006-01-02")
-		dueDateStr = &d
+	if issue.CopyID != nil {
+		if err := releaseCopy(tx, issue.BookID, *issue.CopyID, models.CopyOnLoan); err != nil {
+			return nil, err
+		}
 	}
+	return fine, nil
+}
 
-	issue := &models.Issue{
-		BookID:   bookID,
-		MemberID: memberID,
-		DueDate:  dueDateStr,
+// issueEvent is the data of the book.issued/returned/renewed events.
+func issueEvent(issue *models.Issue, barcode string, fine *models.Fine) map[string]interface{} {
+	data := map[string]interface{}{
+		"issue_id":  issue.ID,
+		"book_id":   issue.BookID,
+		"copy_id":   issue.CopyID,
+		"member_id": issue.MemberID,
+		"due_date":  issue.DueDate,
 	}
-
-	issueID, err := r.IssueRepo.Create(issue)
-	if err != nil {
-
-		_, _ = r.BookRepo.ChangeAvailability(bookID, 1)
-		return 0, err
+	if barcode != "" {
+		data["barcode"] = barcode
 	}
-
-	return issueID, nil
+	if fine != nil {
+		data["fine_assessed"] = fine.Amount
+	}
+	return data
 }
 
-func ReturnBook(r *repository.Repo, issueID int64) (float64, error) {
-	issue, err := r.IssueRepo.GetByID(issueID)
+// closeLoan marks the issue returned and charges any overdue fine; what
+// happens to the copy is up to the caller.
+func closeLoan(tx *repository.Repo, issue *models.Issue) (*models.Fine, error) {
+	if issue.ReturnedAt != nil {
+		return nil, errors.New("already returned")
+	}
+
+	book, err := tx.BookRepo.GetByIDForUpdate(issue.BookID)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	if issue == nil {
-		return 0, errors.New("issue record not found")
+	if book == nil {
+		return nil, errors.New("book not found")
 	}
 
-	var fine float64
-	if issue.DueDate != nil && *issue.DueDate != "" {
-		if due, err := time.Parse("2006-01-02", *issue.DueDate); err == nil && time.Now().After(due) {
-			diff := time.Since(due)
-			days := int(diff.Hours() / 24)
-			if days < 1 {
-				days = 1
-			}
-			fine = float64(days) * finePerDay
-		}
+	policy, err := policyForMember(tx, issue.MemberID)
+	if err != nil {
+		return nil, err
 	}
-
 	now := time.Now()
+	overdue, err := daysOverdue(issue.DueDate, now)
+	if err != nil {
+		return nil, err
+	}
 
-	updated, err := r.IssueRepo.Return(issueID, now, fine)
+	updated, err := tx.IssueRepo.Return(issue.ID, now)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	if !updated {
-		return 0, errors.New("already returned")
+		return nil, errors.New("already returned")
 	}
 
-	_, _ = r.BookRepo.ChangeAvailability(issue.BookID, 1)
-
-	return fine, nil
+	amount := computeFine(policy, overdue)
+	if amount <= 0 {
+		return nil, nil
+	}
+	return chargeFine(tx, &models.Fine{
+		MemberID: issue.MemberID,
+		IssueID:  &issue.ID,
+		Kind:     models.FineOverdue,
+		Amount:   amount,
+		Note:     fmt.Sprintf("%d days overdue", overdue),
+	})
 }
 
-func GetIssuesByMember(r *repository.Repo, memberID int64) ([]models.Issue, error) {
-	return r.IssueRepo.GetByMember(memberID)
+func ListIssues(r *repository.Repo, f models.IssueFilter) (models.Page[models.Issue], error) {
+	switch f.Status {
+	case "", models.IssueStatusActive, models.IssueStatusReturned, models.IssueStatusOverdue:
+	default:
+		return models.Page[models.Issue]{}, fmt.Errorf("%w: status must be active, returned or overdue", repository.ErrInvalidPage)
+	}
+	pageDefaults(&f.ListOptions, "-issued_at")
+	return r.IssueRepo.List(f, today().Format("2006-01-02"))
 }
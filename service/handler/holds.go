@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+const holdPickupDays = 3
+
+func PlaceHold(ctx context.Context, r *repository.Repo, bookID, memberID int64) (int64, error) {
+	var id int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		book, err := tx.BookRepo.GetByIDForUpdate(bookID)
+		if err != nil {
+			return err
+		}
+		if book == nil {
+			return errors.New("book not found")
+		}
+
+		member, err := tx.MemberRepo.GetByID(memberID)
+		if err != nil {
+			return err
+		}
+		if member == nil {
+			return errors.New("member not found")
+		}
+
+		active, err := tx.IssueRepo.GetActiveByBookAndMember(bookID, memberID)
+		if err != nil {
+			return err
+		}
+		if active != nil {
+			return errors.New("this member already has this book issued")
+		}
+
+		open, err := tx.HoldRepo.GetOpenByBookAndMemberForUpdate(bookID, memberID)
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			return errors.New("this member already has a hold on this book")
+		}
+
+		if book.Available > 0 {
+			return errors.New("copies are available; issue the book instead")
+		}
+
+		id, err = tx.HoldRepo.Create(&models.Hold{BookID: bookID, MemberID: memberID})
+		if err != nil {
+			return err
+		}
+		return publish(tx, models.EventHoldPlaced, id, map[string]interface{}{"hold_id": id, "book_id": bookID, "member_id": memberID})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func GetHold(r *repository.Repo, id int64) (*models.Hold, error) {
+	h, err := r.HoldRepo.GetByID(id)
+	if err != nil || h == nil {
+		return nil, err
+	}
+	if h.Status == models.HoldWaiting {
+		if h.Position, err = r.HoldRepo.Position(h); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+func ListHoldQueue(r *repository.Repo, bookID int64) ([]models.Hold, error) {
+	holds, err := r.HoldRepo.GetQueueByBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+	pos := 0
+	for i := range holds {
+		if holds[i].Status == models.HoldWaiting {
+			pos++
+			holds[i].Position = pos
+		}
+	}
+	return holds, nil
+}
+
+func ListMemberHolds(r *repository.Repo, memberID int64) ([]models.Hold, error) {
+	holds, err := r.HoldRepo.GetByMember(memberID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range holds {
+		if holds[i].Status == models.HoldWaiting {
+			if holds[i].Position, err = r.HoldRepo.Position(&holds[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return holds, nil
+}
+
+func CancelHold(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		h, err := tx.HoldRepo.GetByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if h == nil {
+			return errors.New("hold not found")
+		}
+		return closeHold(tx, h, models.HoldCancelled)
+	})
+}
+
+// ExpireHolds closes ready holds whose pickup window has passed and passes
+// their copies on to the next member in line.
+func ExpireHolds(ctx context.Context, r *repository.Repo) (int, error) {
+	var expired int
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		holds, err := tx.HoldRepo.GetExpiredReadyForUpdate(time.Now())
+		if err != nil {
+			return err
+		}
+		for i := range holds {
+			if err := closeHold(tx, &holds[i], models.HoldExpired); err != nil {
+				return err
+			}
+			expired++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+func closeHold(tx *repository.Repo, h *models.Hold, status string) error {
+	closed, err := tx.HoldRepo.Close(h.ID, status, time.Now())
+	if err != nil {
+		return err
+	}
+	if !closed {
+		return errors.New("hold is already closed")
+	}
+	if h.Status == models.HoldReady && h.CopyID != nil {
+		return releaseCopy(tx, h.BookID, *h.CopyID, models.CopyOnHold)
+	}
+	return nil
+}
+
+// releaseCopy hands a copy that just came free (returned, released from an
+// expired hold, or newly shelved) to the oldest waiting hold on the book,
+// or puts it back on the shelf when nobody is waiting.
+func releaseCopy(tx *repository.Repo, bookID, copyID int64, from string) error {
+	next, err := tx.HoldRepo.NextWaitingForUpdate(bookID)
+	if err != nil {
+		return err
+	}
+
+	to := models.CopyAvailable
+	if next != nil {
+		to = models.CopyOnHold
+	}
+	if from != to {
+		ok, err := tx.CopyRepo.SetStatus(copyID, from, to)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("copy status changed concurrently, retry")
+		}
+	}
+	if next == nil {
+		return publish(tx, models.EventBookAvailable, bookID, map[string]interface{}{"book_id": bookID, "copy_id": copyID})
+	}
+
+	now := time.Now()
+	expires := now.AddDate(0, 0, holdPickupDays)
+	ok, err := tx.HoldRepo.MarkReady(next.ID, copyID, now, expires)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("hold changed concurrently, retry")
+	}
+	return publish(tx, models.EventHoldReady, next.ID, map[string]interface{}{
+		"hold_id":    next.ID,
+		"book_id":    bookID,
+		"copy_id":    copyID,
+		"member_id":  next.MemberID,
+		"expires_at": expires.UTC(),
+	})
+}
@@ -0,0 +1,562 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"library-management/service/bulk"
+	"library-management/service/isbn"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// Import progress is saved every importFlushRows rows or importFlushEvery,
+// whichever comes first. A running job not saved for importStale has died
+// with the server that ran it.
+const (
+	importFlushRows  = 200
+	importFlushEvery = 2 * time.Second
+	importStale      = 10 * time.Minute
+)
+
+// maxImportCopies caps the copies one imported row may create.
+const maxImportCopies = 100
+
+var bookImportFields = []bulk.Field{
+	{Name: "title"},
+	{Name: "authors", Aliases: []string{"author", "contributors", "creators"}},
+	{Name: "isbn", Aliases: []string{"isbn13", "isbn10", "isbn_13", "isbn_10"}},
+	{Name: "publisher"},
+	{Name: "publication_year", Aliases: []string{"year", "pub_year", "published"}},
+	{Name: "edition"},
+	{Name: "language", Aliases: []string{"lang"}},
+	{Name: "format"},
+	{Name: "page_count", Aliases: []string{"pages"}},
+	{Name: "description", Aliases: []string{"summary"}},
+	{Name: "call_number", Aliases: []string{"call_no", "shelfmark"}},
+	{Name: "subjects", Aliases: []string{"subject", "tags"}},
+	{Name: "copies"},
+}
+
+var memberImportFields = []bulk.Field{
+	{Name: "name", Aliases: []string{"full_name"}},
+	{Name: "email", Aliases: []string{"e_mail", "email_address"}},
+	{Name: "roll_no", Aliases: []string{"roll_number", "roll", "student_id"}},
+	{Name: "category"},
+	{Name: "expires_at", Aliases: []string{"expires", "expiry", "expiry_date"}},
+}
+
+// ImportFields lists the fields an import of kind understands.
+func ImportFields(kind string) ([]bulk.Field, error) {
+	switch kind {
+	case models.ImportBooks:
+		return bookImportFields, nil
+	case models.ImportMembers:
+		return memberImportFields, nil
+	}
+	return nil, errors.New("imports are of books or members")
+}
+
+// countingReader counts the bytes read from the import file, which is how
+// far along a job is.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// StartImport checks the header of the file at path and records a job for
+// it, then imports the rows in the background. The file is removed when
+// the job is done. Books are matched on ISBN and members on roll number:
+// a match is updated, anything else created. Rows that fail validation are
+// skipped and listed in the job's errors; with DryRun the whole import
+// runs in a transaction that is rolled back.
+func StartImport(r *repository.Repo, job *models.ImportJob, path string, mapping map[string]string) (*models.ImportJob, error) {
+	fields, err := ImportFields(job.Kind)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	cr := &countingReader{r: f}
+	rr, err := bulk.NewReader(cr, job.Format, fields, mapping)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	job.Status = models.ImportRunning
+	job.BytesTotal = fi.Size()
+	job.IgnoredColumns, _ = json.Marshal(rr.Ignored())
+	if job.ID, err = r.ImportRepo.Create(job); err != nil {
+		f.Close()
+		return nil, err
+	}
+	started := *job
+	go func() {
+		defer os.Remove(path)
+		defer f.Close()
+		runImport(r, job, cr, rr)
+	}()
+	return &started, nil
+}
+
+func runImport(r *repository.Repo, job *models.ImportJob, cr *countingReader, rr bulk.Reader) {
+	ctx := context.Background()
+	var rowErrors []models.ImportRow
+	finish := func(status, msg string) {
+		if len(msg) > 1000 {
+			msg = msg[:1000]
+		}
+		now := time.Now()
+		job.Status, job.Error, job.FinishedAt = status, msg, &now
+		if status == models.ImportDone {
+			job.BytesRead = job.BytesTotal
+		}
+		job.IgnoredColumns, _ = json.Marshal(rr.Ignored())
+		if err := saveImport(r, job, rowErrors); err != nil {
+			log.Printf("import %d: %v", job.ID, err)
+		}
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("import %d panicked: %v", job.ID, p)
+			finish(models.ImportFailed, fmt.Sprint(p))
+		}
+	}()
+
+	importRows := func(tx *repository.Repo) error {
+		saved := time.Now()
+		for {
+			row, err := rr.Read()
+			if err == io.EOF {
+				return nil
+			}
+			var result models.ImportRow
+			var rerr *bulk.RowError
+			switch {
+			case errors.As(err, &rerr):
+				result = models.ImportRow{Row: rerr.N, Action: models.ImportSkipped, Error: rerr.Err.Error()}
+			case err != nil:
+				return err
+			case job.Kind == models.ImportBooks:
+				result = importBookRow(ctx, tx, row)
+			default:
+				result = importMemberRow(ctx, tx, row)
+			}
+
+			job.Processed++
+			switch result.Action {
+			case models.ImportCreated:
+				job.Created++
+			case models.ImportUpdated:
+				job.Updated++
+			default:
+				job.Skipped++
+				if len(rowErrors) < models.MaxImportErrors {
+					rowErrors = append(rowErrors, result)
+				}
+			}
+			if job.Processed%importFlushRows == 0 || time.Since(saved) > importFlushEvery {
+				job.BytesRead = cr.n
+				// progress goes through r, outside a dry run's transaction
+				if err := saveImport(r, job, rowErrors); err != nil {
+					return err
+				}
+				saved = time.Now()
+			}
+		}
+	}
+
+	var err error
+	if job.DryRun {
+		err = r.WithTx(ctx, func(tx *repository.Repo) error {
+			if err := importRows(tx); err != nil {
+				return err
+			}
+			return errDryRun
+		})
+		if errors.Is(err, errDryRun) {
+			err = nil
+		}
+	} else {
+		err = importRows(r)
+	}
+	if err != nil {
+		finish(models.ImportFailed, err.Error())
+		return
+	}
+	finish(models.ImportDone, "")
+}
+
+func saveImport(r *repository.Repo, job *models.ImportJob, rowErrors []models.ImportRow) error {
+	if len(rowErrors) > 0 {
+		var err error
+		if job.RowErrors, err = json.Marshal(rowErrors); err != nil {
+			return err
+		}
+	}
+	return r.ImportRepo.Update(job)
+}
+
+// GetImportJob returns a job with its progress as a fraction.
+func GetImportJob(r *repository.Repo, id int64) (*models.ImportJob, error) {
+	job, err := r.ImportRepo.GetByID(id)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	if job.Status == models.ImportRunning && time.Since(job.UpdatedAt) > importStale {
+		job.Status = models.ImportInterrupted
+	}
+	job.Progress = 1
+	if job.BytesTotal > 0 && job.Status != models.ImportDone {
+		job.Progress = float64(job.BytesRead) / float64(job.BytesTotal)
+	}
+	return job, nil
+}
+
+// rowInt reads an optional whole number.
+func rowInt(row bulk.Row, field string) (*int, error) {
+	v := row.Get(field)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a whole number", field)
+	}
+	return &n, nil
+}
+
+// rowContributors reads "Ann Lee; Bo Ek (editor)": names, each with an
+// optional role in brackets.
+func rowContributors(row bulk.Row) []models.Contributor {
+	var out []models.Contributor
+	for _, v := range row.List("authors") {
+		c := models.Contributor{Name: v, Role: models.RoleAuthor}
+		if i := strings.LastIndex(v, " ("); i > 0 && strings.HasSuffix(v, ")") {
+			if role := strings.ToLower(v[i+2 : len(v)-1]); models.IsContributorRole(role) {
+				c.Name, c.Role = v[:i], role
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func contributorCell(contributors []models.Contributor) string {
+	parts := make([]string, len(contributors))
+	for i, c := range contributors {
+		parts[i] = c.Name
+		if c.Role != models.RoleAuthor {
+			parts[i] += " (" + c.Role + ")"
+		}
+	}
+	return strings.Join(parts, bulk.ListSep+" ")
+}
+
+func bookFromRow(row bulk.Row) (*models.Book, error) {
+	b := &models.Book{
+		Title:        row.Get("title"),
+		Contributors: rowContributors(row),
+		Publisher:    row.Get("publisher"),
+		Edition:      row.Get("edition"),
+		Language:     row.Get("language"),
+		Format:       strings.ToLower(row.Get("format")),
+		Description:  row.Get("description"),
+		CallNumber:   row.Get("call_number"),
+		Subjects:     row.List("subjects"),
+	}
+	if v := row.Get("isbn"); v != "" {
+		n, err := isbn.Normalize(v)
+		if err != nil {
+			return nil, fmt.Errorf("isbn %s is not a valid ISBN-10 or ISBN-13", v)
+		}
+		b.ISBN = &n
+	}
+	var err error
+	if b.PublicationYear, err = rowInt(row, "publication_year"); err != nil {
+		return nil, err
+	}
+	if b.PageCount, err = rowInt(row, "page_count"); err != nil {
+		return nil, err
+	}
+	copies, err := rowInt(row, "copies")
+	if err != nil {
+		return nil, err
+	}
+	if copies != nil {
+		if *copies < 0 || *copies > maxImportCopies {
+			return nil, fmt.Errorf("copies must be between 0 and %d", maxImportCopies)
+		}
+		b.Copies = *copies
+	}
+	return b, nil
+}
+
+// importBookRow creates or updates one book. On update, empty cells leave
+// the book as it is and copies is ignored.
+func importBookRow(ctx context.Context, r *repository.Repo, row bulk.Row) models.ImportRow {
+	result := models.ImportRow{Row: row.N, Key: row.Get("isbn"), Title: row.Get("title")}
+	skip := func(err error) models.ImportRow {
+		result.Action, result.Error = models.ImportSkipped, err.Error()
+		return result
+	}
+	b, err := bookFromRow(row)
+	if err != nil {
+		return skip(err)
+	}
+
+	if b.ISBN != nil {
+		result.Key = *b.ISBN
+		existing, err := r.BookRepo.GetByISBN(*b.ISBN)
+		if err != nil {
+			return skip(err)
+		}
+		if existing != nil {
+			if existing.DeletedAt != nil {
+				return skip(fmt.Errorf("isbn %s belongs to deleted book %d", *b.ISBN, existing.ID))
+			}
+			if b.Title == "" {
+				b.Title = existing.Title
+			}
+			if len(b.Contributors) == 0 {
+				b.Contributors = nil
+			}
+			if len(b.Subjects) == 0 {
+				b.Subjects = nil
+			}
+			if err := UpdateBook(ctx, r, existing.ID, b); err != nil {
+				return skip(err)
+			}
+			result.Action, result.ID = models.ImportUpdated, existing.ID
+			return result
+		}
+	}
+
+	if b.Title == "" {
+		return skip(errors.New("title is required"))
+	}
+	if len(b.Contributors) == 0 {
+		return skip(errors.New("authors is required"))
+	}
+	id, err := CreateBook(ctx, r, b)
+	if err != nil {
+		return skip(err)
+	}
+	result.Action, result.ID = models.ImportCreated, id
+	return result
+}
+
+// memberFromRow reads the cells of a member row. expires_at may be in the
+// RFC 3339 form an export writes when the file was edited elsewhere.
+func memberFromRow(row bulk.Row) (*models.Member, error) {
+	m := &models.Member{
+		Name:     strings.Join(strings.Fields(row.Get("name")), " "),
+		Email:    row.Get("email"),
+		RollNo:   row.Get("roll_no"),
+		Category: strings.ToLower(row.Get("category")),
+	}
+	v := row.Get("expires_at")
+	expires, err := memberExpiry(&v)
+	if err != nil {
+		return m, err
+	}
+	m.ExpiresAt = expires
+	return m, nil
+}
+
+// mergeMemberRow lays the non-empty cells of m over existing.
+func mergeMemberRow(existing models.Member, m *models.Member) models.Member {
+	if m.Name != "" {
+		existing.Name = m.Name
+	}
+	if m.Email != "" {
+		existing.Email = m.Email
+	}
+	if m.ExpiresAt != nil {
+		existing.ExpiresAt = m.ExpiresAt
+	} else if existing.ExpiresAt != nil {
+		v := dateCell(*existing.ExpiresAt)
+		existing.ExpiresAt = &v
+	}
+	existing.Category = m.Category
+	return existing
+}
+
+// importMemberRow creates or updates one member. On update, empty cells
+// leave the member as they are.
+func importMemberRow(ctx context.Context, r *repository.Repo, row bulk.Row) models.ImportRow {
+	m, err := memberFromRow(row)
+	result := models.ImportRow{Row: row.N, Key: m.RollNo, Title: m.Name}
+	skip := func(err error) models.ImportRow {
+		result.Action, result.Error = models.ImportSkipped, err.Error()
+		return result
+	}
+	if err != nil {
+		return skip(err)
+	}
+	if len(m.RollNo) > 100 {
+		return skip(errors.New("roll_no is too long"))
+	}
+
+	if m.RollNo != "" {
+		matches, err := r.MemberRepo.GetByRollNo(m.RollNo)
+		if err != nil {
+			return skip(err)
+		}
+		var active []models.Member
+		for _, match := range matches {
+			if match.DeletedAt == nil {
+				active = append(active, match)
+			}
+		}
+		switch {
+		case len(active) > 1:
+			return skip(fmt.Errorf("roll_no %s is shared by %d members", m.RollNo, len(active)))
+		case len(active) == 1:
+			existing := mergeMemberRow(active[0], m)
+			if err := UpdateMember(ctx, r, existing.ID, &existing); err != nil {
+				return skip(err)
+			}
+			result.Action, result.ID = models.ImportUpdated, existing.ID
+			return result
+		case len(matches) > 0:
+			return skip(fmt.Errorf("roll_no %s belongs to deleted member %d", m.RollNo, matches[0].ID))
+		}
+	}
+
+	if m.Name == "" {
+		return skip(errors.New("name is required"))
+	}
+	id, err := CreateMember(ctx, r, m)
+	if err != nil {
+		return skip(err)
+	}
+	result.Action, result.ID = models.ImportCreated, id
+	return result
+}
+
+var (
+	BookExportColumns = []string{"id", "title", "authors", "isbn", "publisher", "publication_year", "edition",
+		"language", "format", "page_count", "description", "call_number", "subjects", "copies", "available"}
+	MemberExportColumns = []string{"id", "name", "email", "roll_no", "category", "expires_at", "created_at"}
+)
+
+// dateCell writes a DATE column, which the driver reads back as a
+// timestamp, as YYYY-MM-DD.
+func dateCell(s string) string {
+	d, err := parseDate(s)
+	if err != nil {
+		return s
+	}
+	return d.Format("2006-01-02")
+}
+
+func optInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
+
+// ExportBooks writes every book f matches, in BookExportColumns, in a form
+// an import reads back.
+func ExportBooks(r *repository.Repo, f models.BookFilter, w *bulk.Writer) error {
+	f.Limit = maxPageSize
+	for {
+		page, err := ListBooks(r, f)
+		if err != nil {
+			return err
+		}
+		for _, b := range page.Items {
+			if b.Subjects, err = r.BookRepo.GetSubjects(b.ID); err != nil {
+				return err
+			}
+			if b.Contributors, err = r.BookRepo.GetContributors(b.ID); err != nil {
+				return err
+			}
+			code := ""
+			if b.ISBN != nil {
+				code = *b.ISBN
+			}
+			err := w.Write([]string{
+				strconv.FormatInt(b.ID, 10),
+				b.Title,
+				contributorCell(b.Contributors),
+				code,
+				b.Publisher,
+				optInt(b.PublicationYear),
+				b.Edition,
+				b.Language,
+				b.Format,
+				optInt(b.PageCount),
+				b.Description,
+				b.CallNumber,
+				strings.Join(b.Subjects, bulk.ListSep+" "),
+				strconv.Itoa(b.Copies),
+				strconv.Itoa(b.Available),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return w.Flush()
+		}
+		f.Cursor = page.NextCursor
+	}
+}
+
+// ExportMembers writes every member f matches, in MemberExportColumns.
+func ExportMembers(r *repository.Repo, f models.MemberFilter, w *bulk.Writer) error {
+	f.Limit = maxPageSize
+	for {
+		page, err := ListMembers(r, f)
+		if err != nil {
+			return err
+		}
+		for _, m := range page.Items {
+			expires := ""
+			if m.ExpiresAt != nil {
+				expires = dateCell(*m.ExpiresAt)
+			}
+			err := w.Write([]string{
+				strconv.FormatInt(m.ID, 10),
+				m.Name,
+				m.Email,
+				m.RollNo,
+				m.Category,
+				expires,
+				m.CreatedAt.UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return w.Flush()
+		}
+		f.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"library-management/service/marc"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// maxMARCExport caps how many books one export may ask for.
+const maxMARCExport = 1000
+
+// errDryRun rolls back the transaction a dry run works in.
+var errDryRun = errors.New("dry run")
+
+// ImportMARC adds the records read from rr to the catalog. A record whose
+// ISBN is already catalogued updates that book, any other creates one;
+// records that can't be mapped or fail validation are skipped and the rest
+// go on. With dryRun everything runs in one transaction that is rolled
+// back, so the report shows exactly what an import would do.
+func ImportMARC(ctx context.Context, r *repository.Repo, rr marc.Reader, dryRun bool) (*models.ImportReport, error) {
+	if !dryRun {
+		return importMARC(ctx, r, rr, false), nil
+	}
+	var report *models.ImportReport
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		report = importMARC(ctx, tx, rr, true)
+		return errDryRun
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+func importMARC(ctx context.Context, r *repository.Repo, rr marc.Reader, dryRun bool) *models.ImportReport {
+	report := &models.ImportReport{DryRun: dryRun, Rows: []models.ImportRow{}}
+	for n := 1; ; n++ {
+		rec, err := rr.Read()
+		if err == io.EOF {
+			return report
+		}
+		var perr *marc.ParseError
+		if errors.As(err, &perr) {
+			report.Add(models.ImportRow{Row: n, Action: models.ImportSkipped, Error: perr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			report.Error = fmt.Sprintf("record %d: %v", n, err)
+			return report
+		}
+
+		row := importMARCRecord(ctx, r, rec)
+		row.Row = n
+		if dryRun && row.Action == models.ImportCreated {
+			// the id is rolled back with everything else
+			row.ID = 0
+		}
+		report.Add(row)
+	}
+}
+
+func importMARCRecord(ctx context.Context, r *repository.Repo, rec *marc.Record) models.ImportRow {
+	b, err := marc.ToBook(rec)
+	if err != nil {
+		return models.ImportRow{Action: models.ImportSkipped, Error: err.Error()}
+	}
+	row := models.ImportRow{Title: b.Title}
+	if b.ISBN != nil {
+		row.Key = *b.ISBN
+	}
+	skip := func(err error) models.ImportRow {
+		row.Action, row.Error = models.ImportSkipped, err.Error()
+		return row
+	}
+
+	if b.ISBN != nil {
+		existing, err := r.BookRepo.GetByISBN(*b.ISBN)
+		if err != nil {
+			return skip(err)
+		}
+		if existing != nil {
+			if existing.DeletedAt != nil {
+				return skip(fmt.Errorf("isbn %s belongs to deleted book %d", *b.ISBN, existing.ID))
+			}
+			if err := UpdateBook(ctx, r, existing.ID, b); err != nil {
+				return skip(err)
+			}
+			row.Action, row.ID = models.ImportUpdated, existing.ID
+			return row
+		}
+	}
+
+	id, err := CreateBook(ctx, r, b)
+	if err != nil {
+		return skip(err)
+	}
+	row.Action, row.ID = models.ImportCreated, id
+	return row
+}
+
+// ExportMARC writes the books with the given ids as MARC records, in the
+// order asked for. All of them must exist.
+func ExportMARC(r *repository.Repo, ids []int64, w marc.Writer) error {
+	if len(ids) == 0 {
+		return errors.New("ids is required")
+	}
+	if len(ids) > maxMARCExport {
+		return fmt.Errorf("at most %d books can be exported at once", maxMARCExport)
+	}
+	books := make([]*models.Book, len(ids))
+	for i, id := range ids {
+		b, err := GetBook(r, id)
+		if err != nil {
+			return err
+		}
+		if b == nil {
+			return fmt.Errorf("book %d not found", id)
+		}
+		books[i] = b
+	}
+	for _, b := range books {
+		if err := w.Write(marc.FromBook(b)); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/notify"
+	"library-management/service/repository"
+)
+
+// NoticeConfig says when members hear about their loans. A reminder goes
+// out DueSoonDays before the due date; overdue notices escalate one level
+// each time the loan passes another entry of EscalationDays (days late).
+type NoticeConfig struct {
+	DueSoonDays    int
+	EscalationDays []int
+}
+
+func DefaultNoticeConfig() NoticeConfig {
+	return NoticeConfig{DueSoonDays: 2, EscalationDays: []int{1, 7, 14}}
+}
+
+// SendNotices scans open loans and sends every reminder or overdue notice
+// that is due and has not been sent yet. It returns how many were sent;
+// notices that failed are retried on the next run.
+func SendNotices(ctx context.Context, r *repository.Repo, n notify.Notifier, cfg NoticeConfig) (int, error) {
+	escalations := append([]int(nil), cfg.EscalationDays...)
+	sort.Ints(escalations)
+
+	day := today()
+	loans, err := r.NoticeRepo.GetLoansDueBefore(day.AddDate(0, 0, cfg.DueSoonDays).Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, loan := range loans {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		if loan.Email == "" {
+			continue
+		}
+
+		kind, level := models.NoticeReminder, 0
+		overdue, err := daysOverdue(&loan.DueDate, day)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("issue %d: %w", loan.IssueID, err))
+			continue
+		}
+		if overdue > 0 {
+			kind = models.NoticeOverdue
+			level = escalationLevel(escalations, overdue)
+			if level == 0 {
+				continue
+			}
+		}
+
+		last, err := r.NoticeRepo.MaxLevel(loan.IssueID, loan.DueDate, kind)
+		if err != nil {
+			return sent, err
+		}
+		if last >= level {
+			continue
+		}
+
+		ok, err := sendNotice(ctx, r, n, &loan, kind, level, overdue)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("issue %d: %w", loan.IssueID, err))
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+func ListNotices(r *repository.Repo, memberID int64) ([]models.Notice, error) {
+	return r.NoticeRepo.GetByMember(memberID)
+}
+
+func escalationLevel(escalations []int, overdue int) int {
+	level := 0
+	for _, days := range escalations {
+		if overdue >= days {
+			level++
+		}
+	}
+	return level
+}
+
+func sendNotice(ctx context.Context, r *repository.Repo, n notify.Notifier, loan *models.DueLoan, kind string, level, overdue int) (bool, error) {
+	msg := noticeMessage(loan, kind, level, overdue)
+	id, claimed, err := r.NoticeRepo.Claim(&models.Notice{
+		IssueID:   loan.IssueID,
+		MemberID:  loan.MemberID,
+		DueDate:   loan.DueDate,
+		Kind:      kind,
+		Level:     level,
+		Channel:   n.Channel(),
+		Recipient: msg.To,
+		Subject:   msg.Subject,
+	})
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := n.Send(ctx, msg); err != nil {
+		if relErr := r.NoticeRepo.Release(id); relErr != nil {
+			return false, errors.Join(err, relErr)
+		}
+		return false, err
+	}
+	now := time.Now()
+	return true, r.NoticeRepo.Finish(id, models.NoticeSent, nil, &now)
+}
+
+func noticeMessage(loan *models.DueLoan, kind string, level, overdue int) notify.Message {
+	due, _ := parseDate(loan.DueDate)
+	dueStr := due.Format("2 January 2006")
+
+	m := notify.Message{To: loan.Email, ToName: loan.MemberName}
+	if kind == models.NoticeReminder {
+		m.Subject = fmt.Sprintf("Reminder: %q is due on %s", loan.Title, dueStr)
+		m.Body = fmt.Sprintf("Dear %s,\n\n%q by %s is due back on %s.\nPlease return or renew it before then to avoid fines.\n",
+			loan.MemberName, loan.Title, loan.Author, dueStr)
+		return m
+	}
+
+	switch level {
+	case 1:
+		m.Subject = fmt.Sprintf("Overdue: %q", loan.Title)
+	case 2:
+		m.Subject = fmt.Sprintf("Second notice - overdue: %q", loan.Title)
+	default:
+		m.Subject = fmt.Sprintf("Final notice - overdue: %q", loan.Title)
+	}
+	m.Body = fmt.Sprintf("Dear %s,\n\n%q by %s was due on %s and is now %d days overdue.\nFines are accruing; please return it as soon as possible.\n",
+		loan.MemberName, loan.Title, loan.Author, dueStr, overdue)
+	return m
+}
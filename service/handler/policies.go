@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+const DefaultMemberCategory = "student"
+
+// ErrInvalidMember wraps the reasons a member's fields are refused, as
+// opposed to a failure to read or save them.
+var ErrInvalidMember = errors.New("invalid member")
+
+func ListPolicies(r *repository.Repo) ([]models.LoanPolicy, error) {
+	return r.PolicyRepo.GetAll()
+}
+
+func GetPolicy(r *repository.Repo, category string) (*models.LoanPolicy, error) {
+	return r.PolicyRepo.GetByCategory(category)
+}
+
+// SavePolicy creates the category if it is new, otherwise replaces its limits.
+func SavePolicy(r *repository.Repo, category string, p *models.LoanPolicy) error {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		return errors.New("category is required")
+	}
+	p.Category = category
+	return r.PolicyRepo.Upsert(p)
+}
+
+func DeletePolicy(r *repository.Repo, category string) error {
+	n, err := r.PolicyRepo.CountMembers(category)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("%d members are in category %s", n, category)
+	}
+	return r.PolicyRepo.Delete(category)
+}
+
+func checkCategory(r *repository.Repo, category string) error {
+	p, err := r.PolicyRepo.GetByCategory(category)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return fmt.Errorf("%w: unknown member category %q", ErrInvalidMember, category)
+	}
+	return nil
+}
+
+func policyForMember(r *repository.Repo, memberID int64) (*models.LoanPolicy, error) {
+	member, err := r.MemberRepo.GetByID(memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, errors.New("member not found")
+	}
+	p, err := r.PolicyRepo.GetByCategory(member.Category)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("no loan policy for category %s", member.Category)
+	}
+	return p, nil
+}
+
+func today() time.Time {
+	t, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	return t
+}
+
+// parseDate reads a DATE column. With parseTime enabled the driver hands
+// dates back as RFC 3339 timestamps, so only the date part is used.
+func parseDate(s string) (time.Time, error) {
+	if len(s) > len("2006-01-02") {
+		s = s[:len("2006-01-02")]
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// daysOverdue counts whole calendar days past the due date; a loan
+// returned on its due date is not overdue.
+func daysOverdue(dueDate *string, on time.Time) (int, error) {
+	if dueDate == nil || *dueDate == "" {
+		return 0, nil
+	}
+	due, err := parseDate(*dueDate)
+	if err != nil {
+		return 0, fmt.Errorf("due date %q: %w", *dueDate, err)
+	}
+	day, _ := time.Parse("2006-01-02", on.Format("2006-01-02"))
+	if !day.After(due) {
+		return 0, nil
+	}
+	return int(day.Sub(due).Hours() / 24), nil
+}
+
+func computeFine(p *models.LoanPolicy, overdue int) float64 {
+	chargeable := overdue - p.GraceDays
+	if overdue <= 0 || chargeable <= 0 {
+		return 0
+	}
+	fine := float64(chargeable) * p.FinePerDay
+	if p.FineCap > 0 && fine > p.FineCap {
+		fine = p.FineCap
+	}
+	return math.Round(fine*100) / 100
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"library-management/service/models"
+)
+
+func TestComputeFine(t *testing.T) {
+	p := &models.LoanPolicy{FinePerDay: 0.25, GraceDays: 2, FineCap: 5}
+	tests := []struct {
+		overdue int
+		want    float64
+	}{
+		{-3, 0},
+		{0, 0},
+		{1, 0},
+		{2, 0},
+		{3, 0.25},
+		{10, 2},
+		{22, 5},
+		{100, 5},
+	}
+	for _, tt := range tests {
+		if got := computeFine(p, tt.overdue); got != tt.want {
+			t.Errorf("computeFine(%d days) = %v, want %v", tt.overdue, got, tt.want)
+		}
+	}
+
+	uncapped := &models.LoanPolicy{FinePerDay: 0.1}
+	if got := computeFine(uncapped, 3); got != 0.3 {
+		t.Errorf("uncapped fine for 3 days = %v, want 0.3 rounded to cents", got)
+	}
+	if got := computeFine(uncapped, 1000); got != 100 {
+		t.Errorf("uncapped fine for 1000 days = %v, want 100", got)
+	}
+}
+
+func TestDaysOverdue(t *testing.T) {
+	on := time.Date(2026, 10, 18, 15, 30, 0, 0, time.Local)
+	if got, err := daysOverdue(nil, on); got != 0 || err != nil {
+		t.Errorf("daysOverdue(nil) = %d, %v; want 0", got, err)
+	}
+	tests := []struct {
+		due  string
+		want int
+	}{
+		{"", 0},
+		{"2026-10-20", 0},
+		{"2026-10-18", 0},
+		{"2026-10-17", 1},
+		{"2026-09-18", 30},
+		// the driver's parseTime form of a DATE column
+		{"2026-10-11T00:00:00Z", 7},
+	}
+	for _, tt := range tests {
+		got, err := daysOverdue(&tt.due, on)
+		if err != nil || got != tt.want {
+			t.Errorf("daysOverdue(%q) = %d, %v; want %d", tt.due, got, err, tt.want)
+		}
+	}
+	bad := "18/10/2026"
+	if _, err := daysOverdue(&bad, on); err == nil {
+		t.Error("daysOverdue accepted a malformed date")
+	}
+}
+
+func TestMemberExpiry(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "2026-12-31", want: "2026-12-31"},
+		{in: "2026-12-31T00:00:00Z", want: "2026-12-31"},
+		{in: "31/12/2026", wantErr: true},
+		{in: "2026-13-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := memberExpiry(&tt.in)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidMember) {
+				t.Errorf("memberExpiry(%q) = %v, %v; want ErrInvalidMember", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got == nil || *got != tt.want {
+			t.Errorf("memberExpiry(%q) = %v, %v; want %s", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []*string{nil, new(string)} {
+		if got, err := memberExpiry(in); got != nil || err != nil {
+			t.Errorf("memberExpiry(%v) = %v, %v; want no expiry", in, got, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"library-management/service/covers"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// PurgeResult counts what one purge run cleaned up.
+type PurgeResult struct {
+	MembersAnonymized int `json:"members_anonymized"`
+	BooksPurged       int `json:"books_purged"`
+	Failed            int `json:"failed"`
+}
+
+// PurgeDeleted finishes off records that were soft deleted more than
+// afterDays ago. Members keep their row, so loan and fine history stays
+// intact, but lose their personal details, there and in the audit log,
+// the outbox, webhook deliveries and notices. Books are removed for good only
+// when they never circulated and have no holds, with their cover files in
+// store; the rest stay soft deleted.
+//
+// Each record is handled in its own transaction, so one that fails is
+// logged and counted in Failed and retried on the next run without
+// holding back the others.
+func PurgeDeleted(ctx context.Context, r *repository.Repo, store covers.Storage, afterDays int) (PurgeResult, error) {
+	var res PurgeResult
+	before := time.Now().AddDate(0, 0, -afterDays)
+
+	ids, err := r.MemberRepo.GetToAnonymize(before)
+	if err != nil {
+		return res, err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		var ok bool
+		err := r.WithTx(ctx, func(tx *repository.Repo) error {
+			var err error
+			if ok, err = tx.MemberRepo.Anonymize(id, time.Now()); err != nil || !ok {
+				return err
+			}
+			if err := tx.MemberRepo.RedactHistory(id); err != nil {
+				return err
+			}
+			return publish(tx, models.EventMemberAnonymized, id, map[string]interface{}{"member_id": id})
+		})
+		if err != nil {
+			log.Printf("purge: anonymize member %d: %v", id, err)
+			res.Failed++
+			continue
+		}
+		if ok {
+			res.MembersAnonymized++
+		}
+	}
+
+	ids, err = r.BookRepo.GetPurgeable(before)
+	if err != nil {
+		return res, err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		ok, err := purgeBook(r, store, id)
+		if err != nil {
+			log.Printf("purge: book %d: %v", id, err)
+			res.Failed++
+			continue
+		}
+		if ok {
+			res.BooksPurged++
+		}
+	}
+	return res, nil
+}
+
+// purgeBook deletes a book for good. Its cover row goes with it; the cover
+// files are removed once the book is gone.
+func purgeBook(r *repository.Repo, store covers.Storage, id int64) (bool, error) {
+	cover, err := r.BookRepo.GetCover(id)
+	if err != nil {
+		return false, err
+	}
+	ok, err := r.BookRepo.Purge(id)
+	if err != nil || !ok {
+		return false, err
+	}
+	if cover != nil {
+		removeCoverFiles(store, cover)
+	}
+	return true, nil
+}
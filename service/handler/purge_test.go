@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"library-management/service/covers"
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+type purgeBooks struct {
+	repository.BookRepo
+	cover    *models.BookCover
+	purgeErr error
+	purged   bool
+}
+
+func (b *purgeBooks) GetCover(id int64) (*models.BookCover, error) {
+	return b.cover, nil
+}
+
+func (b *purgeBooks) Purge(id int64) (bool, error) {
+	return b.purged, b.purgeErr
+}
+
+func TestPurgeBookRemovesCoverFiles(t *testing.T) {
+	cover := &models.BookCover{BookID: 7, ETag: "abc", ContentType: covers.TypePNG}
+	tests := []struct {
+		name      string
+		books     *purgeBooks
+		wantOK    bool
+		wantFiles bool
+	}{
+		{"purged", &purgeBooks{cover: cover, purged: true}, true, false},
+		{"purged without cover", &purgeBooks{purged: true}, true, true},
+		{"no longer purgeable", &purgeBooks{cover: cover}, false, true},
+		{"purge failed", &purgeBooks{cover: cover, purgeErr: errors.New("foreign key")}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := covers.NewLocalStorage(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, key := range coverFiles(cover) {
+				if err := store.Put(key, []byte("img")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ok, err := purgeBook(&repository.Repo{BookRepo: tt.books}, store, 7)
+			if ok != tt.wantOK || (err != nil) != (tt.books.purgeErr != nil) {
+				t.Errorf("purgeBook = %v, %v; want %v", ok, err, tt.wantOK)
+			}
+			for _, key := range coverFiles(cover) {
+				_, err := store.Get(key)
+				if kept := err == nil; kept != tt.wantFiles {
+					t.Errorf("%s kept = %v, want %v", key, kept, tt.wantFiles)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// RenewIssue pushes the due date of an open loan out by another loan
+// period, counted from the later of today and the current due date.
+func RenewIssue(ctx context.Context, r *repository.Repo, issueID int64) (*models.Issue, error) {
+	var renewed *models.Issue
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		issue, err := tx.IssueRepo.GetByIDForUpdate(issueID)
+		if err != nil {
+			return err
+		}
+		if issue == nil {
+			return errors.New("issue record not found")
+		}
+		if issue.ReturnedAt != nil {
+			return errors.New("already returned")
+		}
+
+		policy, err := policyForMember(tx, issue.MemberID)
+		if err != nil {
+			return err
+		}
+		if issue.Renewals >= policy.MaxRenewals {
+			return fmt.Errorf("renewal limit of %d reached", policy.MaxRenewals)
+		}
+
+		waiting, err := tx.HoldRepo.CountWaiting(issue.BookID)
+		if err != nil {
+			return err
+		}
+		if waiting > 0 {
+			return errors.New("book has pending holds")
+		}
+
+		overdue, err := daysOverdue(issue.DueDate, time.Now())
+		if err != nil {
+			return err
+		}
+		if overdue > policy.RenewOverdueMaxDays {
+			return fmt.Errorf("loan is overdue by %d days", overdue)
+		}
+		from := today()
+		if issue.DueDate != nil && *issue.DueDate != "" {
+			due, err := parseDate(*issue.DueDate)
+			if err != nil {
+				return err
+			}
+			if due.After(from) {
+				from = due
+			}
+		}
+
+		dueDate := from.AddDate(0, 0, policy.LoanDays).Format("2006-01-02")
+		ok, err := tx.IssueRepo.Renew(issueID, dueDate, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("already returned")
+		}
+
+		renewed, err = tx.IssueRepo.GetByID(issueID)
+		if err != nil {
+			return err
+		}
+		data := issueEvent(renewed, "", nil)
+		data["renewal_count"] = renewed.Renewals
+		return publish(tx, models.EventBookRenewed, renewed.BookID, data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renewed, nil
+}
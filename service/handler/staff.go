@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+func loadAccess(r *repository.Repo, a *models.Admin) error {
+	roles, err := r.AdminRepo.GetRoleNames(a.ID)
+	if err != nil {
+		return err
+	}
+	perms, err := r.AdminRepo.GetPermissions(a.ID)
+	if err != nil {
+		return err
+	}
+	a.Roles = roles
+	a.Permissions = perms
+	return nil
+}
+
+func resolveRoles(r *repository.Repo, names []string) ([]int64, error) {
+	ids := make([]int64, 0, len(names))
+	seen := map[int64]bool{}
+	for _, name := range names {
+		role, err := r.RoleRepo.GetByName(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return nil, fmt.Errorf("unknown role %q", name)
+		}
+		if !seen[role.ID] {
+			seen[role.ID] = true
+			ids = append(ids, role.ID)
+		}
+	}
+	return ids, nil
+}
+
+var ErrLastStaffManager = errors.New("at least one active account must keep the " + models.PermStaffManage + " permission")
+
+// keepStaffManager fails when a change made in tx left no active account
+// able to manage staff, which would lock everyone out of the staff and
+// role endpoints.
+func keepStaffManager(tx *repository.Repo) error {
+	n, err := tx.AdminRepo.CountActiveWithPermissionForUpdate(models.PermStaffManage)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLastStaffManager
+	}
+	return nil
+}
+
+func validatePermissions(perms []string) error {
+	for _, p := range perms {
+		if !models.IsPermission(p) {
+			return fmt.Errorf("unknown permission %q", p)
+		}
+	}
+	return nil
+}
+
+func ListStaff(r *repository.Repo) ([]models.Admin, error) {
+	staff, err := r.AdminRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range staff {
+		if err := loadAccess(r, &staff[i]); err != nil {
+			return nil, err
+		}
+	}
+	return staff, nil
+}
+
+func GetStaff(r *repository.Repo, id int64) (*models.Admin, error) {
+	a, err := r.AdminRepo.GetByID(id)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	if err := loadAccess(r, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func CreateStaff(ctx context.Context, r *repository.Repo, username, password string, roles []string) (int64, error) {
+	var id int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		roleIDs, err := resolveRoles(tx, roles)
+		if err != nil {
+			return err
+		}
+		id, err = CreateAdmin(tx, username, password)
+		if err != nil {
+			return err
+		}
+		return tx.AdminRepo.SetRoles(id, roleIDs)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func UpdateStaff(r *repository.Repo, actorID, id int64, password *string, active *bool) error {
+	existing, err := r.AdminRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("staff account not found")
+	}
+
+	if password != nil {
+		hash, err := HashPassword(*password)
+		if err != nil {
+			return err
+		}
+		if err := r.AdminRepo.UpdatePassword(id, hash); err != nil {
+			return err
+		}
+		if err := r.SessionRepo.RevokeAllForAdmin(id, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if active != nil && *active != existing.Active {
+		if !*active && id == actorID {
+			return errors.New("you cannot deactivate your own account")
+		}
+		if err := r.AdminRepo.SetActive(id, *active); err != nil {
+			return err
+		}
+		if !*active {
+			return r.SessionRepo.RevokeAllForAdmin(id, time.Now())
+		}
+	}
+	return nil
+}
+
+func DeactivateStaff(r *repository.Repo, actorID, id int64) error {
+	inactive := false
+	return UpdateStaff(r, actorID, id, nil, &inactive)
+}
+
+func SetStaffRoles(ctx context.Context, r *repository.Repo, id int64, roles []string) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.AdminRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("staff account not found")
+		}
+		roleIDs, err := resolveRoles(tx, roles)
+		if err != nil {
+			return err
+		}
+		if err := tx.AdminRepo.SetRoles(id, roleIDs); err != nil {
+			return err
+		}
+		return keepStaffManager(tx)
+	})
+}
+
+func ListRoles(r *repository.Repo) ([]models.Role, error) {
+	roles, err := r.RoleRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		perms, err := r.RoleRepo.GetPermissions(roles[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = perms
+	}
+	return roles, nil
+}
+
+func CreateRole(ctx context.Context, r *repository.Repo, role *models.Role) (int64, error) {
+	if err := validatePermissions(role.Permissions); err != nil {
+		return 0, err
+	}
+	var id int64
+	err := r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.RoleRepo.GetByName(role.Name)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return errors.New("role already exists")
+		}
+		id, err = tx.RoleRepo.Create(role)
+		if err != nil {
+			return err
+		}
+		return tx.RoleRepo.SetPermissions(id, role.Permissions)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func UpdateRole(ctx context.Context, r *repository.Repo, id int64, input *models.Role) error {
+	if err := validatePermissions(input.Permissions); err != nil {
+		return err
+	}
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		existing, err := tx.RoleRepo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return errors.New("role not found")
+		}
+		existing.Name = input.Name
+		existing.Description = input.Description
+		if err := tx.RoleRepo.Update(existing); err != nil {
+			return err
+		}
+		if err := tx.RoleRepo.SetPermissions(id, input.Permissions); err != nil {
+			return err
+		}
+		return keepStaffManager(tx)
+	})
+}
+
+func DeleteRole(ctx context.Context, r *repository.Repo, id int64) error {
+	return r.WithTx(ctx, func(tx *repository.Repo) error {
+		if err := tx.RoleRepo.Delete(id); err != nil {
+			return err
+		}
+		return keepStaffManager(tx)
+	})
+}
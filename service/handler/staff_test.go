@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+type managerCount struct {
+	repository.AdminRepo
+	n    int
+	perm string
+}
+
+func (a *managerCount) CountActiveWithPermissionForUpdate(perm string) (int, error) {
+	a.perm = perm
+	return a.n, nil
+}
+
+func TestKeepStaffManager(t *testing.T) {
+	admins := &managerCount{}
+	r := &repository.Repo{AdminRepo: admins}
+	if err := keepStaffManager(r); !errors.Is(err, ErrLastStaffManager) {
+		t.Errorf("no managers left: got %v, want ErrLastStaffManager", err)
+	}
+	if admins.perm != models.PermStaffManage {
+		t.Errorf("counted %q, want %q", admins.perm, models.PermStaffManage)
+	}
+	admins.n = 1
+	if err := keepStaffManager(r); err != nil {
+		t.Errorf("one manager left: %v", err)
+	}
+}
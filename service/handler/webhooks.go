@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+	"library-management/service/webhook"
+)
+
+const (
+	deliveryBatch = 50
+	deliveryLease = time.Minute
+)
+
+// WebhookSink turns dispatched events into deliveries for every active
+// webhook that wants them. Deliveries are unique per webhook and event, so
+// an event dispatched twice is still posted once.
+type WebhookSink struct {
+	repo *repository.Repo
+}
+
+func NewWebhookSink(r *repository.Repo) *WebhookSink {
+	return &WebhookSink{repo: r}
+}
+
+func (s *WebhookSink) Name() string { return "webhooks" }
+
+func (s *WebhookSink) Publish(ctx context.Context, e models.Event) error {
+	hooks, err := s.repo.WebhookRepo.GetActive()
+	if err != nil {
+		return err
+	}
+	var payload []byte
+	for _, w := range hooks {
+		if !w.Wants(e.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+		_, err := s.repo.WebhookRepo.CreateDelivery(&models.WebhookDelivery{
+			WebhookID:     w.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			NextAttemptAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func CreateWebhook(r *repository.Repo, w *models.Webhook) (int64, error) {
+	if err := checkWebhook(w); err != nil {
+		return 0, err
+	}
+	if w.Secret == "" {
+		secret, err := randomHex(24)
+		if err != nil {
+			return 0, err
+		}
+		w.Secret = "whsec_" + secret
+	}
+	id, err := r.WebhookRepo.Create(w)
+	if err != nil {
+		return 0, err
+	}
+	w.ID = id
+	return id, nil
+}
+
+func ListWebhooks(r *repository.Repo) ([]models.Webhook, error) {
+	hooks, err := r.WebhookRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+func GetWebhook(r *repository.Repo, id int64) (*models.Webhook, error) {
+	w, err := r.WebhookRepo.GetByID(id)
+	if w != nil {
+		w.Secret = ""
+	}
+	return w, err
+}
+
+// UpdateWebhook replaces a webhook's settings. active is nil when the
+// request left it out, which keeps the webhook enabled or disabled.
+func UpdateWebhook(r *repository.Repo, id int64, input *models.Webhook, active *bool) error {
+	existing, err := r.WebhookRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("webhook not found")
+	}
+
+	existing.URL = input.URL
+	existing.Events = input.Events
+	if active != nil {
+		existing.Active = *active
+	}
+	existing.Description = input.Description
+	if err := checkWebhook(existing); err != nil {
+		return err
+	}
+	return r.WebhookRepo.Update(existing)
+}
+
+func DeleteWebhook(r *repository.Repo, id int64) error {
+	return r.WebhookRepo.Delete(id)
+}
+
+func ListWebhookDeliveries(r *repository.Repo, webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return r.WebhookRepo.GetDeliveries(webhookID, status, limit)
+}
+
+func GetWebhookDelivery(r *repository.Repo, id int64) (*models.WebhookDelivery, error) {
+	d, err := r.WebhookRepo.GetDelivery(id)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	d.AttemptLog, err = r.WebhookRepo.GetAttempts(id)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// RetryWebhookDelivery queues a failed or pending delivery to be sent on the
+// next run.
+func RetryWebhookDelivery(r *repository.Repo, id int64) error {
+	ok, err := r.WebhookRepo.RetryDelivery(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("delivery not found or already delivered")
+	}
+	return nil
+}
+
+// DeliverWebhooks sends the deliveries that are due. A failed attempt is
+// retried with exponential backoff until webhook.MaxAttempts, after which
+// the delivery is marked failed. It returns how many were delivered.
+func DeliverWebhooks(ctx context.Context, r *repository.Repo, sender *webhook.Sender) (int, error) {
+	now := time.Now()
+	due, err := r.WebhookRepo.GetDueDeliveries(now, deliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range due {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		d := &due[i]
+		leased, err := r.WebhookRepo.LeaseDelivery(d.ID, now, now.Add(deliveryLease))
+		if err != nil {
+			return delivered, err
+		}
+		if !leased {
+			continue
+		}
+		ok, err := deliver(ctx, r, sender, d)
+		if err != nil {
+			return delivered, err
+		}
+		if ok {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+func deliver(ctx context.Context, r *repository.Repo, sender *webhook.Sender, d *models.WebhookDelivery) (bool, error) {
+	w, err := r.WebhookRepo.GetByID(d.WebhookID)
+	if err != nil {
+		return false, err
+	}
+
+	attempt := &models.WebhookAttempt{DeliveryID: d.ID}
+	var sendErr error
+	if w == nil || !w.Active {
+		sendErr = errors.New("webhook is disabled")
+	} else {
+		var res webhook.Result
+		res, sendErr = sender.Send(ctx, w.URL, w.Secret, d.EventType, d.ID, d.Payload)
+		attempt.DurationMS = int(res.Duration.Milliseconds())
+		if res.StatusCode != 0 {
+			attempt.StatusCode = &res.StatusCode
+			attempt.ResponseBody = &res.Body
+		}
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		attempt.Error = &msg
+	}
+	if _, err := r.WebhookRepo.AddAttempt(attempt); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	d.Attempts++
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+	d.NextAttemptAt = now
+	switch {
+	case sendErr == nil:
+		d.Status = models.DeliveryDelivered
+		d.DeliveredAt = &now
+	case d.Attempts >= webhook.MaxAttempts || w == nil:
+		d.Status = models.DeliveryFailed
+	default:
+		d.Status = models.DeliveryPending
+		d.NextAttemptAt = now.Add(webhook.Backoff(d.Attempts))
+	}
+	return sendErr == nil, r.WebhookRepo.FinishDelivery(d)
+}
+
+func checkWebhook(w *models.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http(s) URL")
+	}
+
+	w.Events = strings.TrimSpace(w.Events)
+	if w.Events == "" {
+		w.Events = "*"
+	}
+	var events []string
+	for _, e := range strings.Split(w.Events, ",") {
+		e = strings.TrimSpace(e)
+		if e != "*" && !models.IsEvent(e) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+		events = append(events, e)
+	}
+	w.Events = strings.Join(events, ",")
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"testing"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+type webhookStore struct {
+	repository.WebhookRepo
+	hook  models.Webhook
+	saved *models.Webhook
+}
+
+func (s *webhookStore) GetByID(id int64) (*models.Webhook, error) {
+	w := s.hook
+	return &w, nil
+}
+
+func (s *webhookStore) Update(w *models.Webhook) error {
+	s.saved = w
+	return nil
+}
+
+func TestUpdateWebhookActive(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name   string
+		was    bool
+		active *bool
+		want   bool
+	}{
+		{"left out keeps disabled", false, nil, false},
+		{"left out keeps enabled", true, nil, true},
+		{"enable", false, &on, true},
+		{"disable", true, &off, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &webhookStore{hook: models.Webhook{ID: 3, URL: "https://example.org/hook", Events: "*", Active: tt.was}}
+			// the request's webhook is active by default, as on create
+			input := &models.Webhook{URL: "https://example.org/new", Events: "book.created", Active: true}
+			if err := UpdateWebhook(&repository.Repo{WebhookRepo: store}, 3, input, tt.active); err != nil {
+				t.Fatal(err)
+			}
+			if store.saved.Active != tt.want {
+				t.Errorf("active = %v, want %v", store.saved.Active, tt.want)
+			}
+			if store.saved.URL != input.URL || store.saved.Events != "book.created" {
+				t.Errorf("saved %+v", store.saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package isbn validates and normalizes International Standard Book
+// Numbers. The catalog stores every ISBN in its 13-digit form.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid ISBN")
+
+// Normalize accepts an ISBN-10 or ISBN-13 with or without hyphens and
+// spaces, checks its check digit and returns the 13-digit form.
+func Normalize(s string) (string, error) {
+	digits := Clean(s)
+	switch len(digits) {
+	case 10:
+		if !valid10(digits) {
+			return "", ErrInvalid
+		}
+		return To13(digits), nil
+	case 13:
+		if !valid13(digits) {
+			return "", ErrInvalid
+		}
+		return digits, nil
+	}
+	return "", ErrInvalid
+}
+
+// Clean drops hyphens and spaces and uppercases a trailing x.
+func Clean(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "ISBN")
+	s = strings.TrimPrefix(s, ":")
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// To13 converts a valid ISBN-10 to ISBN-13.
+func To13(isbn10 string) string {
+	body := "978" + isbn10[:9]
+	return body + string(check13(body))
+}
+
+func valid10(s string) bool {
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var d int
+		switch {
+		case s[i] >= '0' && s[i] <= '9':
+			d = int(s[i] - '0')
+		case s[i] == 'X' && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += d * (10 - i)
+	}
+	return sum%11 == 0
+}
+
+func valid13(s string) bool {
+	if !strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979") {
+		return false
+	}
+	for i := 0; i < 13; i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return check13(s[:12]) == s[12]
+}
+
+func check13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(body[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,46 @@
+package isbn
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"978-0-306-40615-7", "9780306406157", true},
+		{"9780306406157", "9780306406157", true},
+		{"0-306-40615-2", "9780306406157", true},
+		{"ISBN: 0 306 40615 2", "9780306406157", true},
+		{"isbn 0-8044-2957-x", "9780804429573", true},
+		{"979-10-90636-07-1", "9791090636071", true},
+		{"978-0-306-40615-8", "", false}, // wrong check digit
+		{"0-306-40615-3", "", false},
+		{"0-306-4061X-2", "", false}, // X only as the ISBN-10 check digit
+		{"977-0-306-40615-7", "", false},
+		{"12345", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if tt.ok && (err != nil || got != tt.want) {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+		if !tt.ok && err != ErrInvalid {
+			t.Errorf("Normalize(%q) = %q, %v; want ErrInvalid", tt.in, got, err)
+		}
+	}
+}
+
+func TestTo13(t *testing.T) {
+	tests := map[string]string{
+		"0306406152": "9780306406157",
+		"080442957X": "9780804429573",
+		"0261102214": "9780261102217",
+	}
+	for in, want := range tests {
+		if got := To13(in); got != want {
+			t.Errorf("To13(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
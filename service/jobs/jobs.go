@@ -0,0 +1,65 @@
+// Package jobs runs periodic background work next to the HTTP server.
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+type Job struct {
+	Name  string
+	Every time.Duration
+	Run   func(ctx context.Context) error
+}
+
+type Runner struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+func (r *Runner) Add(name string, every time.Duration, run func(ctx context.Context) error) {
+	r.jobs = append(r.jobs, Job{Name: name, Every: every, Run: run})
+}
+
+// Start runs every job once straight away and then on its interval until
+// ctx is cancelled. Failures are logged and retried on the next tick.
+func (r *Runner) Start(ctx context.Context) {
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go func(j Job) {
+			defer r.wg.Done()
+			ticker := time.NewTicker(j.Every)
+			defer ticker.Stop()
+			for {
+				runOnce(ctx, j)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(j)
+	}
+}
+
+// Wait blocks until every job has stopped after ctx was cancelled.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+func runOnce(ctx context.Context, j Job) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("job %s panicked: %v", j.Name, p)
+		}
+	}()
+	if err := j.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("job %s: %v", j.Name, err)
+	}
+}
@@ -0,0 +1,236 @@
+package libhttp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	requestIDHeader     = "X-Request-ID"
+	requestIDContextKey = "request_id"
+	maxAuditExport      = 50000
+)
+
+// RequestID tags every request with an ID, reusing a sane X-Request-ID sent
+// by a proxy, and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			buf := make([]byte, 12)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		c.Set(requestIDContextKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// bodyRecorder keeps a copy of the response so the ID of a created entity
+// can be read back from it.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Audited returns a middleware factory recording successful calls in the
+// audit log. action is "<entity>.<verb>"; param names the path parameter
+// holding the entity's key, or is empty when the entity is created by the
+// call, in which case the key is taken from the "id" (or "issue_id") field
+// of the response. The change has been committed by the time it is
+// audited, so a call whose entry can't be written keeps its response; the
+// entry is logged on an AUDIT FAILURE line instead, for alerting and to
+// be re-entered by hand.
+func Audited(db *sqlx.DB) func(action, param string) gin.HandlerFunc {
+	return func(action, param string) gin.HandlerFunc {
+		entity, _, _ := strings.Cut(action, ".")
+		return func(c *gin.Context) {
+			r := buildRepo(db)
+
+			key := ""
+			if param != "" {
+				key = c.Param(param)
+			}
+			before, err := svc.AuditSnapshot(r, entity, key)
+			if err != nil {
+				log.Printf("audit %s: snapshot before: %v", action, err)
+			}
+
+			rec := &bodyRecorder{ResponseWriter: c.Writer}
+			c.Writer = rec
+			c.Next()
+
+			status := c.Writer.Status()
+			if status >= 400 {
+				return
+			}
+			var resp map[string]interface{}
+			_ = json.Unmarshal(rec.body.Bytes(), &resp)
+			if key == "" {
+				key = responseKey(resp)
+			}
+			after, err := svc.AuditSnapshot(r, entity, key)
+			if err != nil {
+				log.Printf("audit %s: snapshot after: %v", action, err)
+			}
+			if after == nil && key == "" && resp != nil {
+				// bulk actions have no single entity; keep their summary
+				after = rec.body.Bytes()
+			}
+
+			e := &models.AuditEntry{
+				Action:     action,
+				EntityType: entity,
+				EntityID:   key,
+				Before:     before,
+				After:      after,
+				Method:     c.Request.Method,
+				Path:       c.Request.URL.Path,
+				Status:     status,
+				RequestID:  c.GetString(requestIDContextKey),
+				IP:         c.ClientIP(),
+			}
+			if admin := currentAdmin(c); admin != nil {
+				e.ActorID = &admin.ID
+				e.Actor = admin.Username
+			}
+			if err := svc.RecordAudit(r, e); err != nil {
+				entry, _ := json.Marshal(e)
+				log.Printf("AUDIT FAILURE %s %s request %s: %v: %s", action, key, e.RequestID, err, entry)
+			}
+		}
+	}
+}
+
+func responseKey(resp map[string]interface{}) string {
+	for _, field := range []string{"id", "issue_id"} {
+		switch v := resp[field].(type) {
+		case float64:
+			return strconv.FormatInt(int64(v), 10)
+		case string:
+			return v
+		}
+	}
+	return ""
+}
+
+func auditFilter(c *gin.Context) (models.AuditFilter, error) {
+	f := models.AuditFilter{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		RequestID:  c.Query("request_id"),
+	}
+	f.ActorID, _ = strconv.ParseInt(c.Query("actor_id"), 10, 64)
+	f.BeforeID, _ = strconv.ParseInt(c.Query("before_id"), 10, 64)
+	f.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	var err error
+	if f.From, err = queryTime(c, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = queryTime(c, "to"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// ListAuditHandler pages newest first; pass the last id seen as before_id
+// for the next page.
+func ListAuditHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f, err := auditFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		entries, err := svc.ListAudit(r, f)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+func ExportAuditHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f, err := auditFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		f.Limit = 1000
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"id", "occurred_at", "actor_id", "actor", "action", "entity_type", "entity_id",
+			"method", "path", "status", "request_id", "ip", "diff"})
+
+		written := 0
+		for written < maxAuditExport {
+			entries, err := svc.ListAudit(r, f)
+			if err != nil {
+				log.Printf("audit export: %v", err)
+				break
+			}
+			for _, e := range entries {
+				actorID := ""
+				if e.ActorID != nil {
+					actorID = strconv.FormatInt(*e.ActorID, 10)
+				}
+				w.Write([]string{
+					strconv.FormatInt(e.ID, 10),
+					e.OccurredAt.UTC().Format(time.RFC3339),
+					actorID,
+					e.Actor,
+					e.Action,
+					e.EntityType,
+					e.EntityID,
+					e.Method,
+					e.Path,
+					strconv.Itoa(e.Status),
+					e.RequestID,
+					e.IP,
+					string(e.Diff),
+				})
+			}
+			written += len(entries)
+			if len(entries) < f.Limit {
+				break
+			}
+			f.BeforeID = entries[len(entries)-1].ID
+		}
+		w.Flush()
+	}
+}
@@ -0,0 +1,76 @@
+package libhttp
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+// unreachableDB fails every query, so no audit entry can be written.
+func unreachableDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Open("mysql", "audit:audit@tcp(127.0.0.1:1)/library?timeout=1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestAuditedKeepsResponseWhenEntryCantBeWritten(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		wantBody   string
+		wantAlert  bool
+	}{
+		{
+			name:       "created",
+			handler:    func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{"id": 7}) },
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":7}`,
+			wantAlert:  true,
+		},
+		{
+			name:       "no content",
+			handler:    func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+			wantAlert:  true,
+		},
+		{
+			name:       "rejected",
+			handler:    func(c *gin.Context) { jsonError(c, http.StatusBadRequest, "title is required") },
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"title is required"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logged.Reset()
+			router := gin.New()
+			router.POST("/books", Audited(unreachableDB(t))("book.create", ""), tt.handler)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/books", nil))
+			if w.Code != tt.wantStatus || w.Body.String() != tt.wantBody {
+				t.Errorf("got %d %q, want %d %q", w.Code, w.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+			if alert := strings.Contains(logged.String(), "AUDIT FAILURE book.create"); alert != tt.wantAlert {
+				t.Errorf("AUDIT FAILURE logged = %v, want %v:\n%s", alert, tt.wantAlert, logged.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package libhttp
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const adminContextKey = "admin"
+
+type adminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+func sessionTTL() time.Duration {
+	if v := os.Getenv("SESSION_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+	}
+	return svc.DefaultSessionTTL
+}
+
+func bearerToken(c *gin.Context) string {
+	h := c.GetHeader("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+func currentAdmin(c *gin.Context) *models.Admin {
+	if v, ok := c.Get(adminContextKey); ok {
+		if a, ok := v.(*models.Admin); ok {
+			return a
+		}
+	}
+	return nil
+}
+
+func AdminLoginHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var req adminLoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		token, expiresAt, err := svc.Login(r, req.Username, req.Password, sessionTTL())
+		if err != nil {
+			if errors.Is(err, svc.ErrInvalidCredentials) {
+				jsonError(c, http.StatusUnauthorized, err.Error())
+				return
+			}
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": expiresAt})
+	}
+}
+
+func AdminLogoutHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		if c.Query("all") == "true" {
+			if err := svc.LogoutEverywhere(r, currentAdmin(c).ID); err != nil {
+				jsonError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		if err := svc.Logout(r, bearerToken(c)); err != nil {
+			jsonError(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func AdminMeHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, currentAdmin(c))
+}
+
+// RequireAdmin rejects requests without a live session token in the
+// Authorization header and stores the admin on the context.
+func RequireAdmin(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		admin, err := svc.Authenticate(r, bearerToken(c))
+		if err != nil {
+			if errors.Is(err, svc.ErrInvalidSession) {
+				c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+				jsonError(c, http.StatusUnauthorized, err.Error())
+			} else {
+				jsonError(c, http.StatusInternalServerError, err.Error())
+			}
+			c.Abort()
+			return
+		}
+		c.Set(adminContextKey, admin)
+		c.Next()
+	}
+}
+
+// RequirePermission must run after RequireAdmin; it lets the request through
+// only if one of the admin's roles grants perm.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		admin := currentAdmin(c)
+		if admin == nil || !admin.Can(perm) {
+			jsonError(c, http.StatusForbidden, "missing permission "+perm)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func ListAuthorsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f := models.AuthorFilter{ListOptions: listOptions(c), Query: c.Query("q")}
+		page, err := svc.ListAuthors(r, f)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func GetAuthorHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		author, err := svc.GetAuthor(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if author == nil {
+			jsonError(c, http.StatusNotFound, "author not found")
+			return
+		}
+		c.JSON(http.StatusOK, author)
+	}
+}
+
+func RenameAuthorHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := svc.RenameAuthor(c.Request.Context(), r, id, req.Name); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func MergeAuthorsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req struct {
+			AuthorIDs []int64 `json:"author_ids" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		author, err := svc.MergeAuthors(c.Request.Context(), r, id, req.AuthorIDs)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, author)
+	}
+}
+
+func DuplicateAuthorsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		groups, err := svc.FindDuplicateAuthors(r)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, groups)
+	}
+}
@@ -0,0 +1,88 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func ListCopiesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		bookID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		copies, err := svc.ListCopies(r, bookID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, copies)
+	}
+}
+
+func GetCopyByBarcodeHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		item, err := svc.GetCopyByBarcode(r, c.Param("barcode"))
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if item == nil {
+			jsonError(c, http.StatusNotFound, "copy not found")
+			return
+		}
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+func AddCopyHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		bookID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var item models.BookCopy
+		if err := c.ShouldBindJSON(&item); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		id, err := svc.AddCopy(c.Request.Context(), r, bookID, &item)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": id, "barcode": item.Barcode})
+	}
+}
+
+func UpdateCopyHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var update models.CopyUpdate
+		if err := c.ShouldBindJSON(&update); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := svc.UpdateCopy(c.Request.Context(), r, id, &update); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,103 @@
+package libhttp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"library-management/service/covers"
+	svc "library-management/service/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// coverCacheAge is how long clients may reuse a cover without asking
+// again; after that the ETag makes the check cheap.
+const coverCacheAge = 24 * 60 * 60
+
+// UploadCoverHandler takes a JPEG or PNG as the raw body or as the "file"
+// part of a multipart form.
+func UploadCoverHandler(db *sqlx.DB, store covers.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		// room for the multipart envelope; the image itself is checked
+		// against covers.MaxBytes
+		body, err := uploadBody(c, covers.MaxBytes+64<<10)
+		var data []byte
+		if err == nil {
+			data, err = io.ReadAll(body)
+			body.Close()
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			jsonError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("image is larger than %d MB", covers.MaxBytes>>20))
+			return
+		}
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		cover, err := svc.SetCover(c.Request.Context(), r, store, id, data)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, cover)
+	}
+}
+
+func DeleteCoverHandler(db *sqlx.DB, store covers.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		if err := svc.DeleteCover(c.Request.Context(), r, store, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// GetCoverHandler serves one size of a cover (default medium). Each
+// upload has its own ETag, so If-None-Match and If-Modified-Since get a
+// 304 until the cover changes.
+func GetCoverHandler(db *sqlx.DB, store covers.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+		size := c.DefaultQuery("size", "medium")
+
+		cover, data, err := svc.GetCover(r, store, id, size)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if cover == nil {
+			jsonError(c, http.StatusNotFound, "book has no cover")
+			return
+		}
+
+		contentType := covers.TypeJPEG
+		if size == covers.Original {
+			contentType = cover.ContentType
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("ETag", `"`+cover.ETag+"-"+size+`"`)
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(coverCacheAge))
+		http.ServeContent(c.Writer, c.Request, "", cover.UpdatedAt, bytes.NewReader(data))
+	}
+}
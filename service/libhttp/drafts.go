@@ -0,0 +1,44 @@
+package libhttp
+
+import (
+	"errors"
+	"net/http"
+
+	svc "library-management/service/handler"
+	"library-management/service/metadata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// DraftBookHandler answers an ISBN scan with a book to review and post to
+// /admin/books. A provider that can't be reached is a 502.
+func DraftBookHandler(db *sqlx.DB, provider metadata.MetadataProvider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		code := c.Query("isbn")
+		if code == "" {
+			jsonError(c, http.StatusBadRequest, "isbn is required")
+			return
+		}
+		draft, err := svc.DraftBook(c.Request.Context(), r, provider, code)
+		if errors.Is(err, svc.ErrInvalidISBN) {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, svc.ErrLookupFailed) {
+			jsonError(c, http.StatusBadGateway, err.Error())
+			return
+		}
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if draft == nil {
+			jsonError(c, http.StatusNotFound, "nothing known about isbn "+code)
+			return
+		}
+		c.JSON(http.StatusOK, draft)
+	}
+}
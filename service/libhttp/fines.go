@@ -0,0 +1,216 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+type chargeRequest struct {
+	MemberID int64   `json:"member_id" binding:"required"`
+	IssueID  *int64  `json:"issue_id"`
+	Kind     string  `json:"kind" binding:"required"`
+	Amount   float64 `json:"amount" binding:"required,gt=0"`
+	Note     string  `json:"note"`
+}
+
+type paymentRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Method string  `json:"method" binding:"required"`
+}
+
+type waiveRequest struct {
+	Amount float64 `json:"amount" binding:"min=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+type lostRequest struct {
+	Replacement float64 `json:"replacement_cost" binding:"min=0"`
+}
+
+func ChargeFineHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var req chargeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fine, err := svc.ChargeFine(c.Request.Context(), r, svc.FineCharge{
+			MemberID: req.MemberID,
+			IssueID:  req.IssueID,
+			Kind:     req.Kind,
+			Amount:   req.Amount,
+			Note:     req.Note,
+			StaffID:  currentAdmin(c).ID,
+		})
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, fine)
+	}
+}
+
+func MarkLostHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		issueID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req lostRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fines, err := svc.MarkLost(c.Request.Context(), r, issueID, req.Replacement, currentAdmin(c).ID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"issue_id": issueID, "fines": fines})
+	}
+}
+
+func GetFineHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		fine, err := svc.GetFine(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if fine == nil {
+			jsonError(c, http.StatusNotFound, "fine not found")
+			return
+		}
+		c.JSON(http.StatusOK, fine)
+	}
+}
+
+func MemberFinesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		fines, err := svc.ListMemberFines(r, memberID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, fines)
+	}
+}
+
+func MemberBalanceHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		balance, err := svc.MemberBalance(r, memberID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, balance)
+	}
+}
+
+func PayFineHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		fineID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req paymentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		receipt, err := svc.PayFine(c.Request.Context(), r, fineID, req.Amount, req.Method, currentAdmin(c).ID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, receipt)
+	}
+}
+
+func PayBalanceHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req paymentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		receipt, err := svc.PayBalance(c.Request.Context(), r, memberID, req.Amount, req.Method, currentAdmin(c).ID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, receipt)
+	}
+}
+
+func WaiveFineHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		fineID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req waiveRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		fine, err := svc.WaiveFine(c.Request.Context(), r, fineID, req.Amount, req.Reason, currentAdmin(c).ID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, fine)
+	}
+}
+
+func ReceiptHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		receipt, err := svc.Receipt(r, c.Param("receipt_no"))
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if receipt == nil {
+			jsonError(c, http.StatusNotFound, "receipt not found")
+			return
+		}
+		c.JSON(http.StatusOK, receipt)
+	}
+}
@@ -1,13 +1,14 @@
 package libhttp
 
 import (
+	"errors"
 	"net/http"
-	"os"
 	"strconv"
 
 	svc "library-management/service/handler"
 	"library-management/service/models"
 	"library-management/service/repository"
+	"library-management/service/search"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -21,58 +22,57 @@ func jsonError(c *gin.Context, status int, msg string) {
 	c.JSON(status, gin.H{"error": msg})
 }
 
-type adminLoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
-func AdminLoginHandler(c *gin.Context) {
-	var req adminLoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		jsonError(c, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	adminUser := getenvOrDefault("ADMIN_USER", "admin")
-	adminPass := getenvOrDefault("ADMIN_PASS", "123")
-
-	if req.Username == adminUser && req.Password == adminPass {
-		c.JSON(http.StatusOK, gin.H{"message": "login successful"})
-		return
-	}
-
-	jsonError(c, http.StatusUnauthorized, "invalid credentials")
-}
-
-func getenvOrDefault(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
-	}
-	return def
-}
-
 func ListBooksHandler(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		r := buildRepo(db)
-		books, err := svc.ListBooks(r)
+
+		f, err := bookFilter(c)
 		if err != nil {
-			jsonError(c, http.StatusInternalServerError, err.Error())
+			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
-		c.JSON(http.StatusOK, books)
+		page, err := svc.ListBooks(r, f)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
 	}
 }
 
-func SearchBooksHandler(db *sqlx.DB) gin.HandlerFunc {
+func SearchBooksHandler(db *sqlx.DB, suggester *search.Suggester) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		r := buildRepo(db)
 		q := c.Query("q")
-		books, err := svc.SearchBooks(r, q)
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		filter, err := facetFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		idx := search.NewFuzzyIndex(search.NewMySQLIndex(r), suggester)
+		res, err := svc.SearchBooks(idx, q, filter, limit)
 		if err != nil {
 			jsonError(c, http.StatusInternalServerError, err.Error())
 			return
 		}
-		c.JSON(http.StatusOK, books)
+		c.JSON(http.StatusOK, gin.H{
+			"query":        q,
+			"hits":         res.Hits,
+			"facets":       res.Facets,
+			"did_you_mean": res.DidYouMean,
+			"corrected":    res.Corrected,
+		})
+	}
+}
+
+func SuggestBooksHandler(suggester *search.Suggester) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := c.Query("q")
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		c.JSON(http.StatusOK, gin.H{"query": q, "suggestions": svc.SuggestBooks(suggester, q, limit)})
 	}
 }
 
@@ -103,9 +103,9 @@ func CreateBookHandler(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		id, err := svc.CreateBook(r, &b)
+		id, err := svc.CreateBook(c.Request.Context(), r, &b)
 		if err != nil {
-			jsonError(c, http.StatusInternalServerError, err.Error())
+			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
 		c.JSON(http.StatusCreated, gin.H{"id": id})
@@ -125,7 +125,7 @@ func UpdateBookHandler(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := svc.UpdateBook(r, id, &b); err != nil {
+		if err := svc.UpdateBook(c.Request.Context(), r, id, &b); err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -140,7 +140,7 @@ func DeleteBookHandler(db *sqlx.DB) gin.HandlerFunc {
 		idStr := c.Param("id")
 		id, _ := strconv.ParseInt(idStr, 10, 64)
 
-		if err := svc.DeleteBook(r, id); err != nil {
+		if err := svc.DeleteBook(c.Request.Context(), r, id); err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -148,15 +148,46 @@ func DeleteBookHandler(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
-func ListMembersHandler(db *sqlx.DB) gin.HandlerFunc {
+func ListDeletedBooksHandler(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		r := buildRepo(db)
-		members, err := svc.ListMembers(r)
+		books, err := svc.ListDeletedBooks(r)
 		if err != nil {
 			jsonError(c, http.StatusInternalServerError, err.Error())
 			return
 		}
-		c.JSON(http.StatusOK, members)
+		c.JSON(http.StatusOK, books)
+	}
+}
+
+func RestoreBookHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+		if err := svc.RestoreBook(c.Request.Context(), r, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func ListMembersHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f, err := memberFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		page, err := svc.ListMembers(r, f)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
 	}
 }
 
@@ -190,7 +221,11 @@ func CreateMemberHandler(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		id, err := svc.CreateMember(r, &m)
+		id, err := svc.CreateMember(c.Request.Context(), r, &m)
+		if errors.Is(err, svc.ErrInvalidMember) {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		if err != nil {
 			jsonError(c, http.StatusInternalServerError, err.Error())
 			return
@@ -212,7 +247,7 @@ func UpdateMemberHandler(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := svc.UpdateMember(r, id, &m); err != nil {
+		if err := svc.UpdateMember(c.Request.Context(), r, id, &m); err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -227,7 +262,32 @@ func DeleteMemberHandler(db *sqlx.DB) gin.HandlerFunc {
 		idStr := c.Param("id")
 		id, _ := strconv.ParseInt(idStr, 10, 64)
 
-		if err := svc.DeleteMember(r, id); err != nil {
+		if err := svc.DeleteMember(c.Request.Context(), r, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func ListDeletedMembersHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		members, err := svc.ListDeletedMembers(r)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, members)
+	}
+}
+
+func RestoreMemberHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		id, _ := strconv.ParseInt(c.Param("id"), 10, 64)
+
+		if err := svc.RestoreMember(c.Request.Context(), r, id); err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
@@ -236,9 +296,26 @@ func DeleteMemberHandler(db *sqlx.DB) gin.HandlerFunc {
 }
 
 type issueRequest struct {
-	BookID   int64 `json:"book_id" binding:"required"`
-	MemberID int64 `json:"member_id" binding:"required"`
-	DueDays  int   `json:"due_days"`
+	BookID   int64  `json:"book_id"`
+	Barcode  string `json:"barcode"`
+	MemberID int64  `json:"member_id" binding:"required"`
+	DueDays  int    `json:"due_days"`
+	Override *struct {
+		Justification string `json:"justification"`
+	} `json:"override"`
+}
+
+func returnResponse(issueID int64, fine *models.Fine) gin.H {
+	resp := gin.H{"issue_id": issueID, "fine_assessed": 0.0}
+	if fine != nil {
+		resp["fine_assessed"] = fine.Amount
+		resp["fine_id"] = fine.ID
+	}
+	return resp
+}
+
+type returnRequest struct {
+	Barcode string `json:"barcode" binding:"required"`
 }
 
 func IssueBookHandler(db *sqlx.DB) gin.HandlerFunc {
@@ -251,7 +328,32 @@ func IssueBookHandler(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		id, err := svc.IssueBook(r, req.BookID, req.MemberID, req.DueDays)
+		if req.BookID == 0 && req.Barcode == "" {
+			jsonError(c, http.StatusBadRequest, "book_id or barcode is required")
+			return
+		}
+
+		issue := svc.IssueRequest{
+			BookID:   req.BookID,
+			Barcode:  req.Barcode,
+			MemberID: req.MemberID,
+			DueDays:  req.DueDays,
+		}
+		if req.Override != nil {
+			admin := currentAdmin(c)
+			if !admin.Can(models.PermCirculationOverride) {
+				jsonError(c, http.StatusForbidden, "missing permission "+models.PermCirculationOverride)
+				return
+			}
+			issue.Override = &svc.IssueOverride{StaffID: admin.ID, Justification: req.Override.Justification}
+		}
+
+		id, err := svc.IssueBook(c.Request.Context(), r, issue)
+		var blocked *svc.BlockError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": blocked.Error(), "reasons": blocked.Reasons})
+			return
+		}
 		if err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
@@ -267,12 +369,47 @@ func ReturnBookHandler(db *sqlx.DB) gin.HandlerFunc {
 		idStr := c.Param("id")
 		issueID, _ := strconv.ParseInt(idStr, 10, 64)
 
-		fine, err := svc.ReturnBook(r, issueID)
+		fine, err := svc.ReturnBook(c.Request.Context(), r, issueID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, returnResponse(issueID, fine))
+	}
+}
+
+func RenewIssueHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		issueID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		issue, err := svc.RenewIssue(c.Request.Context(), r, issueID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, issue)
+	}
+}
+
+func ReturnByBarcodeHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var req returnRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		issueID, fine, err := svc.ReturnByBarcode(c.Request.Context(), r, req.Barcode)
 		if err != nil {
 			jsonError(c, http.StatusBadRequest, err.Error())
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"fine": fine})
+		c.JSON(http.StatusOK, returnResponse(issueID, fine))
 	}
 }
 
@@ -280,14 +417,59 @@ func IssuesByMemberHandler(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		r := buildRepo(db)
 
-		idStr := c.Param("member_id")
+		f := issueFilter(c)
+		f.MemberID, _ = strconv.ParseInt(c.Param("member_id"), 10, 64)
+
+		page, err := svc.ListIssues(r, f)
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func ListIssuesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		page, err := svc.ListIssues(r, issueFilter(c))
+		if err != nil {
+			listError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func MemberEligibilityHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		reasons, err := svc.CirculationBlocks(r, memberID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"member_id": memberID, "eligible": len(reasons) == 0, "reasons": reasons})
+	}
+}
+
+func MemberOverridesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
 		memberID, _ := strconv.ParseInt(idStr, 10, 64)
 
-		issues, err := svc.GetIssuesByMember(r, memberID)
+		overrides, err := svc.ListOverrides(r, memberID)
 		if err != nil {
 			jsonError(c, http.StatusInternalServerError, err.Error())
 			return
 		}
-		c.JSON(http.StatusOK, issues)
+		c.JSON(http.StatusOK, overrides)
 	}
 }
@@ -0,0 +1,120 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+type holdRequest struct {
+	BookID   int64 `json:"book_id" binding:"required"`
+	MemberID int64 `json:"member_id" binding:"required"`
+}
+
+func PlaceHoldHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var req holdRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		id, err := svc.PlaceHold(c.Request.Context(), r, req.BookID, req.MemberID)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		hold, err := svc.GetHold(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, hold)
+	}
+}
+
+func GetHoldHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		hold, err := svc.GetHold(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if hold == nil {
+			jsonError(c, http.StatusNotFound, "hold not found")
+			return
+		}
+		c.JSON(http.StatusOK, hold)
+	}
+}
+
+func CancelHoldHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		if err := svc.CancelHold(c.Request.Context(), r, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func BookHoldQueueHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		bookID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		holds, err := svc.ListHoldQueue(r, bookID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, holds)
+	}
+}
+
+func MemberHoldsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		holds, err := svc.ListMemberHolds(r, memberID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, holds)
+	}
+}
+
+func ExpireHoldsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		n, err := svc.ExpireHolds(c.Request.Context(), r)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"expired": n})
+	}
+}
@@ -0,0 +1,176 @@
+package libhttp
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"library-management/service/bulk"
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+var bulkContentTypes = map[string]string{
+	bulk.FormatCSV:   "text/csv; charset=utf-8",
+	bulk.FormatJSONL: "application/x-ndjson",
+}
+
+// bulkFormat is the format query parameter, else JSON Lines for a body
+// sent as such, else CSV.
+func bulkFormat(c *gin.Context) string {
+	if f := c.Query("format"); f != "" {
+		return f
+	}
+	if ct := c.ContentType(); strings.Contains(ct, "ndjson") || strings.Contains(ct, "jsonl") {
+		return bulk.FormatJSONL
+	}
+	return bulk.FormatCSV
+}
+
+// importMapping reads map=Column:field parameters, which put a column
+// under a field its header doesn't name; map=Column: ignores it.
+func importMapping(c *gin.Context) (map[string]string, error) {
+	mapping := map[string]string{}
+	for _, m := range c.QueryArray("map") {
+		i := strings.LastIndex(m, ":")
+		if i <= 0 {
+			return nil, errors.New("map must be given as column:field")
+		}
+		mapping[m[:i]] = strings.TrimSpace(m[i+1:])
+	}
+	return mapping, nil
+}
+
+// StartImportHandler saves the upload to a temporary file and answers 202
+// with the job, to be polled at /admin/imports/:id.
+func StartImportHandler(db *sqlx.DB, kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		job := &models.ImportJob{Kind: kind, Format: bulkFormat(c)}
+		job.DryRun, _ = strconv.ParseBool(c.Query("dry_run"))
+		if a := currentAdmin(c); a != nil {
+			job.CreatedBy = &a.ID
+		}
+		mapping, err := importMapping(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		body, err := importBody(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer body.Close()
+		tmp, err := os.CreateTemp("", "library-import-*")
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		_, err = io.Copy(tmp, body)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		started, err := svc.StartImport(r, job, tmp.Name(), mapping)
+		if err != nil {
+			os.Remove(tmp.Name())
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusAccepted, started)
+	}
+}
+
+func GetImportJobHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		job, err := svc.GetImportJob(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if job == nil {
+			jsonError(c, http.StatusNotFound, "import not found")
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	}
+}
+
+// startExport sets the download headers and returns the writer for the
+// response, or answers 400 for an unknown format.
+func startExport(c *gin.Context, name string, columns []string) *bulk.Writer {
+	format := c.DefaultQuery("format", bulk.FormatCSV)
+	contentType, ok := bulkContentTypes[format]
+	if !ok {
+		jsonError(c, http.StatusBadRequest, bulk.ErrFormat.Error())
+		return nil
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+name+`.`+format+`"`)
+	w, err := bulk.NewWriter(c.Writer, format, columns)
+	if err != nil {
+		log.Printf("%s export: %v", name, err)
+		return nil
+	}
+	return w
+}
+
+// ExportBooksHandler streams every book the list filters match; an error
+// after the first page can only be logged.
+func ExportBooksHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f, err := bookFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		w := startExport(c, "books", svc.BookExportColumns)
+		if w == nil {
+			return
+		}
+		if err := svc.ExportBooks(r, f, w); err != nil {
+			log.Printf("book export: %v", err)
+		}
+	}
+}
+
+func ExportMembersHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		f, err := memberFilter(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		w := startExport(c, "members", svc.MemberExportColumns)
+		if w == nil {
+			return
+		}
+		if err := svc.ExportMembers(r, f, w); err != nil {
+			log.Printf("member export: %v", err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package libhttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+func listOptions(c *gin.Context) models.ListOptions {
+	opts := models.ListOptions{Cursor: c.Query("cursor"), Sort: c.Query("sort")}
+	opts.Limit, _ = strconv.Atoi(c.Query("limit"))
+	return opts
+}
+
+// queryTime reads an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		d, derr := time.Parse("2006-01-02", v)
+		if derr != nil {
+			return nil, fmt.Errorf("%s must be RFC 3339 or YYYY-MM-DD", name)
+		}
+		t = d
+	}
+	return &t, nil
+}
+
+// listError answers 400 for a bad sort or cursor and 500 otherwise.
+func listError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrInvalidPage) {
+		jsonError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonError(c, http.StatusInternalServerError, err.Error())
+}
+
+func bookFilter(c *gin.Context) (models.BookFilter, error) {
+	f := models.BookFilter{ListOptions: listOptions(c), Author: c.Query("author")}
+	if v := c.Query("available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("available must be true or false")
+		}
+		f.Available = &available
+	}
+	var err error
+	if f.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+		return f, err
+	}
+	if f.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func memberFilter(c *gin.Context) (models.MemberFilter, error) {
+	f := models.MemberFilter{ListOptions: listOptions(c), Category: c.Query("category")}
+	var err error
+	if f.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+		return f, err
+	}
+	if f.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+func issueFilter(c *gin.Context) models.IssueFilter {
+	f := models.IssueFilter{ListOptions: listOptions(c), Status: c.Query("status")}
+	f.MemberID, _ = strconv.ParseInt(c.Query("member_id"), 10, 64)
+	f.BookID, _ = strconv.ParseInt(c.Query("book_id"), 10, 64)
+	return f
+}
+
+// facetFilter reads the facet values a search is narrowed to. author,
+// subject, language and format may be repeated to accept any of them; year
+// takes a facet bucket such as 1990-1999, or use year_from and year_to.
+func facetFilter(c *gin.Context) (models.FacetFilter, error) {
+	f := models.FacetFilter{
+		Authors:   c.QueryArray("author"),
+		Subjects:  c.QueryArray("subject"),
+		Languages: c.QueryArray("language"),
+		Formats:   c.QueryArray("format"),
+	}
+	for _, format := range f.Formats {
+		if !models.IsBookFormat(format) {
+			return f, fmt.Errorf("unknown format %q", format)
+		}
+	}
+
+	if v := c.Query("year"); v != "" {
+		from, to, ok := strings.Cut(v, "-")
+		if !ok {
+			to = from
+		}
+		var err1, err2 error
+		f.YearFrom, err1 = strconv.Atoi(from)
+		f.YearTo, err2 = strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			return f, errors.New("year must be YYYY or YYYY-YYYY")
+		}
+	}
+	for name, dst := range map[string]*int{"year_from": &f.YearFrom, "year_to": &f.YearTo} {
+		if v := c.Query(name); v != "" {
+			year, err := strconv.Atoi(v)
+			if err != nil {
+				return f, fmt.Errorf("%s must be a year", name)
+			}
+			*dst = year
+		}
+	}
+
+	if v := c.Query("available"); v != "" {
+		available, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("available must be true or false")
+		}
+		f.Available = &available
+	}
+	return f, nil
+}
@@ -0,0 +1,123 @@
+package libhttp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	svc "library-management/service/handler"
+	"library-management/service/marc"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxImportBytes caps an uploaded import file.
+const maxImportBytes = 64 << 20
+
+var marcContentTypes = map[string]string{
+	marc.FormatBinary: "application/marc",
+	marc.FormatXML:    "application/marcxml+xml",
+}
+
+// importBody returns the uploaded file: the "file" part of a multipart
+// form, or else the raw request body.
+func importBody(c *gin.Context) (io.ReadCloser, error) {
+	return uploadBody(c, maxImportBytes)
+}
+
+// uploadBody is importBody for uploads of at most limit bytes.
+func uploadBody(c *gin.Context, limit int64) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		return c.Request.Body, nil
+	}
+	fh, err := c.FormFile("file")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("file is required")
+	}
+	return fh.Open()
+}
+
+// queryIDs reads ids given as ids=1,2,3, repeated, or both.
+func queryIDs(c *gin.Context, name string) ([]int64, error) {
+	var ids []int64
+	for _, v := range c.QueryArray(name) {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, errors.New(name + " must be a list of ids")
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func ImportMARCHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+		body, err := importBody(c)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		defer body.Close()
+
+		rr, err := marc.NewReader(body, c.Query("format"))
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		report, err := svc.ImportMARC(c.Request.Context(), r, rr, dryRun)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func ExportMARCHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		format := c.DefaultQuery("format", marc.FormatXML)
+		ids, err := queryIDs(c, "ids")
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// buffered, so a missing book is still a JSON error
+		var buf bytes.Buffer
+		w, err := marc.NewWriter(&buf, format)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := svc.ExportMARC(r, ids, w); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		ext := "mrc"
+		if format == marc.FormatXML {
+			ext = "xml"
+		}
+		c.Header("Content-Disposition", `attachment; filename="books.`+ext+`"`)
+		c.Data(http.StatusOK, marcContentTypes[format], buf.Bytes())
+	}
+}
@@ -0,0 +1,34 @@
+package libhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateMemberHandlerStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"not json", `{"name":`, http.StatusBadRequest},
+		{"bad expiry", `{"name":"Ada Lovelace","expires_at":"31/12/2026"}`, http.StatusBadRequest},
+		{"database down", `{"name":"Ada Lovelace","category":"staff"}`, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/members", CreateMemberHandler(unreachableDB(t)))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/members", strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Errorf("got %d %s, want %d", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func MemberNoticesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		memberID, _ := strconv.ParseInt(idStr, 10, 64)
+
+		notices, err := svc.ListNotices(r, memberID)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, notices)
+	}
+}
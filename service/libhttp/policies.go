@@ -0,0 +1,68 @@
+package libhttp
+
+import (
+	"net/http"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func ListPoliciesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		policies, err := svc.ListPolicies(r)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, policies)
+	}
+}
+
+func GetPolicyHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		policy, err := svc.GetPolicy(r, c.Param("category"))
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if policy == nil {
+			jsonError(c, http.StatusNotFound, "loan policy not found")
+			return
+		}
+		c.JSON(http.StatusOK, policy)
+	}
+}
+
+func SavePolicyHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var p models.LoanPolicy
+		if err := c.ShouldBindJSON(&p); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := svc.SavePolicy(r, c.Param("category"), &p); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func DeletePolicyHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		if err := svc.DeletePolicy(r, c.Param("category")); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -1,32 +1,128 @@
 package libhttp
 
 import (
+	"library-management/service/covers"
+	"library-management/service/metadata"
+	"library-management/service/models"
+	"library-management/service/search"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
-func RegisterRoutes(r *gin.Engine, db *sqlx.DB) {
+func RegisterRoutes(r *gin.Engine, db *sqlx.DB, suggester *search.Suggester, provider metadata.MetadataProvider,
+	store covers.Storage) {
+	r.Use(RequestID())
 
-	r.POST("/admin/login", AdminLoginHandler)
+	r.POST("/admin/login", AdminLoginHandler(db))
 	r.GET("/books", ListBooksHandler(db))
-	r.GET("/books/search", SearchBooksHandler(db))
+	r.GET("/books/search", SearchBooksHandler(db, suggester))
+	r.GET("/books/suggest", SuggestBooksHandler(suggester))
 	r.GET("/books/:id", GetBookHandler(db))
+	r.GET("/books/:id/cover", GetCoverHandler(db, store))
+	r.GET("/authors", ListAuthorsHandler(db))
+	r.GET("/authors/:id", GetAuthorHandler(db))
 	r.GET("/members/:id", GetMemberHandler(db))
 
-	admin := r.Group("/admin")
+	can := RequirePermission
+	audit := Audited(db)
+
+	admin := r.Group("/admin", RequireAdmin(db))
 	{
-		admin.POST("/books", CreateBookHandler(db))
-		admin.PUT("/books/:id", UpdateBookHandler(db))
-		admin.DELETE("/books/:id", DeleteBookHandler(db))
+		admin.POST("/logout", audit("session.logout", ""), AdminLogoutHandler(db))
+		admin.GET("/me", AdminMeHandler)
+
+		admin.POST("/books", can(models.PermCatalogWrite), audit("book.create", ""), CreateBookHandler(db))
+		admin.PUT("/books/:id", can(models.PermCatalogWrite), audit("book.update", "id"), UpdateBookHandler(db))
+		admin.DELETE("/books/:id", can(models.PermCatalogDelete), audit("book.delete", "id"), DeleteBookHandler(db))
+		admin.POST("/books/:id/restore", can(models.PermCatalogDelete), audit("book.restore", "id"), RestoreBookHandler(db))
+		admin.POST("/books/:id/cover", can(models.PermCatalogWrite), audit("book.cover", "id"), UploadCoverHandler(db, store))
+		admin.DELETE("/books/:id/cover", can(models.PermCatalogWrite), audit("book.cover_delete", "id"), DeleteCoverHandler(db, store))
+		admin.GET("/books/draft", can(models.PermCatalogWrite), DraftBookHandler(db, provider))
+		admin.GET("/books/deleted", can(models.PermCatalogDelete), ListDeletedBooksHandler(db))
 		admin.GET("/books", ListBooksHandler(db))
+		admin.GET("/books/:id/copies", ListCopiesHandler(db))
+		admin.POST("/books/:id/copies", can(models.PermCatalogWrite), audit("copy.create", ""), AddCopyHandler(db))
+		admin.PUT("/copies/:id", can(models.PermCatalogWrite), audit("copy.update", "id"), UpdateCopyHandler(db))
+		admin.GET("/copies/barcode/:barcode", GetCopyByBarcodeHandler(db))
+
+		admin.POST("/marc/import", can(models.PermCatalogWrite), audit("catalog.import", ""), ImportMARCHandler(db))
+		admin.GET("/marc/export", ExportMARCHandler(db))
+		admin.POST("/imports/books", can(models.PermCatalogWrite), audit("import.books", ""), StartImportHandler(db, models.ImportBooks))
+		admin.POST("/imports/members", can(models.PermMembersWrite), audit("import.members", ""), StartImportHandler(db, models.ImportMembers))
+		admin.GET("/imports/:id", GetImportJobHandler(db))
+		admin.GET("/export/books", ExportBooksHandler(db))
+		admin.GET("/export/members", can(models.PermMembersRead), ExportMembersHandler(db))
+
+		admin.GET("/authors/duplicates", can(models.PermCatalogWrite), DuplicateAuthorsHandler(db))
+		admin.PUT("/authors/:id", can(models.PermCatalogWrite), audit("author.update", "id"), RenameAuthorHandler(db))
+		admin.POST("/authors/:id/merge", can(models.PermCatalogWrite), audit("author.merge", "id"), MergeAuthorsHandler(db))
+
+		admin.POST("/members", can(models.PermMembersWrite), audit("member.create", ""), CreateMemberHandler(db))
+		admin.PUT("/members/:id", can(models.PermMembersWrite), audit("member.update", "id"), UpdateMemberHandler(db))
+		admin.DELETE("/members/:id", can(models.PermMembersDelete), audit("member.delete", "id"), DeleteMemberHandler(db))
+		admin.POST("/members/:id/restore", can(models.PermMembersDelete), audit("member.restore", "id"), RestoreMemberHandler(db))
+		admin.GET("/members/deleted", can(models.PermMembersDelete), ListDeletedMembersHandler(db))
+		admin.GET("/members", can(models.PermMembersRead), ListMembersHandler(db))
+		admin.GET("/members/:id/eligibility", can(models.PermMembersRead), MemberEligibilityHandler(db))
+		admin.GET("/members/:id/overrides", can(models.PermMembersRead), MemberOverridesHandler(db))
+		admin.GET("/members/:id/notices", can(models.PermMembersRead), MemberNoticesHandler(db))
+
+		admin.POST("/issues", can(models.PermCirculationIssue), audit("issue.create", ""), IssueBookHandler(db))
+		admin.POST("/issues/:id/return", can(models.PermCirculationReturn), audit("issue.return", "id"), ReturnBookHandler(db))
+		admin.POST("/issues/:id/renew", can(models.PermCirculationIssue), audit("issue.renew", "id"), RenewIssueHandler(db))
+		admin.POST("/issues/:id/lost", can(models.PermCirculationReturn), audit("issue.lost", "id"), MarkLostHandler(db))
+		admin.POST("/returns", can(models.PermCirculationReturn), audit("issue.return", ""), ReturnByBarcodeHandler(db))
+		admin.GET("/issues", can(models.PermMembersRead), ListIssuesHandler(db))
+		admin.GET("/issues/member/:member_id", can(models.PermMembersRead), IssuesByMemberHandler(db))
+
+		admin.POST("/holds", can(models.PermCirculationIssue), audit("hold.create", ""), PlaceHoldHandler(db))
+		admin.GET("/holds/:id", can(models.PermMembersRead), GetHoldHandler(db))
+		admin.DELETE("/holds/:id", can(models.PermCirculationIssue), audit("hold.cancel", "id"), CancelHoldHandler(db))
+		admin.POST("/holds/expire", can(models.PermCirculationIssue), audit("hold.expire", ""), ExpireHoldsHandler(db))
+		admin.GET("/books/:id/holds", can(models.PermMembersRead), BookHoldQueueHandler(db))
+		admin.GET("/members/:id/holds", can(models.PermMembersRead), MemberHoldsHandler(db))
+
+		admin.POST("/fines", can(models.PermCirculationReturn), audit("fine.create", ""), ChargeFineHandler(db))
+		admin.GET("/fines/:id", can(models.PermMembersRead), GetFineHandler(db))
+		admin.POST("/fines/:id/payments", can(models.PermFinesCollect), audit("fine.pay", "id"), PayFineHandler(db))
+		admin.POST("/fines/:id/waive", can(models.PermFinesWaive), audit("fine.waive", "id"), WaiveFineHandler(db))
+		admin.GET("/members/:id/fines", can(models.PermMembersRead), MemberFinesHandler(db))
+		admin.GET("/members/:id/balance", can(models.PermMembersRead), MemberBalanceHandler(db))
+		admin.POST("/members/:id/payments", can(models.PermFinesCollect), audit("balance.pay", "id"), PayBalanceHandler(db))
+		admin.GET("/receipts/:receipt_no", can(models.PermMembersRead), ReceiptHandler(db))
+
+		admin.GET("/policies", ListPoliciesHandler(db))
+		admin.GET("/policies/:category", GetPolicyHandler(db))
+		admin.PUT("/policies/:category", can(models.PermPoliciesManage), audit("policy.save", "category"), SavePolicyHandler(db))
+		admin.DELETE("/policies/:category", can(models.PermPoliciesManage), audit("policy.delete", "category"), DeletePolicyHandler(db))
+
+		admin.GET("/audit", can(models.PermAuditRead), ListAuditHandler(db))
+		admin.GET("/audit/export", can(models.PermAuditRead), ExportAuditHandler(db))
+
+		admin.GET("/events", can(models.PermEventsRead), ListDomainEventsHandler(db))
+
+		admin.GET("/webhooks", can(models.PermWebhooksManage), ListWebhooksHandler(db))
+		admin.POST("/webhooks", can(models.PermWebhooksManage), audit("webhook.create", ""), CreateWebhookHandler(db))
+		admin.GET("/webhooks/events", can(models.PermWebhooksManage), ListEventsHandler)
+		admin.GET("/webhooks/:id", can(models.PermWebhooksManage), GetWebhookHandler(db))
+		admin.PUT("/webhooks/:id", can(models.PermWebhooksManage), audit("webhook.update", "id"), UpdateWebhookHandler(db))
+		admin.DELETE("/webhooks/:id", can(models.PermWebhooksManage), audit("webhook.delete", "id"), DeleteWebhookHandler(db))
+		admin.GET("/webhooks/:id/deliveries", can(models.PermWebhooksManage), WebhookDeliveriesHandler(db))
+		admin.GET("/webhook-deliveries/:id", can(models.PermWebhooksManage), GetWebhookDeliveryHandler(db))
+		admin.POST("/webhook-deliveries/:id/retry", can(models.PermWebhooksManage), audit("webhook_delivery.retry", "id"), RetryWebhookDeliveryHandler(db))
 
-		admin.POST("/members", CreateMemberHandler(db))
-		admin.PUT("/members/:id", UpdateMemberHandler(db))
-		admin.DELETE("/members/:id", DeleteMemberHandler(db))
-		admin.GET("/members", ListMembersHandler(db))
+		admin.GET("/staff", can(models.PermStaffManage), ListStaffHandler(db))
+		admin.POST("/staff", can(models.PermStaffManage), audit("staff.create", ""), CreateStaffHandler(db))
+		admin.GET("/staff/:id", can(models.PermStaffManage), GetStaffHandler(db))
+		admin.PUT("/staff/:id", can(models.PermStaffManage), audit("staff.update", "id"), UpdateStaffHandler(db))
+		admin.PUT("/staff/:id/roles", can(models.PermStaffManage), audit("staff.set_roles", "id"), SetStaffRolesHandler(db))
+		admin.DELETE("/staff/:id", can(models.PermStaffManage), audit("staff.deactivate", "id"), DeactivateStaffHandler(db))
 
-		admin.POST("/issues", IssueBookHandler(db))
-		admin.POST("/issues/:id/return", ReturnBookHandler(db))
-		admin.GET("/issues/member/:member_id", IssuesByMemberHandler(db))
+		admin.GET("/roles", can(models.PermStaffManage), ListRolesHandler(db))
+		admin.POST("/roles", can(models.PermStaffManage), audit("role.create", ""), CreateRoleHandler(db))
+		admin.PUT("/roles/:id", can(models.PermStaffManage), audit("role.update", "id"), UpdateRoleHandler(db))
+		admin.DELETE("/roles/:id", can(models.PermStaffManage), audit("role.delete", "id"), DeleteRoleHandler(db))
+		admin.GET("/permissions", can(models.PermStaffManage), ListPermissionsHandler)
 	}
 }
@@ -0,0 +1,199 @@
+package libhttp
+
+import (
+	"net/http"
+	"strconv"
+
+	svc "library-management/service/handler"
+	"library-management/service/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+type webhookRequest struct {
+	URL         string `json:"url" binding:"required"`
+	Secret      string `json:"secret"`
+	Events      string `json:"events"`
+	Active      *bool  `json:"active"`
+	Description string `json:"description"`
+}
+
+// webhook builds the webhook to create; a new one is active unless the
+// request says otherwise.
+func (req *webhookRequest) webhook() *models.Webhook {
+	w := &models.Webhook{
+		URL:         req.URL,
+		Secret:      req.Secret,
+		Events:      req.Events,
+		Active:      true,
+		Description: req.Description,
+	}
+	if req.Active != nil {
+		w.Active = *req.Active
+	}
+	return w
+}
+
+func ListWebhooksHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+		hooks, err := svc.ListWebhooks(r)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, hooks)
+	}
+}
+
+func CreateWebhookHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		w := req.webhook()
+		id, err := svc.CreateWebhook(r, w)
+		if err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": id, "secret": w.Secret})
+	}
+}
+
+func GetWebhookHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		w, err := svc.GetWebhook(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if w == nil {
+			jsonError(c, http.StatusNotFound, "webhook not found")
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	}
+}
+
+func UpdateWebhookHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		var req webhookRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if err := svc.UpdateWebhook(r, id, req.webhook(), req.Active); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func DeleteWebhookHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		if err := svc.DeleteWebhook(r, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func WebhookDeliveriesHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		deliveries, err := svc.ListWebhookDeliveries(r, id, c.Query("status"), limit)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func GetWebhookDeliveryHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		d, err := svc.GetWebhookDelivery(r, id)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if d == nil {
+			jsonError(c, http.StatusNotFound, "delivery not found")
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+func RetryWebhookDeliveryHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		idStr := c.Param("id")
+		id, _ := strconv.ParseInt(idStr, 10, 64)
+
+		if err := svc.RetryWebhookDelivery(r, id); err != nil {
+			jsonError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusAccepted)
+	}
+}
+
+func ListEventsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, models.AllEvents)
+}
+
+// ListDomainEventsHandler lets consumers poll the outbox: pass the last seq they
+// processed as ?after= to resume.
+func ListDomainEventsHandler(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := buildRepo(db)
+
+		after, _ := strconv.ParseInt(c.Query("after"), 10, 64)
+		aggregateID, _ := strconv.ParseInt(c.Query("aggregate_id"), 10, 64)
+		limit, _ := strconv.Atoi(c.Query("limit"))
+
+		events, err := svc.ListEvents(r, after, c.Query("type"), c.Query("aggregate_type"), aggregateID, limit)
+		if err != nil {
+			jsonError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
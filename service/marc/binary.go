@@ -0,0 +1,193 @@
+package marc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ISO 2709 delimiters.
+const (
+	subfieldDelim    = 0x1F
+	fieldTerminator  = 0x1E
+	recordTerminator = 0x1D
+)
+
+const (
+	leaderLen   = 24
+	dirEntryLen = 12
+)
+
+// BinaryReader reads ISO 2709 records. Records are expected in UTF-8
+// (leader/09 "a"); in others, fields that aren't valid UTF-8 are decoded as
+// Latin-1, which is right for plain ASCII and close for most MARC-8 text.
+type BinaryReader struct {
+	r *bufio.Reader
+	n int
+}
+
+func NewBinaryReader(r io.Reader) *BinaryReader {
+	return &BinaryReader{r: bufio.NewReader(r)}
+}
+
+func (br *BinaryReader) Read() (*Record, error) {
+	data, err := br.r.ReadBytes(recordTerminator)
+	if err == io.EOF {
+		if len(strings.TrimSpace(string(data))) == 0 {
+			return nil, io.EOF
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	br.n++
+	// some files put a newline between records
+	data = []byte(strings.TrimLeft(string(data), "\r\n"))
+	rec, err := parseBinary(data)
+	if err != nil {
+		return nil, &ParseError{Record: br.n, Err: err}
+	}
+	return rec, nil
+}
+
+func parseBinary(data []byte) (*Record, error) {
+	if len(data) < leaderLen+1 {
+		return nil, errors.New("record is shorter than its leader")
+	}
+	leader := string(data[:leaderLen])
+	base, ok := digits(data[12:17])
+	if !ok || base <= leaderLen || base > len(data) {
+		return nil, errors.New("bad base address in leader")
+	}
+	decode := latin1
+	if leader[9] == 'a' {
+		decode = func(b []byte) string { return strings.ToValidUTF8(string(b), "�") }
+	}
+
+	rec := &Record{Leader: leader}
+	dir := data[leaderLen : base-1]
+	for len(dir) >= dirEntryLen {
+		tag := string(dir[:3])
+		length, ok1 := digits(dir[3:7])
+		start, ok2 := digits(dir[7:12])
+		dir = dir[dirEntryLen:]
+		if !ok1 || !ok2 || length < 1 || base+start+length > len(data) {
+			return nil, fmt.Errorf("bad directory entry for field %s", tag)
+		}
+		raw := data[base+start : base+start+length]
+		if n := len(raw); n > 0 && raw[n-1] == fieldTerminator {
+			raw = raw[:n-1]
+		}
+
+		if IsControl(tag) {
+			rec.Fields = append(rec.Fields, Field{Tag: tag, Value: decode(raw)})
+			continue
+		}
+		f := Field{Tag: tag, Ind1: ' ', Ind2: ' '}
+		if len(raw) >= 2 {
+			f.Ind1, f.Ind2 = raw[0], raw[1]
+			raw = raw[2:]
+		}
+		for _, part := range strings.Split(string(raw), string(rune(subfieldDelim)))[1:] {
+			if part == "" {
+				continue
+			}
+			f.Subfields = append(f.Subfields, Subfield{Code: part[0], Value: decode([]byte(part[1:]))})
+		}
+		rec.Fields = append(rec.Fields, f)
+	}
+	return rec, nil
+}
+
+// digits reads a fixed-width number of the leader or directory. Unlike
+// strconv.Atoi it takes no sign, so a hostile record can't point before
+// the data.
+func digits(b []byte) (int, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+func latin1(b []byte) string {
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// BinaryWriter writes UTF-8 ISO 2709 records.
+type BinaryWriter struct {
+	w io.Writer
+}
+
+func NewBinaryWriter(w io.Writer) *BinaryWriter {
+	return &BinaryWriter{w: w}
+}
+
+func (bw *BinaryWriter) Write(r *Record) error {
+	var dir, data strings.Builder
+	for _, f := range r.Fields {
+		start := data.Len()
+		if IsControl(f.Tag) {
+			data.WriteString(f.Value)
+		} else {
+			data.WriteByte(f.Ind1)
+			data.WriteByte(f.Ind2)
+			for _, s := range f.Subfields {
+				data.WriteByte(subfieldDelim)
+				data.WriteByte(s.Code)
+				data.WriteString(s.Value)
+			}
+		}
+		data.WriteByte(fieldTerminator)
+		length := data.Len() - start
+		if length > 9999 || start > 99999 {
+			return fmt.Errorf("field %s does not fit in a MARC record", f.Tag)
+		}
+		fmt.Fprintf(&dir, "%s%04d%05d", f.Tag, length, start)
+	}
+	dir.WriteByte(fieldTerminator)
+
+	base := leaderLen + dir.Len()
+	total := base + data.Len() + 1
+	if total > 99999 {
+		return errors.New("record is longer than 99999 bytes")
+	}
+	leader := []byte(r.Leader)
+	if len(leader) != leaderLen {
+		leader = []byte(defaultLeader)
+	}
+	copy(leader[0:5], fmt.Sprintf("%05d", total))
+	leader[9] = 'a'
+	leader[10], leader[11] = '2', '2'
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	out := make([]byte, 0, total)
+	out = append(out, leader...)
+	out = append(out, dir.String()...)
+	out = append(out, data.String()...)
+	out = append(out, recordTerminator)
+	_, err := bw.w.Write(out)
+	return err
+}
+
+func (bw *BinaryWriter) Close() error {
+	return nil
+}
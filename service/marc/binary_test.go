@@ -0,0 +1,112 @@
+package marc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func sampleRecord() *Record {
+	return &Record{
+		Leader: defaultLeader,
+		Fields: []Field{
+			{Tag: "001", Value: "ctl1"},
+			{Tag: "245", Ind1: '1', Ind2: '0', Subfields: []Subfield{{Code: 'a', Value: "Title"}}},
+		},
+	}
+}
+
+func encode(t *testing.T, recs ...*Record) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewBinaryWriter(&buf)
+	for _, r := range recs {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// The sample record is laid out as: leader [0:24], the 001 entry [24:36],
+// the 245 entry [36:48], the directory terminator, then the data from 49.
+func TestBinaryRoundTrip(t *testing.T) {
+	data := encode(t, sampleRecord())
+	if got := string(data[12:17]); got != "00049" {
+		t.Fatalf("base address %q, want 00049", got)
+	}
+
+	rec, err := NewBinaryReader(bytes.NewReader(data)).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.Control("001"); got != "ctl1" {
+		t.Errorf("001 = %q, want ctl1", got)
+	}
+	titles := rec.DataFields("245")
+	if len(titles) != 1 || titles[0].Ind1 != '1' || len(titles[0].Subfields) != 1 || titles[0].Subfields[0].Value != "Title" {
+		t.Errorf("245 = %+v", titles)
+	}
+}
+
+func TestBinaryHostileRecords(t *testing.T) {
+	patch := func(at int, s string) func([]byte) []byte {
+		return func(b []byte) []byte {
+			copy(b[at:], s)
+			return b
+		}
+	}
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+	}{
+		{"shorter than leader", func(b []byte) []byte { return append(b[:10:10], recordTerminator) }},
+		{"truncated data", func(b []byte) []byte { return append(b[:55:55], recordTerminator) }},
+		{"signed base address", patch(12, "-0049")},
+		{"base address past the end", patch(12, "99999")},
+		{"base address with letters", patch(12, "00a49")},
+		{"negative field length", patch(27, "-001")},
+		{"signed field length", patch(27, "+005")},
+		{"zero field length", patch(27, "0000")},
+		{"field length with space", patch(27, " 005")},
+		{"negative field start", patch(43, "-0001")},
+		{"field start past the end", patch(43, "99999")},
+		{"field overruns the record", patch(39, "9000")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.corrupt(encode(t, sampleRecord()))
+			rec, err := NewBinaryReader(bytes.NewReader(data)).Read()
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("got %+v, %v; want a ParseError", rec, err)
+			}
+			if pe.Record != 1 {
+				t.Errorf("ParseError.Record = %d, want 1", pe.Record)
+			}
+		})
+	}
+}
+
+func TestBinaryReaderGoesOnAfterBadRecord(t *testing.T) {
+	bad := encode(t, sampleRecord())
+	copy(bad[27:], "-001")
+	good := encode(t, sampleRecord())
+
+	r := NewBinaryReader(bytes.NewReader(append(append(bad, '\n'), good...)))
+	var pe *ParseError
+	if _, err := r.Read(); !errors.As(err, &pe) {
+		t.Fatalf("first record: got %v, want a ParseError", err)
+	}
+	rec, err := r.Read()
+	if err != nil {
+		t.Fatalf("second record: %v", err)
+	}
+	if rec.Control("001") != "ctl1" {
+		t.Errorf("second record 001 = %q", rec.Control("001"))
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("after the last record: got %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,396 @@
+package marc
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"library-management/service/isbn"
+	"library-management/service/models"
+)
+
+// defaultLeader is a new, complete monograph in Unicode; lengths are
+// filled in when the record is written.
+const defaultLeader = "00000nam a2200000 i 4500"
+
+// relators maps MARC relator codes ($4) and terms ($e) to contributor
+// roles. Anything else is credited as an author.
+var relators = map[string]string{
+	"aut":         models.RoleAuthor,
+	"author":      models.RoleAuthor,
+	"edt":         models.RoleEditor,
+	"editor":      models.RoleEditor,
+	"ill":         models.RoleIllustrator,
+	"illustrator": models.RoleIllustrator,
+	"trl":         models.RoleTranslator,
+	"translator":  models.RoleTranslator,
+}
+
+var roleRelators = map[string]string{
+	models.RoleEditor:      "editor",
+	models.RoleIllustrator: "illustrator",
+	models.RoleTranslator:  "translator",
+}
+
+// languages maps the MARC (ISO 639-2/B) codes of common languages to the
+// two-letter codes the catalog is usually entered with, and back.
+var languages = map[string]string{
+	"ara": "ar", "chi": "zh", "dut": "nl", "eng": "en", "fre": "fr",
+	"ger": "de", "gre": "el", "hin": "hi", "ita": "it", "jpn": "ja",
+	"kor": "ko", "lat": "la", "pol": "pl", "por": "pt", "rus": "ru",
+	"spa": "es", "swe": "sv", "tur": "tr",
+}
+
+var (
+	yearPattern  = regexp.MustCompile(`\d{4}`)
+	pagesPattern = regexp.MustCompile(`(\d+)\s*(p\b|pages|pp\b|s\.)`)
+)
+
+// trimISBD strips the punctuation MARC cataloguing puts at the end of
+// subfields ("The hobbit /", "London :", "Tolkien, J. R. R.,").
+func trimISBD(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimRight(s, " /:;,=")
+	// a final period ends the field unless it closes an initial ("R.")
+	if strings.HasSuffix(s, ".") {
+		i := strings.LastIndexAny(s[:len(s)-1], " .")
+		if len(s)-1-(i+1) > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+// directName turns an inverted personal name ("Le Guin, Ursula K.") into
+// the order the catalog displays ("Ursula K. Le Guin").
+func directName(f Field) string {
+	name := trimISBD(f.Sub('a'))
+	if f.Ind1 != '1' {
+		return name
+	}
+	last, first, ok := strings.Cut(name, ",")
+	if !ok || strings.Contains(first, ",") {
+		return name
+	}
+	return strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+}
+
+// invertedName is directName reversed, taking the last word as the surname.
+func invertedName(name string) (string, byte) {
+	i := strings.LastIndexByte(name, ' ')
+	if i < 0 {
+		return name, '0'
+	}
+	return name[i+1:] + ", " + name[:i], '1'
+}
+
+func contributorRole(f Field) string {
+	for _, code := range append(f.Subs('4'), f.Subs('e')...) {
+		if role, ok := relators[strings.ToLower(trimISBD(code))]; ok {
+			return role
+		}
+	}
+	return models.RoleAuthor
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// ToBook maps a bibliographic record to a book: ISBN from 020, people from
+// 100 and 700, title from 245, edition from 250, publisher and year from
+// 264 or 260, pages from 300, description from 520, subjects from 650 and
+// 651, call number from 090/099/082/050 and language from 041 or 008.
+// Records without a title are rejected.
+func ToBook(r *Record) (*models.Book, error) {
+	b := &models.Book{}
+
+	for _, f := range r.DataFields("245") {
+		title := trimISBD(f.Sub('a'))
+		if sub := trimISBD(f.Sub('b')); sub != "" {
+			title += ": " + sub
+		}
+		for _, part := range append(f.Subs('n'), f.Subs('p')...) {
+			if part = trimISBD(part); part != "" {
+				title += ". " + part
+			}
+		}
+		b.Title = truncate(title, 255)
+		break
+	}
+	if b.Title == "" {
+		return nil, fmt.Errorf("no title (245 $a)")
+	}
+
+	for _, f := range r.DataFields("020") {
+		// "9780261103573 (pbk.)"; the first valid one wins
+		raw, _, _ := strings.Cut(f.Sub('a'), " ")
+		if n, err := isbn.Normalize(raw); err == nil {
+			b.ISBN = &n
+			break
+		}
+	}
+
+	for _, f := range r.DataFields("100", "700") {
+		if name := directName(f); name != "" {
+			b.Contributors = append(b.Contributors, models.Contributor{Name: name, Role: contributorRole(f)})
+		}
+	}
+
+	for _, f := range r.DataFields("250") {
+		// editions end in abbreviations ("2nd ed."), so keep the period
+		b.Edition = truncate(strings.TrimRight(f.Sub('a'), " /:;,="), 64)
+		break
+	}
+
+	// 264 with second indicator 1 is the publication statement; 260 is the
+	// older field for the same
+	var pub *Field
+	for _, f := range r.DataFields("264") {
+		if f.Ind2 == '1' {
+			f := f
+			pub = &f
+			break
+		}
+	}
+	if pub == nil {
+		for _, f := range r.DataFields("260") {
+			f := f
+			pub = &f
+			break
+		}
+	}
+	if pub != nil {
+		b.Publisher = truncate(trimISBD(pub.Sub('b')), 255)
+		if y := yearPattern.FindString(pub.Sub('c')); y != "" {
+			year, _ := strconv.Atoi(y)
+			b.PublicationYear = &year
+		}
+	}
+	if f008 := r.Control("008"); b.PublicationYear == nil && len(f008) >= 11 {
+		if year, err := strconv.Atoi(f008[7:11]); err == nil && year > 0 {
+			b.PublicationYear = &year
+		}
+	}
+
+	for _, f := range r.DataFields("300") {
+		if m := pagesPattern.FindStringSubmatch(f.Sub('a')); m != nil {
+			pages, _ := strconv.Atoi(m[1])
+			if pages > 0 {
+				b.PageCount = &pages
+			}
+		}
+		break
+	}
+
+	var description []string
+	for _, f := range r.DataFields("520") {
+		if s := strings.TrimSpace(f.Sub('a')); s != "" {
+			description = append(description, s)
+		}
+	}
+	b.Description = strings.Join(description, "\n\n")
+
+	for _, f := range r.DataFields("650", "651") {
+		parts := []string{trimISBD(f.Sub('a'))}
+		for _, code := range []byte{'x', 'y', 'z', 'v'} {
+			for _, s := range f.Subs(code) {
+				parts = append(parts, trimISBD(s))
+			}
+		}
+		if parts[0] != "" {
+			b.Subjects = append(b.Subjects, strings.Join(parts, " -- "))
+		}
+	}
+
+	for _, tag := range []string{"090", "099", "092", "082", "050"} {
+		fields := r.DataFields(tag)
+		if len(fields) == 0 {
+			continue
+		}
+		f := fields[0]
+		parts := f.Subs('a')
+		if item := f.Sub('b'); item != "" {
+			parts = append(parts[:min(len(parts), 1)], item)
+		}
+		if call := strings.TrimSpace(strings.ReplaceAll(strings.Join(parts, " "), "/", "")); call != "" {
+			b.CallNumber = truncate(call, 64)
+			break
+		}
+	}
+
+	lang := ""
+	for _, f := range r.DataFields("041") {
+		lang = f.Sub('a')
+		break
+	}
+	if f008 := r.Control("008"); lang == "" && len(f008) >= 38 {
+		lang = strings.TrimSpace(f008[35:38])
+	}
+	lang = strings.ToLower(lang)
+	if short, ok := languages[lang]; ok {
+		lang = short
+	}
+	if lang != "und" && lang != "zxx" && len(lang) >= 2 && len(lang) <= 3 {
+		b.Language = lang
+	}
+
+	b.Format = bookFormat(r.Leader)
+	return b, nil
+}
+
+// bookFormat reads the type of record (leader/06) and bibliographic level
+// (leader/07).
+func bookFormat(leader string) string {
+	if len(leader) < 8 {
+		return models.FormatPrint
+	}
+	if leader[7] == 's' {
+		return models.FormatPeriodical
+	}
+	switch leader[6] {
+	case 'e', 'f':
+		return models.FormatMap
+	case 'g':
+		return models.FormatVideo
+	case 'i':
+		return models.FormatAudiobook
+	case 'm':
+		return models.FormatEbook
+	}
+	return models.FormatPrint
+}
+
+func recordType(format string) (byte, byte) {
+	switch format {
+	case models.FormatPeriodical:
+		return 'a', 's'
+	case models.FormatMap:
+		return 'e', 'm'
+	case models.FormatVideo:
+		return 'g', 'm'
+	case models.FormatAudiobook:
+		return 'i', 'm'
+	case models.FormatEbook:
+		return 'm', 'm'
+	}
+	return 'a', 'm'
+}
+
+// FromBook builds a record for a book with its contributors and subjects
+// loaded. 001 holds the book's id.
+func FromBook(b *models.Book) *Record {
+	leader := []byte(defaultLeader)
+	leader[6], leader[7] = recordType(b.Format)
+	r := &Record{Leader: string(leader)}
+
+	r.addControl("001", strconv.FormatInt(b.ID, 10))
+	r.addControl("005", b.UpdatedAt.UTC().Format("20060102150405.0"))
+
+	lang := b.Language
+	for long, short := range languages {
+		if short == lang {
+			lang = long
+			break
+		}
+	}
+	if len(lang) != 3 {
+		lang = "und"
+	}
+	year := "    "
+	if b.PublicationYear != nil {
+		year = fmt.Sprintf("%04d", *b.PublicationYear)
+	}
+	f008 := []byte(strings.Repeat(" ", 40))
+	copy(f008[0:6], b.CreatedAt.UTC().Format("060102"))
+	f008[6] = 's'
+	copy(f008[7:11], year)
+	copy(f008[15:18], "xx ")
+	copy(f008[35:38], lang)
+	f008[39] = 'd'
+	r.addControl("008", string(f008))
+
+	if b.ISBN != nil {
+		r.addData("020", ' ', ' ', Subfield{'a', *b.ISBN})
+	}
+	if b.CallNumber != "" {
+		r.addData("099", ' ', ' ', Subfield{'a', b.CallNumber})
+	}
+
+	people := b.Contributors
+	if len(people) == 0 && b.Author != "" {
+		people = []models.Contributor{{Name: b.Author, Role: models.RoleAuthor}}
+	}
+	main := -1
+	for i, c := range people {
+		if c.Role == models.RoleAuthor {
+			main = i
+			break
+		}
+	}
+	if main >= 0 {
+		name, ind1 := invertedName(people[main].Name)
+		r.addData("100", ind1, ' ', Subfield{'a', name + ","}, Subfield{'e', "author."})
+	}
+
+	title, subtitle, _ := strings.Cut(b.Title, ": ")
+	ind1 := byte('0')
+	if main >= 0 {
+		ind1 = '1'
+	}
+	skip := byte('0')
+	for _, article := range []string{"The ", "A ", "An "} {
+		if strings.HasPrefix(title, article) {
+			skip = byte('0' + len(article))
+		}
+	}
+	if subtitle != "" {
+		r.addData("245", ind1, skip, Subfield{'a', title + " :"}, Subfield{'b', subtitle})
+	} else {
+		r.addData("245", ind1, skip, Subfield{'a', title})
+	}
+
+	r.addData("250", ' ', ' ', Subfield{'a', b.Edition})
+	if b.Publisher != "" || b.PublicationYear != nil {
+		var subs []Subfield
+		if b.Publisher != "" {
+			subs = append(subs, Subfield{'b', b.Publisher})
+		}
+		if b.PublicationYear != nil {
+			subs = append(subs, Subfield{'c', strconv.Itoa(*b.PublicationYear)})
+		}
+		r.addData("264", ' ', '1', subs...)
+	}
+	if b.PageCount != nil {
+		r.addData("300", ' ', ' ', Subfield{'a', fmt.Sprintf("%d pages", *b.PageCount)})
+	}
+	r.addData("520", ' ', ' ', Subfield{'a', b.Description})
+	for _, s := range b.Subjects {
+		parts := strings.Split(s, " -- ")
+		subs := []Subfield{{'a', parts[0]}}
+		for _, p := range parts[1:] {
+			subs = append(subs, Subfield{'x', p})
+		}
+		r.addData("650", ' ', '4', subs...)
+	}
+	for i, c := range people {
+		if i == main {
+			continue
+		}
+		name, ind1 := invertedName(c.Name)
+		relator := roleRelators[c.Role]
+		if relator == "" {
+			relator = "author"
+		}
+		r.addData("700", ind1, ' ', Subfield{'a', name + ","}, Subfield{'e', relator + "."})
+	}
+	return r
+}
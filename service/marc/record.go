@@ -0,0 +1,168 @@
+// Package marc reads and writes MARC 21 bibliographic records, as binary
+// ISO 2709 files and as MARCXML, and maps them to and from catalog books.
+package marc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	FormatBinary = "marc"
+	FormatXML    = "xml"
+)
+
+var ErrFormat = errors.New("unknown MARC format")
+
+// ParseError is a record that could not be decoded. Reading can go on with
+// the next one.
+type ParseError struct {
+	Record int
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("record %d: %v", e.Record, e.Err)
+}
+
+// Record is one MARC record. Control fields (001-009) carry Value, data
+// fields indicators and subfields.
+type Record struct {
+	Leader string
+	Fields []Field
+}
+
+type Field struct {
+	Tag       string
+	Value     string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// IsControl reports whether tag is a control field, which has no
+// indicators or subfields.
+func IsControl(tag string) bool {
+	return len(tag) == 3 && tag < "010" && strings.HasPrefix(tag, "00")
+}
+
+// Control returns the value of the first control field with tag.
+func (r *Record) Control(tag string) string {
+	for _, f := range r.Fields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// DataFields returns the fields with any of the given tags, in record order.
+func (r *Record) DataFields(tags ...string) []Field {
+	var out []Field
+	for _, f := range r.Fields {
+		for _, t := range tags {
+			if f.Tag == t {
+				out = append(out, f)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Sub returns the first subfield with code, trimmed.
+func (f Field) Sub(code byte) string {
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			return strings.TrimSpace(s.Value)
+		}
+	}
+	return ""
+}
+
+// Subs returns every subfield with code, trimmed.
+func (f Field) Subs(code byte) []string {
+	var out []string
+	for _, s := range f.Subfields {
+		if s.Code == code {
+			out = append(out, strings.TrimSpace(s.Value))
+		}
+	}
+	return out
+}
+
+func (r *Record) addControl(tag, value string) {
+	r.Fields = append(r.Fields, Field{Tag: tag, Value: value})
+}
+
+func (r *Record) addData(tag string, ind1, ind2 byte, subs ...Subfield) {
+	var kept []Subfield
+	for _, s := range subs {
+		if s.Value != "" {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) > 0 {
+		r.Fields = append(r.Fields, Field{Tag: tag, Ind1: ind1, Ind2: ind2, Subfields: kept})
+	}
+}
+
+// Reader yields records one at a time and returns io.EOF after the last.
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// Writer writes records; Close finishes the file.
+type Writer interface {
+	Write(r *Record) error
+	Close() error
+}
+
+// NewReader picks the binary or the XML reader for format, or by looking
+// at the first bytes of the input when format is empty.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	br := bufio.NewReader(r)
+	if format == "" {
+		format = FormatBinary
+		for {
+			b, err := br.Peek(1)
+			if err != nil {
+				break
+			}
+			if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' || b[0] == 0xEF || b[0] == 0xBB || b[0] == 0xBF {
+				// whitespace or a byte order mark before XML
+				_, _ = br.ReadByte()
+				continue
+			}
+			if b[0] == '<' {
+				format = FormatXML
+			}
+			break
+		}
+	}
+	switch format {
+	case FormatBinary:
+		return NewBinaryReader(br), nil
+	case FormatXML:
+		return NewXMLReader(br), nil
+	}
+	return nil, ErrFormat
+}
+
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatBinary:
+		return NewBinaryWriter(w), nil
+	case FormatXML:
+		return NewXMLWriter(w), nil
+	}
+	return nil, ErrFormat
+}
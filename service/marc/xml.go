@@ -0,0 +1,149 @@
+package marc
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const xmlNamespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLReader reads the record elements of a MARCXML document, whether the
+// root is a collection or a single record.
+type XMLReader struct {
+	d *xml.Decoder
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{d: xml.NewDecoder(r)}
+}
+
+func (xr *XMLReader) Read() (*Record, error) {
+	for {
+		tok, err := xr.d.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var x xmlRecord
+		if err := xr.d.DecodeElement(&x, &start); err != nil {
+			return nil, err
+		}
+		return fromXML(&x), nil
+	}
+}
+
+func fromXML(x *xmlRecord) *Record {
+	rec := &Record{Leader: x.Leader}
+	// control fields come first in MARC, then data fields, each in tag order
+	// as given
+	for _, c := range x.ControlFields {
+		rec.Fields = append(rec.Fields, Field{Tag: c.Tag, Value: c.Value})
+	}
+	for _, d := range x.DataFields {
+		f := Field{Tag: d.Tag, Ind1: indicator(d.Ind1), Ind2: indicator(d.Ind2)}
+		for _, s := range d.Subfields {
+			if s.Code == "" {
+				continue
+			}
+			f.Subfields = append(f.Subfields, Subfield{Code: s.Code[0], Value: s.Value})
+		}
+		rec.Fields = append(rec.Fields, f)
+	}
+	return rec
+}
+
+func indicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
+
+// XMLWriter writes a MARCXML collection.
+type XMLWriter struct {
+	w       io.Writer
+	e       *xml.Encoder
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	return &XMLWriter{w: w, e: e}
+}
+
+func (xw *XMLWriter) start() error {
+	if xw.started {
+		return nil
+	}
+	xw.started = true
+	if _, err := io.WriteString(xw.w, xml.Header); err != nil {
+		return err
+	}
+	return xw.e.EncodeToken(xml.StartElement{
+		Name: xml.Name{Local: "collection"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xmlNamespace}},
+	})
+}
+
+func (xw *XMLWriter) Write(r *Record) error {
+	if err := xw.start(); err != nil {
+		return err
+	}
+	x := xmlRecord{Leader: r.Leader}
+	for _, f := range r.Fields {
+		if IsControl(f.Tag) {
+			x.ControlFields = append(x.ControlFields, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+		d := xmlDataField{Tag: f.Tag, Ind1: string(f.Ind1), Ind2: string(f.Ind2)}
+		for _, s := range f.Subfields {
+			d.Subfields = append(d.Subfields, xmlSubfield{Code: string(s.Code), Value: s.Value})
+		}
+		x.DataFields = append(x.DataFields, d)
+	}
+	return xw.e.Encode(&x)
+}
+
+// Close ends the collection; an export of no records is still a valid,
+// empty collection.
+func (xw *XMLWriter) Close() error {
+	if err := xw.start(); err != nil {
+		return err
+	}
+	if err := xw.e.EncodeToken(xml.EndElement{Name: xml.Name{Local: "collection"}}); err != nil {
+		return err
+	}
+	if err := xw.e.Flush(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(xw.w, "\n")
+	return err
+}
@@ -0,0 +1,96 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"library-management/service/bulk"
+)
+
+func TestFileProviderCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.csv")
+	data := "ISBN,Title,Author,Publisher,Year\n" +
+		"0-306-40615-2,The Old Title,Someone,,\n" +
+		"978-0-306-40615-7,Signal Processing,Ann Author; Bob Writer,Plenum,1981\n" +
+		"\n" +
+		"0-261-10221-4,The Hobbit,J.R.R. Tolkien,HarperCollins,\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := p.Lookup(context.Background(), "9780306406157")
+	if err != nil {
+		t.Fatal(err)
+	}
+	year := 1981
+	want := &Record{
+		ISBN:      "9780306406157",
+		Title:     "Signal Processing",
+		Authors:   []string{"Ann Author", "Bob Writer"},
+		Publisher: "Plenum",
+		Year:      &year,
+		Source:    "file",
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("Lookup = %+v, want %+v (a later row replaces an earlier one)", rec, want)
+	}
+
+	rec.Authors[0] = "changed"
+	again, _ := p.Lookup(context.Background(), "9780306406157")
+	if again.Authors[0] != "Ann Author" {
+		t.Error("changing a looked-up record changed the provider's copy")
+	}
+
+	hobbit, err := p.Lookup(context.Background(), "9780261102217")
+	if err != nil || hobbit.Year != nil || hobbit.Title != "The Hobbit" {
+		t.Errorf("Lookup(hobbit) = %+v, %v", hobbit, err)
+	}
+	if _, err := p.Lookup(context.Background(), "9780000000002"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown isbn: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestFileProviderJSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.jsonl")
+	data := `{"isbn13": "9780306406157", "title": "Signal Processing", "authors": ["Ann Author", "Bob Writer"], "year": 1981, "cover": "http://covers.example/1.jpg"}` + "\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewFileProvider(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := p.Lookup(context.Background(), "9780306406157")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rec.Authors, []string{"Ann Author", "Bob Writer"}) || rec.Year == nil || *rec.Year != 1981 || rec.CoverURL != "http://covers.example/1.jpg" {
+		t.Errorf("Lookup = %+v", rec)
+	}
+}
+
+func TestReadRecordsRejects(t *testing.T) {
+	tests := map[string]string{
+		"bad isbn":        "isbn,title\n978-0-306-40615-8,Wrong Check Digit\n",
+		"missing title":   "isbn,title\n9780306406157,\n",
+		"bad year":        "isbn,title,year\n9780306406157,Title,nineteen\n",
+		"no known column": "code,name\n9780306406157,Title\n",
+	}
+	for name, data := range tests {
+		if _, err := ReadRecords(strings.NewReader(data), bulk.FormatCSV); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+	if _, err := NewFileProvider(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("missing file: no error")
+	}
+}
@@ -0,0 +1,97 @@
+// Package metadata looks up what is known about an edition by its ISBN, so
+// cataloguers don't have to type it.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrNotFound = errors.New("no metadata for this isbn")
+
+// Record is an edition as a provider describes it. ISBN is the ISBN-13
+// that was looked up; Source names the provider that answered.
+type Record struct {
+	ISBN      string   `json:"isbn"`
+	Title     string   `json:"title"`
+	Authors   []string `json:"authors"`
+	Publisher string   `json:"publisher"`
+	Year      *int     `json:"publication_year"`
+	CoverURL  string   `json:"cover_url"`
+	Source    string   `json:"source"`
+}
+
+// MetadataProvider finds an edition by ISBN-13, or returns ErrNotFound.
+type MetadataProvider interface {
+	Name() string
+	Lookup(ctx context.Context, isbn string) (*Record, error)
+}
+
+// Chain asks each provider in turn until one knows the ISBN.
+type Chain []MetadataProvider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c Chain) Lookup(ctx context.Context, isbn string) (*Record, error) {
+	var failed error
+	for _, p := range c {
+		rec, err := p.Lookup(ctx, isbn)
+		if err == nil {
+			return rec, nil
+		}
+		if !errors.Is(err, ErrNotFound) && failed == nil {
+			// a later provider may still know it
+			failed = fmt.Errorf("%s: %w", p.Name(), err)
+		}
+	}
+	if failed != nil {
+		return nil, failed
+	}
+	return nil, ErrNotFound
+}
+
+// FromEnv builds the providers listed in METADATA_PROVIDERS, comma
+// separated and asked in that order:
+//
+//	openlibrary (default) the Open Library books API at OPENLIBRARY_URL
+//	file        a CSV or JSON Lines file of records, METADATA_FILE
+//	none        no lookups
+func FromEnv() (MetadataProvider, error) {
+	kinds := os.Getenv("METADATA_PROVIDERS")
+	if kinds == "" {
+		kinds = "openlibrary"
+	}
+	var chain Chain
+	for _, kind := range strings.Split(kinds, ",") {
+		switch kind = strings.TrimSpace(kind); kind {
+		case "openlibrary":
+			chain = append(chain, NewOpenLibrary(os.Getenv("OPENLIBRARY_URL")))
+		case "file":
+			path := os.Getenv("METADATA_FILE")
+			if path == "" {
+				path = "metadata.csv"
+			}
+			p, err := NewFileProvider(path)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, p)
+		case "none":
+		default:
+			return nil, fmt.Errorf("unknown metadata provider %q", kind)
+		}
+	}
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
@@ -0,0 +1,60 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type stubProvider struct {
+	name string
+	rec  *Record
+	err  error
+	asks int
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) Lookup(ctx context.Context, isbn string) (*Record, error) {
+	s.asks++
+	return s.rec, s.err
+}
+
+func TestChain(t *testing.T) {
+	ctx := context.Background()
+	found := &Record{ISBN: "9780306406157", Title: "Found", Source: "second"}
+
+	miss := &stubProvider{name: "first", err: ErrNotFound}
+	hit := &stubProvider{name: "second", rec: found}
+	never := &stubProvider{name: "third", rec: &Record{Title: "Too late"}}
+	c := Chain{miss, hit, never}
+	if c.Name() != "first,second,third" {
+		t.Errorf("Name = %q", c.Name())
+	}
+	rec, err := c.Lookup(ctx, found.ISBN)
+	if err != nil || rec != found {
+		t.Fatalf("Lookup = %+v, %v; want the second provider's record", rec, err)
+	}
+	if never.asks != 0 {
+		t.Error("providers after a hit were asked")
+	}
+
+	// a failing provider doesn't hide a later answer
+	down := &stubProvider{name: "down", err: errors.New("connection refused")}
+	if rec, err := (Chain{down, hit}).Lookup(ctx, found.ISBN); err != nil || rec != found {
+		t.Errorf("after a failure: got %+v, %v; want the record", rec, err)
+	}
+
+	// but it is reported when nobody knows the isbn
+	_, err = Chain{miss, down}.Lookup(ctx, found.ISBN)
+	if err == nil || errors.Is(err, ErrNotFound) || err.Error() != "down: connection refused" {
+		t.Errorf("failure and miss: got %v, want the failure", err)
+	}
+
+	if _, err := (Chain{miss, miss}).Lookup(ctx, found.ISBN); !errors.Is(err, ErrNotFound) {
+		t.Errorf("all miss: got %v, want ErrNotFound", err)
+	}
+	if _, err := (Chain{}).Lookup(ctx, found.ISBN); !errors.Is(err, ErrNotFound) {
+		t.Errorf("empty chain: got %v, want ErrNotFound", err)
+	}
+}
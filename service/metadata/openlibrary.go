@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	openLibraryURL     = "https://openlibrary.org"
+	openLibraryTimeout = 10 * time.Second
+)
+
+// OpenLibrary looks editions up with the Open Library books API, or any
+// service answering the same /api/books requests.
+type OpenLibrary struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOpenLibrary(baseURL string) *OpenLibrary {
+	if baseURL == "" {
+		baseURL = openLibraryURL
+	}
+	return &OpenLibrary{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: openLibraryTimeout},
+	}
+}
+
+func (o *OpenLibrary) Name() string { return "openlibrary" }
+
+type openLibraryName struct {
+	Name string `json:"name"`
+}
+
+type openLibraryBook struct {
+	Title       string            `json:"title"`
+	Subtitle    string            `json:"subtitle"`
+	Authors     []openLibraryName `json:"authors"`
+	Publishers  []openLibraryName `json:"publishers"`
+	PublishDate string            `json:"publish_date"`
+	Cover       struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+}
+
+func (o *OpenLibrary) Lookup(ctx context.Context, isbn string) (*Record, error) {
+	key := "ISBN:" + isbn
+	q := url.Values{"bibkeys": {key}, "format": {"json"}, "jscmd": {"data"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/books?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "library-management")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var found map[string]openLibraryBook
+	if err := json.NewDecoder(resp.Body).Decode(&found); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	b, ok := found[key]
+	if !ok || b.Title == "" {
+		return nil, ErrNotFound
+	}
+
+	rec := &Record{ISBN: isbn, Title: b.Title, Year: yearOf(b.PublishDate), Source: o.Name()}
+	if b.Subtitle != "" {
+		rec.Title += ": " + b.Subtitle
+	}
+	for _, a := range b.Authors {
+		if a.Name != "" {
+			rec.Authors = append(rec.Authors, a.Name)
+		}
+	}
+	if len(b.Publishers) > 0 {
+		rec.Publisher = b.Publishers[0].Name
+	}
+	for _, u := range []string{b.Cover.Large, b.Cover.Medium, b.Cover.Small} {
+		if u != "" {
+			rec.CoverURL = u
+			break
+		}
+	}
+	return rec, nil
+}
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// yearOf finds the year in a free-form date such as "March 1965" or
+// "c1965".
+func yearOf(date string) *int {
+	m := yearPattern.FindString(strings.TrimPrefix(strings.TrimSpace(date), "c"))
+	if m == "" {
+		return nil
+	}
+	y, _ := strconv.Atoi(m)
+	return &y
+}
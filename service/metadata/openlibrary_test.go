@@ -0,0 +1,95 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenLibraryLookup(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/books" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		switch r.URL.Query().Get("bibkeys") {
+		case "ISBN:9780306406157":
+			w.Write([]byte(`{"ISBN:9780306406157": {
+				"title": "Signal Processing",
+				"subtitle": "An Introduction",
+				"authors": [{"name": "Ann Author"}, {"name": ""}, {"name": "Bob Writer"}],
+				"publishers": [{"name": "Plenum"}, {"name": "Other"}],
+				"publish_date": "March 1981",
+				"cover": {"small": "http://covers.example/s.jpg", "medium": "http://covers.example/m.jpg"}
+			}}`))
+		case "ISBN:9780261102217":
+			w.Write([]byte(`{}`))
+		case "ISBN:9780000000002":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		default:
+			w.Write([]byte(`not json`))
+		}
+	}))
+	defer srv.Close()
+	ol := NewOpenLibrary(srv.URL + "/")
+	ctx := context.Background()
+
+	rec, err := ol.Lookup(ctx, "9780306406157")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "bibkeys=ISBN%3A9780306406157&format=json&jscmd=data" {
+		t.Errorf("query = %s", query)
+	}
+	year := 1981
+	want := &Record{
+		ISBN:      "9780306406157",
+		Title:     "Signal Processing: An Introduction",
+		Authors:   []string{"Ann Author", "Bob Writer"},
+		Publisher: "Plenum",
+		Year:      &year,
+		CoverURL:  "http://covers.example/m.jpg",
+		Source:    "openlibrary",
+	}
+	if !reflect.DeepEqual(rec, want) {
+		t.Errorf("Lookup = %+v, want %+v", rec, want)
+	}
+
+	if _, err := ol.Lookup(ctx, "9780261102217"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown isbn: got %v, want ErrNotFound", err)
+	}
+	if _, err := ol.Lookup(ctx, "9780000000002"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("server error: got %v, want a failure", err)
+	}
+	if _, err := ol.Lookup(ctx, "9791090636071"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("bad response: got %v, want a failure", err)
+	}
+}
+
+func TestYearOf(t *testing.T) {
+	tests := map[string]int{
+		"1965":            1965,
+		"March 1965":      1965,
+		"c1965":           1965,
+		"  c1965 ":        1965,
+		"1965-03-01":      1965,
+		"May 4, 2001":     2001,
+		"[1998?]":         1998,
+		"printed 1990-92": 1990,
+	}
+	for in, want := range tests {
+		if got := yearOf(in); got == nil || *got != want {
+			t.Errorf("yearOf(%q) = %v, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "n.d.", "19th century", "12345"} {
+		if got := yearOf(in); got != nil {
+			t.Errorf("yearOf(%q) = %d, want nil", in, *got)
+		}
+	}
+}
@@ -1,32 +1,807 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
+// Book.Copies and Book.Available are derived from BookCopy rows. On create,
+// Copies asks for that many copies with generated barcodes unless Barcodes
+// lists the real ones. Subjects and Contributors are only loaded for a
+// single book. ISBN is stored as ISBN-13 whichever form was given. Author
+// is the display line built from Contributors.
 type Book struct {
-	ID        int64     `db:"id" json:"id"`
-	Title     string    `db:"title" json:"title" binding:"required"`
-	Author    string    `db:"author" json:"author" binding:"required"`
-	Copies    int       `db:"copies" json:"copies"`
-	Available int       `db:"available" json:"available"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+	ID              int64         `db:"id" json:"id"`
+	Title           string        `db:"title" json:"title" binding:"required"`
+	Author          string        `db:"author" json:"author"`
+	ISBN            *string       `db:"isbn" json:"isbn"`
+	Publisher       string        `db:"publisher" json:"publisher"`
+	PublicationYear *int          `db:"publication_year" json:"publication_year"`
+	Edition         string        `db:"edition" json:"edition"`
+	Language        string        `db:"language" json:"language"`
+	Format          string        `db:"format" json:"format"`
+	PageCount       *int          `db:"page_count" json:"page_count"`
+	Description     string        `db:"description" json:"description"`
+	CallNumber      string        `db:"call_number" json:"call_number"`
+	Subjects        []string      `db:"-" json:"subjects,omitempty"`
+	Contributors    []Contributor `db:"-" json:"contributors,omitempty"`
+	Copies          int           `db:"copies" json:"copies"`
+	Available       int           `db:"available" json:"available"`
+	Barcodes        []string      `db:"-" json:"barcodes,omitempty"`
+	CreatedAt       time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time     `db:"updated_at" json:"updated_at"`
+	DeletedAt       *time.Time    `db:"deleted_at" json:"deleted_at,omitempty"`
 }
 
-type Member struct {
+const (
+	FormatPrint      = "print"
+	FormatEbook      = "ebook"
+	FormatAudiobook  = "audiobook"
+	FormatPeriodical = "periodical"
+	FormatVideo      = "video"
+	FormatMap        = "map"
+)
+
+var BookFormats = []string{FormatPrint, FormatEbook, FormatAudiobook, FormatPeriodical, FormatVideo, FormatMap}
+
+func IsBookFormat(f string) bool {
+	for _, known := range BookFormats {
+		if f == known {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleIllustrator = "illustrator"
+	RoleTranslator  = "translator"
+)
+
+var ContributorRoles = []string{RoleAuthor, RoleEditor, RoleIllustrator, RoleTranslator}
+
+func IsContributorRole(r string) bool {
+	for _, known := range ContributorRoles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Contributor credits an author with a role on a book. On input either
+// AuthorID or Name picks the author; a name that isn't known yet creates
+// one.
+type Contributor struct {
+	AuthorID int64  `db:"author_id" json:"author_id,omitempty"`
+	Name     string `db:"name" json:"name"`
+	Role     string `db:"role" json:"role"`
+}
+
+// Author.Books counts the books they contributed to that aren't deleted.
+type Author struct {
 	ID        int64     `db:"id" json:"id"`
 	Name      string    `db:"name" json:"name" binding:"required"`
-	Email     string    `db:"email" json:"email"`
-	RollNo    string    `db:"roll_no" json:"roll_no"`
+	NameKey   string    `db:"name_key" json:"-"`
+	Books     int       `db:"books" json:"books"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
+// AuthorWork is one of an author's books and the role they had in it.
+type AuthorWork struct {
+	BookID          int64  `db:"book_id" json:"book_id"`
+	Title           string `db:"title" json:"title"`
+	PublicationYear *int   `db:"publication_year" json:"publication_year"`
+	Role            string `db:"role" json:"role"`
+}
+
+type AuthorDetail struct {
+	Author
+	Works []AuthorWork `json:"works"`
+}
+
+type AuthorFilter struct {
+	ListOptions
+	Query string
+}
+
+// AuthorDuplicates is a group of authors that are probably one person,
+// and why they were grouped.
+type AuthorDuplicates struct {
+	Reason  string   `json:"reason"`
+	Authors []Author `json:"authors"`
+}
+
+const (
+	CopyAvailable = "available"
+	CopyOnLoan    = "on_loan"
+	CopyOnHold    = "on_hold"
+	CopyDamaged   = "damaged"
+	CopyLost      = "lost"
+	CopyWithdrawn = "withdrawn"
+)
+
+type BookCopy struct {
+	ID          int64     `db:"id" json:"id"`
+	BookID      int64     `db:"book_id" json:"book_id"`
+	Barcode     string    `db:"barcode" json:"barcode"`
+	AccessionNo *string   `db:"accession_no" json:"accession_no"`
+	Status      string    `db:"status" json:"status"`
+	Condition   string    `db:"item_condition" json:"condition"`
+	Location    string    `db:"location" json:"location"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// CopyUpdate edits a copy; fields left out (or null) keep their value. An
+// empty accession_no clears it.
+type CopyUpdate struct {
+	Barcode     *string `json:"barcode"`
+	AccessionNo *string `json:"accession_no"`
+	Status      *string `json:"status"`
+	Condition   *string `json:"condition"`
+	Location    *string `json:"location"`
+}
+
+type Member struct {
+	ID           int64      `db:"id" json:"id"`
+	Name         string     `db:"name" json:"name" binding:"required"`
+	Email        string     `db:"email" json:"email"`
+	RollNo       string     `db:"roll_no" json:"roll_no"`
+	Category     string     `db:"category" json:"category"`
+	ExpiresAt    *string    `db:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	AnonymizedAt *time.Time `db:"anonymized_at" json:"anonymized_at,omitempty"`
+}
+
 type Issue struct {
 	ID         int64      `db:"id" json:"id"`
 	BookID     int64      `db:"book_id" json:"book_id"`
+	CopyID     *int64     `db:"copy_id" json:"copy_id"`
 	MemberID   int64      `db:"member_id" json:"member_id"`
 	IssuedAt   time.Time  `db:"issued_at" json:"issued_at"`
 	DueDate    *string    `db:"due_date" json:"due_date"`
+	Renewals   int        `db:"renewal_count" json:"renewal_count"`
+	RenewedAt  *time.Time `db:"last_renewed_at" json:"last_renewed_at"`
 	ReturnedAt *time.Time `db:"returned_at" json:"returned_at"`
 	FinePaid   float64    `db:"fine_paid" json:"fine_paid"`
 }
+
+type Admin struct {
+	ID           int64     `db:"id" json:"id"`
+	Username     string    `db:"username" json:"username"`
+	PasswordHash string    `db:"password_hash" json:"-"`
+	Active       bool      `db:"active" json:"active"`
+	Roles        []string  `db:"-" json:"roles"`
+	Permissions  []string  `db:"-" json:"permissions"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (a *Admin) Can(permission string) bool {
+	for _, p := range a.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	PermCatalogWrite        = "catalog:write"
+	PermCatalogDelete       = "catalog:delete"
+	PermMembersRead         = "members:read"
+	PermMembersWrite        = "members:write"
+	PermMembersDelete       = "members:delete"
+	PermCirculationIssue    = "circulation:issue"
+	PermCirculationReturn   = "circulation:return"
+	PermStaffManage         = "staff:manage"
+	PermPoliciesManage      = "policies:manage"
+	PermFinesCollect        = "fines:collect"
+	PermFinesWaive          = "fines:waive"
+	PermCirculationOverride = "circulation:override"
+	PermWebhooksManage      = "webhooks:manage"
+	PermEventsRead          = "events:read"
+	PermAuditRead           = "audit:read"
+)
+
+var AllPermissions = []string{
+	PermCatalogWrite,
+	PermCatalogDelete,
+	PermMembersRead,
+	PermMembersWrite,
+	PermMembersDelete,
+	PermCirculationIssue,
+	PermCirculationReturn,
+	PermStaffManage,
+	PermPoliciesManage,
+	PermFinesCollect,
+	PermFinesWaive,
+	PermCirculationOverride,
+	PermWebhooksManage,
+	PermEventsRead,
+	PermAuditRead,
+}
+
+func IsPermission(p string) bool {
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	ID          int64     `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name" binding:"required"`
+	Description string    `db:"description" json:"description"`
+	Permissions []string  `db:"-" json:"permissions"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+type AdminSession struct {
+	ID        int64      `db:"id" json:"id"`
+	AdminID   int64      `db:"admin_id" json:"admin_id"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
+
+const (
+	HoldWaiting   = "waiting"
+	HoldReady     = "ready"
+	HoldFulfilled = "fulfilled"
+	HoldCancelled = "cancelled"
+	HoldExpired   = "expired"
+)
+
+// Hold is a member's place in the FIFO queue for a title. Once a returned
+// copy is set aside for it the hold is ready until ExpiresAt.
+type Hold struct {
+	ID        int64      `db:"id" json:"id"`
+	BookID    int64      `db:"book_id" json:"book_id"`
+	MemberID  int64      `db:"member_id" json:"member_id"`
+	Status    string     `db:"status" json:"status"`
+	CopyID    *int64     `db:"copy_id" json:"copy_id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	ReadyAt   *time.Time `db:"ready_at" json:"ready_at"`
+	ExpiresAt *time.Time `db:"expires_at" json:"expires_at"`
+	ClosedAt  *time.Time `db:"closed_at" json:"closed_at"`
+	Position  int        `db:"-" json:"position,omitempty"`
+}
+
+// LoanPolicy holds the circulation limits for one member category. A zero
+// FineCap means fines are not capped; members owing more than
+// MaxFineBalance are blocked from borrowing.
+type LoanPolicy struct {
+	Category            string    `db:"category" json:"category"`
+	LoanDays            int       `db:"loan_days" json:"loan_days" binding:"required,min=1"`
+	MaxLoans            int       `db:"max_loans" json:"max_loans" binding:"min=0"`
+	MaxRenewals         int       `db:"max_renewals" json:"max_renewals" binding:"min=0"`
+	RenewOverdueMaxDays int       `db:"renew_overdue_max_days" json:"renew_overdue_max_days" binding:"min=0"`
+	FinePerDay          float64   `db:"fine_per_day" json:"fine_per_day" binding:"min=0"`
+	GraceDays           int       `db:"grace_days" json:"grace_days" binding:"min=0"`
+	FineCap             float64   `db:"fine_cap" json:"fine_cap" binding:"min=0"`
+	MaxFineBalance      float64   `db:"max_fine_balance" json:"max_fine_balance" binding:"min=0"`
+	BlockOnOverdue      bool      `db:"block_on_overdue" json:"block_on_overdue"`
+	UpdatedAt           time.Time `db:"updated_at" json:"updated_at"`
+}
+
+const (
+	FineOverdue = "overdue"
+	FineLost    = "lost"
+	FineDamaged = "damaged"
+	FineOther   = "other"
+
+	FineOpen   = "open"
+	FinePaid   = "paid"
+	FineWaived = "waived"
+
+	TxnPayment = "payment"
+	TxnWaiver  = "waiver"
+)
+
+var PaymentMethods = []string{"cash", "card", "online", "cheque"}
+
+// Fine is a charge against a member. Amount never changes; Paid and Waived
+// accumulate from FineTransaction rows until nothing is outstanding.
+type Fine struct {
+	ID           int64             `db:"id" json:"id"`
+	MemberID     int64             `db:"member_id" json:"member_id"`
+	IssueID      *int64            `db:"issue_id" json:"issue_id"`
+	Kind         string            `db:"kind" json:"kind"`
+	Amount       float64           `db:"amount" json:"amount"`
+	Paid         float64           `db:"paid" json:"paid"`
+	Waived       float64           `db:"waived" json:"waived"`
+	Status       string            `db:"status" json:"status"`
+	Note         string            `db:"note" json:"note"`
+	CreatedBy    *int64            `db:"created_by" json:"created_by"`
+	CreatedAt    time.Time         `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time         `db:"updated_at" json:"updated_at"`
+	Transactions []FineTransaction `db:"-" json:"transactions,omitempty"`
+}
+
+func (f *Fine) Outstanding() float64 {
+	return f.Amount - f.Paid - f.Waived
+}
+
+type FineTransaction struct {
+	ID        int64     `db:"id" json:"id"`
+	FineID    int64     `db:"fine_id" json:"fine_id"`
+	MemberID  int64     `db:"member_id" json:"member_id"`
+	Kind      string    `db:"kind" json:"kind"`
+	Amount    float64   `db:"amount" json:"amount"`
+	Method    string    `db:"method" json:"method,omitempty"`
+	Reason    string    `db:"reason" json:"reason,omitempty"`
+	ReceiptNo *string   `db:"receipt_no" json:"receipt_no,omitempty"`
+	StaffID   *int64    `db:"staff_id" json:"staff_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type Receipt struct {
+	ReceiptNo string            `json:"receipt_no"`
+	MemberID  int64             `json:"member_id"`
+	Method    string            `json:"method"`
+	Total     float64           `json:"total"`
+	StaffID   *int64            `json:"staff_id"`
+	IssuedAt  time.Time         `json:"issued_at"`
+	Lines     []FineTransaction `json:"lines"`
+}
+
+type Balance struct {
+	MemberID    int64   `json:"member_id"`
+	Outstanding float64 `json:"outstanding"`
+	OpenFines   []Fine  `json:"open_fines"`
+}
+
+const (
+	BlockFinesExceeded     = "FINES_EXCEEDED"
+	BlockOverdueLoans      = "OVERDUE_LOANS"
+	BlockLoanLimit         = "LOAN_LIMIT"
+	BlockMembershipExpired = "MEMBERSHIP_EXPIRED"
+)
+
+type BlockReason struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type CirculationOverride struct {
+	ID            int64     `db:"id" json:"id"`
+	IssueID       *int64    `db:"issue_id" json:"issue_id"`
+	MemberID      int64     `db:"member_id" json:"member_id"`
+	BookID        int64     `db:"book_id" json:"book_id"`
+	StaffID       *int64    `db:"staff_id" json:"staff_id"`
+	Reasons       string    `db:"reasons" json:"reasons"`
+	Justification string    `db:"justification" json:"justification"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+const (
+	NoticeReminder = "reminder"
+	NoticeOverdue  = "overdue"
+
+	NoticePending = "pending"
+	NoticeSent    = "sent"
+	NoticeFailed  = "failed"
+)
+
+// Notice is one reminder or overdue message sent for a loan. Level is 0 for
+// reminders and the escalation step (1, 2, ...) for overdue notices.
+type Notice struct {
+	ID        int64      `db:"id" json:"id"`
+	IssueID   int64      `db:"issue_id" json:"issue_id"`
+	MemberID  int64      `db:"member_id" json:"member_id"`
+	DueDate   string     `db:"due_date" json:"due_date"`
+	Kind      string     `db:"kind" json:"kind"`
+	Level     int        `db:"level" json:"level"`
+	Channel   string     `db:"channel" json:"channel"`
+	Recipient string     `db:"recipient" json:"recipient"`
+	Subject   string     `db:"subject" json:"subject"`
+	Status    string     `db:"status" json:"status"`
+	Error     *string    `db:"error" json:"error,omitempty"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	SentAt    *time.Time `db:"sent_at" json:"sent_at"`
+}
+
+// DueLoan is an open loan with what a notice about it needs to say.
+type DueLoan struct {
+	IssueID    int64  `db:"issue_id"`
+	MemberID   int64  `db:"member_id"`
+	MemberName string `db:"member_name"`
+	Email      string `db:"email"`
+	BookID     int64  `db:"book_id"`
+	Title      string `db:"title"`
+	Author     string `db:"author"`
+	DueDate    string `db:"due_date"`
+}
+
+const (
+	EventBookCreated      = "book.created"
+	EventBookUpdated      = "book.updated"
+	EventBookDeleted      = "book.deleted"
+	EventBookRestored     = "book.restored"
+	EventBookCoverChanged = "book.cover_changed"
+	EventBookAvailable    = "book.available"
+	EventBookIssued       = "book.issued"
+	EventBookReturned     = "book.returned"
+	EventBookRenewed      = "book.renewed"
+	EventHoldPlaced       = "hold.placed"
+	EventHoldReady        = "hold.ready"
+	EventAuthorUpdated    = "author.updated"
+	EventAuthorMerged     = "author.merged"
+	EventMemberCreated    = "member.created"
+	EventMemberUpdated    = "member.updated"
+	EventMemberDeleted    = "member.deleted"
+	EventMemberRestored   = "member.restored"
+	EventMemberAnonymized = "member.anonymized"
+	EventFineAssessed     = "fine.assessed"
+	EventFinePaid         = "fine.paid"
+	EventFineWaived       = "fine.waived"
+)
+
+var AllEvents = []string{
+	EventBookCreated,
+	EventBookUpdated,
+	EventBookDeleted,
+	EventBookRestored,
+	EventBookCoverChanged,
+	EventBookAvailable,
+	EventBookIssued,
+	EventBookReturned,
+	EventBookRenewed,
+	EventHoldPlaced,
+	EventHoldReady,
+	EventAuthorUpdated,
+	EventAuthorMerged,
+	EventMemberCreated,
+	EventMemberUpdated,
+	EventMemberDeleted,
+	EventMemberRestored,
+	EventMemberAnonymized,
+	EventFineAssessed,
+	EventFinePaid,
+	EventFineWaived,
+}
+
+// Event is a domain event recorded in the outbox in the same transaction
+// as the change it describes. Its JSON form is what sinks receive.
+type Event struct {
+	Seq           int64           `db:"id" json:"seq"`
+	ID            string          `db:"event_id" json:"id"`
+	Type          string          `db:"event_type" json:"type"`
+	AggregateType string          `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID   int64           `db:"aggregate_id" json:"aggregate_id"`
+	Data          json.RawMessage `db:"payload" json:"data"`
+	Status        string          `db:"status" json:"-"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	DispatchedAt  *time.Time      `db:"dispatched_at" json:"dispatched_at,omitempty"`
+	Attempts      int             `db:"attempts" json:"-"`
+	NextAttemptAt *time.Time      `db:"next_attempt_at" json:"-"`
+	LastError     *string         `db:"last_error" json:"-"`
+}
+
+func IsEvent(e string) bool {
+	for _, known := range AllEvents {
+		if e == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a subscription. Events is a comma separated list of event
+// types, or "*" for all of them. Secret signs every delivery and is only
+// shown when the webhook is created.
+type Webhook struct {
+	ID          int64     `db:"id" json:"id"`
+	URL         string    `db:"url" json:"url" binding:"required,url"`
+	Secret      string    `db:"secret" json:"secret,omitempty"`
+	Events      string    `db:"events" json:"events"`
+	Active      bool      `db:"active" json:"active"`
+	Description string    `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (w *Webhook) Wants(event string) bool {
+	for _, e := range strings.Split(w.Events, ",") {
+		if e = strings.TrimSpace(e); e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookDelivery struct {
+	ID             int64           `db:"id" json:"id"`
+	WebhookID      int64           `db:"webhook_id" json:"webhook_id"`
+	EventID        string          `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code" json:"last_status_code"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+
+	AttemptLog []WebhookAttempt `db:"-" json:"attempt_log,omitempty"`
+}
+
+type WebhookAttempt struct {
+	ID           int64     `db:"id" json:"id"`
+	DeliveryID   int64     `db:"delivery_id" json:"delivery_id"`
+	StatusCode   *int      `db:"status_code" json:"status_code"`
+	Error        *string   `db:"error" json:"error"`
+	ResponseBody *string   `db:"response_body" json:"response_body"`
+	DurationMS   int       `db:"duration_ms" json:"duration_ms"`
+	AttemptedAt  time.Time `db:"attempted_at" json:"attempted_at"`
+}
+
+// AuditEntry records one administrative action. Before and After are
+// snapshots of the entity around the change; Diff holds only the fields
+// that changed as {"field": {"from": ..., "to": ...}}.
+type AuditEntry struct {
+	ID         int64           `db:"id" json:"id"`
+	OccurredAt time.Time       `db:"occurred_at" json:"occurred_at"`
+	ActorID    *int64          `db:"actor_id" json:"actor_id"`
+	Actor      string          `db:"actor" json:"actor"`
+	Action     string          `db:"action" json:"action"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   string          `db:"entity_id" json:"entity_id"`
+	Before     json.RawMessage `db:"before_json" json:"before,omitempty"`
+	After      json.RawMessage `db:"after_json" json:"after,omitempty"`
+	Diff       json.RawMessage `db:"diff" json:"diff,omitempty"`
+	Method     string          `db:"method" json:"method"`
+	Path       string          `db:"path" json:"path"`
+	Status     int             `db:"status" json:"status"`
+	RequestID  string          `db:"request_id" json:"request_id"`
+	IP         string          `db:"ip" json:"ip"`
+}
+
+type AuditFilter struct {
+	ActorID    int64
+	Action     string
+	EntityType string
+	EntityID   string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	BeforeID   int64
+	Limit      int
+}
+
+// ListOptions asks for one page of a list. Cursor is the next_cursor of the
+// previous page; Sort is a sort key, prefixed with "-" for descending.
+type ListOptions struct {
+	Limit  int
+	Cursor string
+	Sort   string
+}
+
+type BookFilter struct {
+	ListOptions
+	Author      string
+	Available   *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+type MemberFilter struct {
+	ListOptions
+	Category    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+const (
+	IssueStatusActive   = "active"
+	IssueStatusReturned = "returned"
+	IssueStatusOverdue  = "overdue"
+)
+
+type IssueFilter struct {
+	ListOptions
+	MemberID int64
+	BookID   int64
+	Status   string
+}
+
+// Page is the envelope every paginated list answers with. NextCursor is
+// empty on the last page; Total is only counted for the first page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// TextMatch is one condition of a catalog search. Field is "" for title,
+// author and description together, "title" or "author". For Match conditions Expr is a
+// MySQL boolean-mode expression, for Like conditions plain text to find
+// anywhere in the field.
+type TextMatch struct {
+	Field   string
+	Expr    string
+	Exclude bool
+}
+
+// TextQuery is a catalog search ready for the FULLTEXT index. Rank is the
+// boolean-mode expression hits are ordered by. ISBN lists ISBN-13s a hit
+// must have, NotISBN ones it must not.
+type TextQuery struct {
+	Match   []TextMatch
+	Like    []TextMatch
+	ISBN    []string
+	NotISBN []string
+	Filter  FacetFilter
+	Rank    string
+	Limit   int
+}
+
+// FacetFilter narrows a search to books having one of the listed values for
+// every facet that lists any. Years are inclusive; 0 leaves a side open.
+type FacetFilter struct {
+	Authors   []string
+	Subjects  []string
+	Languages []string
+	Formats   []string
+	YearFrom  int
+	YearTo    int
+	Available *bool
+}
+
+func (f FacetFilter) Empty() bool {
+	return len(f.Authors) == 0 && len(f.Subjects) == 0 && len(f.Languages) == 0 &&
+		len(f.Formats) == 0 && f.YearFrom == 0 && f.YearTo == 0 && f.Available == nil
+}
+
+type FacetCount struct {
+	Value string `db:"value" json:"value"`
+	Count int    `db:"count" json:"count"`
+}
+
+// Facets counts every book a search matched, not only the page of hits
+// returned. Year buckets are decades such as "1990-1999".
+type Facets struct {
+	Author       []FacetCount `json:"author"`
+	Subject      []FacetCount `json:"subject"`
+	Year         []FacetCount `json:"year"`
+	Language     []FacetCount `json:"language"`
+	Format       []FacetCount `json:"format"`
+	Availability []FacetCount `json:"availability"`
+}
+
+type ScoredBook struct {
+	Book
+	Score float64 `db:"score" json:"score"`
+}
+
+// CatalogEntry is the part of a book the suggestion index keeps in memory.
+type CatalogEntry struct {
+	ID      int64
+	Title   string
+	Authors []string
+}
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+)
+
+// ImportRow is the outcome of one record of an import. Row counts records
+// (or data lines) from 1; Key is what the record was matched on.
+type ImportRow struct {
+	Row    int    `json:"row"`
+	Action string `json:"action"`
+	ID     int64  `json:"id,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport sums up an import, or with DryRun what it would have done.
+// Error is set when the file stopped being readable; the rows before it
+// were imported.
+type ImportReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Skipped int         `json:"skipped"`
+	Rows    []ImportRow `json:"rows"`
+	Error   string      `json:"error,omitempty"`
+}
+
+func (r *ImportReport) Add(row ImportRow) {
+	switch row.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+const (
+	ImportBooks   = "books"
+	ImportMembers = "members"
+
+	ImportRunning     = "running"
+	ImportDone        = "done"
+	ImportFailed      = "failed"
+	ImportInterrupted = "interrupted"
+)
+
+// ImportJob tracks a bulk import running in the background. Progress is
+// BytesRead of BytesTotal; Errors keeps the skipped rows, the first
+// MaxImportErrors of them. IgnoredColumns are header columns that matched
+// no field.
+type ImportJob struct {
+	ID             int64           `db:"id" json:"id"`
+	Kind           string          `db:"kind" json:"kind"`
+	Format         string          `db:"format" json:"format"`
+	Status         string          `db:"status" json:"status"`
+	DryRun         bool            `db:"dry_run" json:"dry_run"`
+	BytesTotal     int64           `db:"bytes_total" json:"bytes_total"`
+	BytesRead      int64           `db:"bytes_read" json:"bytes_read"`
+	Progress       float64         `db:"-" json:"progress"`
+	Processed      int             `db:"processed" json:"processed"`
+	Created        int             `db:"created" json:"created"`
+	Updated        int             `db:"updated" json:"updated"`
+	Skipped        int             `db:"skipped" json:"skipped"`
+	IgnoredColumns json.RawMessage `db:"ignored_columns" json:"ignored_columns,omitempty"`
+	RowErrors      json.RawMessage `db:"row_errors" json:"errors,omitempty"`
+	Error          string          `db:"error" json:"error,omitempty"`
+	CreatedBy      *int64          `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+	FinishedAt     *time.Time      `db:"finished_at" json:"finished_at"`
+}
+
+const MaxImportErrors = 1000
+
+// BookDraft is a new book filled in from an ISBN lookup, for a cataloguer
+// to check and send on to create. ExistingBookID is set when the ISBN is
+// already in the catalog.
+type BookDraft struct {
+	Title           string        `json:"title"`
+	Author          string        `json:"author"`
+	ISBN            string        `json:"isbn"`
+	Publisher       string        `json:"publisher"`
+	PublicationYear *int          `json:"publication_year"`
+	Contributors    []Contributor `json:"contributors"`
+	CoverURL        string        `json:"cover_url,omitempty"`
+	Source          string        `json:"source"`
+	ExistingBookID  *int64        `json:"existing_book_id,omitempty"`
+}
+
+// BookCover describes a book's cover image. ContentType, Width and Height
+// are those of the original upload; thumbnails are JPEG.
+type BookCover struct {
+	BookID      int64             `db:"book_id" json:"book_id"`
+	ETag        string            `db:"etag" json:"etag"`
+	ContentType string            `db:"content_type" json:"content_type"`
+	Width       int               `db:"width" json:"width"`
+	Height      int               `db:"height" json:"height"`
+	Bytes       int               `db:"bytes" json:"bytes"`
+	UpdatedAt   time.Time         `db:"updated_at" json:"updated_at"`
+	URLs        map[string]string `db:"-" json:"urls,omitempty"`
+}
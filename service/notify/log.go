@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes each message to a logger instead of delivering it.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Channel() string { return "log" }
+
+func (n *LogNotifier) Send(ctx context.Context, m Message) error {
+	n.logger.Printf("notice to %s: %s\n%s", m.To, m.Subject, m.Body)
+	return nil
+}
+
+// FileNotifier appends each message as a JSON line.
+type FileNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewFileNotifier(path string) (*FileNotifier, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriterNotifier(f), nil
+}
+
+func NewWriterNotifier(w io.Writer) *FileNotifier {
+	return &FileNotifier{w: w}
+}
+
+func (n *FileNotifier) Channel() string { return "file" }
+
+func (n *FileNotifier) Send(ctx context.Context, m Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{m, time.Now()})
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = n.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,60 @@
+// Package notify delivers messages to library members.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type Message struct {
+	To      string `json:"to"`
+	ToName  string `json:"to_name,omitempty"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier sends a message over one channel. Channel names the channel in
+// the notices table, e.g. "smtp" or "log".
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, m Message) error
+}
+
+// FromEnv builds the notifier selected by NOTIFIER:
+//
+//	log  (default) write notices to the process log
+//	file append notices as JSON lines to NOTICE_FILE
+//	smtp send mail through SMTP_HOST:SMTP_PORT as SMTP_FROM, with
+//	     SMTP_USER/SMTP_PASS when the server wants authentication
+func FromEnv() (Notifier, error) {
+	switch kind := os.Getenv("NOTIFIER"); kind {
+	case "", "log":
+		return NewLogNotifier(nil), nil
+	case "file":
+		path := os.Getenv("NOTICE_FILE")
+		if path == "" {
+			path = "notices.jsonl"
+		}
+		return NewFileNotifier(path)
+	case "smtp":
+		port := 25
+		if s := os.Getenv("SMTP_PORT"); s != "" {
+			p, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("SMTP_PORT: %w", err)
+			}
+			port = p
+		}
+		return NewSMTPNotifier(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			From:     os.Getenv("SMTP_FROM"),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASS"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q", kind)
+	}
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds a send whose context has no deadline.
+const smtpTimeout = time.Minute
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	From     string
+	Username string
+	Password string
+}
+
+type SMTPNotifier struct {
+	addr string
+	from mail.Address
+	auth smtp.Auth
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) (*SMTPNotifier, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("smtp from address: %w", err)
+	}
+	n := &SMTPNotifier{
+		addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from: *from,
+	}
+	if cfg.Username != "" {
+		n.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return n, nil
+}
+
+func (n *SMTPNotifier) Channel() string { return "smtp" }
+
+func (n *SMTPNotifier) Send(ctx context.Context, m Message) error {
+	to := mail.Address{Name: m.ToName, Address: m.To}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("\r\n")
+	buf.Write(bytes.ReplaceAll([]byte(m.Body), []byte("\n"), []byte("\r\n")))
+	buf.WriteString("\r\n")
+
+	return n.send(ctx, m.To, buf.Bytes())
+}
+
+// send is smtp.SendMail over a connection that ctx bounds: when ctx ends
+// the connection's deadline passes and the exchange fails where it is, so
+// Send never reports failure for a mail that is still being delivered.
+// Once the server has accepted the message it counts as sent.
+func (n *SMTPNotifier) send(ctx context.Context, to string, msg []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(n.addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	c.Quit()
+	return nil
+}
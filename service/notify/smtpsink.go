@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMTPSink is a tiny stand-in SMTP server for development. It accepts every
+// message without authentication and hands it to Deliver instead of
+// relaying it, so SMTPNotifier can be exercised without a real mail server.
+type SMTPSink struct {
+	Deliver func(Message)
+
+	ln       net.Listener
+	mu       sync.Mutex
+	received []Message
+	wg       sync.WaitGroup
+}
+
+func ListenSMTPSink(addr string) (*SMTPSink, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPSink{ln: ln}, nil
+}
+
+func (s *SMTPSink) Addr() string { return s.ln.Addr().String() }
+
+// Serve accepts connections until Close is called.
+func (s *SMTPSink) Serve() error {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *SMTPSink) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Messages returns everything received so far.
+func (s *SMTPSink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.received...)
+}
+
+func (s *SMTPSink) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	rd := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	reply("220 library smtp sink ready")
+	var rcpt []string
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, _, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250 hello")
+		case "MAIL":
+			rcpt = nil
+			reply("250 ok")
+		case "RCPT":
+			rcpt = append(rcpt, strings.Trim(line[strings.Index(line, ":")+1:], " <>"))
+			reply("250 ok")
+		case "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(rd)
+			if err != nil {
+				return
+			}
+			s.store(rcpt, data)
+			reply("250 queued")
+		case "RSET":
+			rcpt = nil
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func readData(rd *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return buf.Bytes(), nil
+		}
+		// undo dot-stuffing
+		buf.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
+
+func (s *SMTPSink) store(rcpt []string, data []byte) {
+	m := Message{To: strings.Join(rcpt, ", ")}
+	if msg, err := mail.ReadMessage(bytes.NewReader(data)); err == nil {
+		subject := msg.Header.Get("Subject")
+		if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+			subject = decoded
+		}
+		m.Subject = subject
+		body, _ := io.ReadAll(msg.Body)
+		m.Body = strings.ReplaceAll(string(body), "\r\n", "\n")
+	} else {
+		m.Body = string(data)
+	}
+
+	s.mu.Lock()
+	s.received = append(s.received, m)
+	s.mu.Unlock()
+	if s.Deliver != nil {
+		s.Deliver(m)
+	}
+}
@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSMTPNotifierThroughSink(t *testing.T) {
+	sink, err := ListenSMTPSink("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivered := make(chan Message, 1)
+	sink.Deliver = func(m Message) { delivered <- m }
+	go sink.Serve()
+	defer sink.Close()
+
+	host, portStr, _ := net.SplitHostPort(sink.Addr())
+	port, _ := strconv.Atoi(portStr)
+	n, err := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "Library <library@example.org>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := Message{
+		To:      "reader@example.org",
+		ToName:  "Zoë Reader",
+		Subject: "Reminder: «Dune» is due on 20 October 2026",
+		Body:    "Dear Zoë,\n.starts with a dot\nThe library",
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := n.Send(ctx, sent); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-delivered:
+		if got.To != sent.To {
+			t.Errorf("To = %q, want %q", got.To, sent.To)
+		}
+		if got.Subject != sent.Subject {
+			t.Errorf("Subject = %q, want %q", got.Subject, sent.Subject)
+		}
+		if want := sent.Body + "\n"; got.Body != want {
+			t.Errorf("Body = %q, want %q", got.Body, want)
+		}
+	case <-ctx.Done():
+		t.Fatal("sink received nothing")
+	}
+	if msgs := sink.Messages(); len(msgs) != 1 {
+		t.Errorf("sink kept %d messages, want 1", len(msgs))
+	}
+}
+
+// A cancelled send gives up on the connection before returning, so the
+// server can't go on to accept a message the caller counts as failed.
+func TestSMTPNotifierCancelClosesConnection(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	closed := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			closed <- err
+			return
+		}
+		defer conn.Close()
+		// never greet; wait for the client to hang up
+		_, err = conn.Read(make([]byte, 1))
+		closed <- err
+	}()
+
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	n, err := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "library@example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := n.Send(ctx, Message{To: "reader@example.org", Subject: "s", Body: "b"}); err == nil {
+		t.Fatal("send to a silent server succeeded")
+	}
+
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Errorf("server read data after the send failed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("connection still open after Send returned")
+	}
+}
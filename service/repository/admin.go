@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type AdminRepo interface {
+	Create(a *models.Admin) (int64, error)
+	GetByID(id int64) (*models.Admin, error)
+	GetByUsername(username string) (*models.Admin, error)
+	GetAll() ([]models.Admin, error)
+	UpdatePassword(id int64, passwordHash string) error
+	SetActive(id int64, active bool) error
+	GetRoleNames(id int64) ([]string, error)
+	GetPermissions(id int64) ([]string, error)
+	SetRoles(id int64, roleIDs []int64) error
+	// CountActiveWithPermissionForUpdate counts the active accounts granted
+	// perm by any of their roles, locking the rows it reads.
+	CountActiveWithPermissionForUpdate(perm string) (int, error)
+}
+
+type SessionRepo interface {
+	Create(s *models.AdminSession) (int64, error)
+	GetActiveByTokenHash(tokenHash string, now time.Time) (*models.AdminSession, error)
+	Revoke(tokenHash string, at time.Time) (bool, error)
+	RevokeAllForAdmin(adminID int64, at time.Time) error
+}
+
+type adminRepository struct {
+	db queryer
+}
+
+func (r *adminRepository) Create(a *models.Admin) (int64, error) {
+	res, err := r.db.Exec(db.QCreateAdmin, a.Username, a.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *adminRepository) GetByID(id int64) (*models.Admin, error) {
+	var a models.Admin
+	if err := r.db.Get(&a, db.QGetAdminByID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *adminRepository) GetByUsername(username string) (*models.Admin, error) {
+	var a models.Admin
+	if err := r.db.Get(&a, db.QGetAdminByUsername, username); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *adminRepository) GetAll() ([]models.Admin, error) {
+	var admins []models.Admin
+	if err := r.db.Select(&admins, db.QGetAllAdmins); err != nil {
+		return nil, err
+	}
+	return admins, nil
+}
+
+func (r *adminRepository) SetActive(id int64, active bool) error {
+	_, err := r.db.Exec(db.QSetAdminActive, active, id)
+	return err
+}
+
+func (r *adminRepository) GetRoleNames(id int64) ([]string, error) {
+	names := []string{}
+	if err := r.db.Select(&names, db.QGetAdminRoleNames, id); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (r *adminRepository) GetPermissions(id int64) ([]string, error) {
+	perms := []string{}
+	if err := r.db.Select(&perms, db.QGetAdminPermissions, id); err != nil {
+		return nil, err
+	}
+	return perms, nil
+}
+
+func (r *adminRepository) SetRoles(id int64, roleIDs []int64) error {
+	if _, err := r.db.Exec(db.QClearAdminRoles, id); err != nil {
+		return err
+	}
+	for _, roleID := range roleIDs {
+		if _, err := r.db.Exec(db.QAddAdminRole, id, roleID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *adminRepository) CountActiveWithPermissionForUpdate(perm string) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountActiveAdminsWithPermissionForUpdate, perm); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *adminRepository) UpdatePassword(id int64, passwordHash string) error {
+	_, err := r.db.Exec(db.QUpdateAdminPassword, passwordHash, id)
+	return err
+}
+
+type sessionRepository struct {
+	db queryer
+}
+
+func (r *sessionRepository) Create(s *models.AdminSession) (int64, error) {
+	res, err := r.db.Exec(db.QCreateSession, s.AdminID, s.TokenHash, s.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *sessionRepository) GetActiveByTokenHash(tokenHash string, now time.Time) (*models.AdminSession, error) {
+	var s models.AdminSession
+	if err := r.db.Get(&s, db.QGetActiveSessionByToken, tokenHash, now); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *sessionRepository) Revoke(tokenHash string, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QRevokeSession, at, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *sessionRepository) RevokeAllForAdmin(adminID int64, at time.Time) error {
+	_, err := r.db.Exec(db.QRevokeSessionsByAdmin, at, adminID)
+	return err
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+// AuditRepo is append-only on purpose: entries are never updated or
+// deleted through the service.
+type AuditRepo interface {
+	Append(e *models.AuditEntry) (int64, error)
+	Find(f models.AuditFilter) ([]models.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db queryer
+}
+
+func (r *auditRepository) Append(e *models.AuditEntry) (int64, error) {
+	res, err := r.db.Exec(db.QAppendAudit, e.ActorID, e.Actor, e.Action, e.EntityType, e.EntityID,
+		nullJSON(e.Before), nullJSON(e.After), nullJSON(e.Diff),
+		e.Method, e.Path, e.Status, e.RequestID, e.IP)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *auditRepository) Find(f models.AuditFilter) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	err := r.db.Select(&entries, db.QFindAudit,
+		f.ActorID, f.ActorID,
+		f.Action, f.Action,
+		f.EntityType, f.EntityType,
+		f.EntityID, f.EntityID,
+		f.RequestID, f.RequestID,
+		f.From, f.From,
+		f.To, f.To,
+		f.BeforeID, f.BeforeID,
+		f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return b
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type AuthorRepo interface {
+	Create(name, key string) (int64, error)
+	GetByID(id int64) (*models.Author, error)
+	GetByIDForUpdate(id int64) (*models.Author, error)
+	GetByKey(key string) (*models.Author, error)
+	GetAll() ([]models.Author, error)
+	// GetKeys lists every author with only ID, Name and NameKey set.
+	GetKeys() ([]models.Author, error)
+	List(f models.AuthorFilter) (models.Page[models.Author], error)
+	GetWorks(id int64) ([]models.AuthorWork, error)
+	GetBookIDs(id int64) ([]int64, error)
+	Update(id int64, name, key string) error
+	MoveContributions(from, to int64) error
+	Delete(id int64) error
+}
+
+type authorRepository struct {
+	db queryer
+}
+
+func (r *authorRepository) Create(name, key string) (int64, error) {
+	res, err := r.db.Exec(db.QCreateAuthor, name, key)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *authorRepository) get(query string, args ...interface{}) (*models.Author, error) {
+	var a models.Author
+	if err := r.db.Get(&a, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *authorRepository) GetByID(id int64) (*models.Author, error) {
+	return r.get(db.QGetAuthorByID, id)
+}
+
+func (r *authorRepository) GetByIDForUpdate(id int64) (*models.Author, error) {
+	return r.get(db.QGetAuthorByIDForUpdate, id)
+}
+
+func (r *authorRepository) GetByKey(key string) (*models.Author, error) {
+	return r.get(db.QGetAuthorByKey, key)
+}
+
+func (r *authorRepository) GetAll() ([]models.Author, error) {
+	authors := []models.Author{}
+	if err := r.db.Select(&authors, db.QGetAllAuthors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+func (r *authorRepository) GetKeys() ([]models.Author, error) {
+	authors := []models.Author{}
+	if err := r.db.Select(&authors, db.QGetAuthorKeys); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+var authorSorts = map[string]sortKey{
+	"name": {column: "a.name"},
+}
+
+// List finds authors whose name contains f.Query.
+func (r *authorRepository) List(f models.AuthorFilter) (models.Page[models.Author], error) {
+	page := models.Page[models.Author]{Items: []models.Author{}}
+	pattern := "%" + likeEscaper.Replace(f.Query) + "%"
+	args := []interface{}{f.Query, pattern}
+	if f.Cursor == "" {
+		var total int
+		if err := r.db.Get(&total, db.QCountAuthors, args...); err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	query, args, err := pageQuery(db.QListAuthors, args, authorSorts, "a.id", f.ListOptions)
+	if err != nil {
+		return page, err
+	}
+	if err := r.db.Select(&page.Items, query, args...); err != nil {
+		return page, err
+	}
+	page.Items, page.NextCursor = nextCursor(page.Items, f.ListOptions, func(a *models.Author) (string, int64) {
+		if f.Sort == "name" || f.Sort == "-name" {
+			return a.Name, a.ID
+		}
+		return "", a.ID
+	})
+	return page, nil
+}
+
+func (r *authorRepository) GetWorks(id int64) ([]models.AuthorWork, error) {
+	works := []models.AuthorWork{}
+	if err := r.db.Select(&works, db.QGetAuthorWorks, id); err != nil {
+		return nil, err
+	}
+	return works, nil
+}
+
+// GetBookIDs includes soft deleted books.
+func (r *authorRepository) GetBookIDs(id int64) ([]int64, error) {
+	ids := []int64{}
+	if err := r.db.Select(&ids, db.QGetAuthorBookIDs, id); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *authorRepository) Update(id int64, name, key string) error {
+	_, err := r.db.Exec(db.QUpdateAuthor, name, key, id)
+	return err
+}
+
+// MoveContributions credits author to with everything author from
+// contributed, leaving from without books.
+func (r *authorRepository) MoveContributions(from, to int64) error {
+	if _, err := r.db.Exec(db.QMoveContributions, to, from); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(db.QDeleteAuthorContributions, from)
+	return err
+}
+
+func (r *authorRepository) Delete(id int64) error {
+	_, err := r.db.Exec(db.QDeleteAuthor, id)
+	return err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type CopyRepo interface {
+	Create(c *models.BookCopy) (int64, error)
+	GetByID(id int64) (*models.BookCopy, error)
+	GetByBarcode(barcode string) (*models.BookCopy, error)
+	GetByBarcodeForUpdate(barcode string) (*models.BookCopy, error)
+	GetByBook(bookID int64) ([]models.BookCopy, error)
+	CountByBook(bookID int64) (int, error)
+	ClaimAvailable(bookID int64) (*models.BookCopy, error)
+	Update(c *models.BookCopy) error
+	SetStatus(id int64, from, to string) (bool, error)
+}
+
+type copyRepository struct {
+	db queryer
+}
+
+func (r *copyRepository) Create(c *models.BookCopy) (int64, error) {
+	res, err := r.db.Exec(db.QCreateCopy, c.BookID, c.Barcode, c.AccessionNo, c.Status, c.Condition, c.Location)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *copyRepository) get(query string, args ...interface{}) (*models.BookCopy, error) {
+	var c models.BookCopy
+	if err := r.db.Get(&c, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *copyRepository) GetByID(id int64) (*models.BookCopy, error) {
+	return r.get(db.QGetCopyByID, id)
+}
+
+func (r *copyRepository) GetByBarcode(barcode string) (*models.BookCopy, error) {
+	return r.get(db.QGetCopyByBarcode, barcode)
+}
+
+func (r *copyRepository) GetByBarcodeForUpdate(barcode string) (*models.BookCopy, error) {
+	return r.get(db.QGetCopyByBarcodeForUpdate, barcode)
+}
+
+// ClaimAvailable locks the first available copy of a book; the caller is
+// expected to flip its status within the same transaction.
+func (r *copyRepository) ClaimAvailable(bookID int64) (*models.BookCopy, error) {
+	return r.get(db.QClaimAvailableCopy, bookID)
+}
+
+func (r *copyRepository) GetByBook(bookID int64) ([]models.BookCopy, error) {
+	copies := []models.BookCopy{}
+	if err := r.db.Select(&copies, db.QGetCopiesByBook, bookID); err != nil {
+		return nil, err
+	}
+	return copies, nil
+}
+
+func (r *copyRepository) CountByBook(bookID int64) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountCopiesByBook, bookID); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *copyRepository) Update(c *models.BookCopy) error {
+	_, err := r.db.Exec(db.QUpdateCopy, c.Barcode, c.AccessionNo, c.Condition, c.Location, c.ID)
+	return err
+}
+
+func (r *copyRepository) SetStatus(id int64, from, to string) (bool, error) {
+	res, err := r.db.Exec(db.QSetCopyStatus, to, id, from)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
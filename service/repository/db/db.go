@@ -29,41 +29,3 @@ func ConnectDB() (*sqlx.DB, error) {
 	}
 	return db, nil
 }
-
-func EnsureSchema(db *sqlx.DB) error {
-	schema := `
-  CREATE TABLE IF NOT EXISTS books (
-  id BIGINT AUTO_INCREMENT PRIMARY KEY,
-  title VARCHAR(255) NOT NULL,
-  author VARCHAR(255) NOT NULL,
-  copies INT DEFAULT 1,
-  available INT DEFAULT 1,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-
-CREATE TABLE IF NOT EXISTS members (
-id BIGINT AUTO_INCREMENT PRIMARY KEY,
-name VARCHAR(255) NOT NULL,
-email VARCHAR(255),
-roll_no VARCHAR(100),
-created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
-
-CREATE TABLE IF NOT EXISTS issues (
-id BIGINT AUTO_INCREMENT PRIMARY KEY,
-book_id BIGINT NOT NULL,
-member_id BIGINT NOT NULL,
-issued_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-due_date DATE,
-returned_at TIMESTAMP NULL DEFAULT NULL,
-fine_paid DECIMAL(10,2) DEFAULT 0,
-created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
-FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE
-)ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
-
-	_, err := db.Exec(schema)
-	return err
-}
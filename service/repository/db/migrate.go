@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const (
+	migrationLockName    = "library_schema_migrations"
+	migrationLockTimeout = 60
+)
+
+const (
+	qCreateMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+	qAppliedMigrations = `SELECT version, applied_at
+	FROM schema_migrations
+	ORDER BY version`
+	qRecordMigration = `INSERT INTO schema_migrations (version, name)
+	VALUES (?, ?)`
+	qForgetMigration = `DELETE FROM schema_migrations
+	WHERE version = ?`
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads the embedded NNNN_name.up.sql / NNNN_name.down.sql
+// pairs, ordered by version.
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		file := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(file, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(file, "."+direction+".sql")
+		num, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name", file)
+		}
+		version, err := strconv.Atoi(num)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", file, err)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join("migrations", file))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %04d: name mismatch %q vs %q", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s: missing up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order.
+func MigrateUp(ctx context.Context, db *sqlx.DB) ([]Migration, error) {
+	var applied []Migration
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		migrations, done, err := loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, m.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", m.Version, m.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, qRecordMigration, m.Version, m.Name); err != nil {
+				return err
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown reverts the most recent steps applied migrations.
+func MigrateDown(ctx context.Context, db *sqlx.DB, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		migrations, done, err := loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %04d_%s: no down file", m.Version, m.Name)
+			}
+			if _, err := conn.ExecContext(ctx, m.Down); err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", m.Version, m.Name, err)
+			}
+			if _, err := conn.ExecContext(ctx, qForgetMigration, m.Version); err != nil {
+				return err
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+func MigrationStatuses(ctx context.Context, db *sqlx.DB) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		migrations, done, err := loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			st := MigrationStatus{Version: m.Version, Name: m.Name}
+			if at, ok := done[m.Version]; ok {
+				at := at
+				st.AppliedAt = &at
+			}
+			statuses = append(statuses, st)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func loadState(ctx context.Context, conn *sql.Conn) ([]Migration, map[int]time.Time, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, qCreateMigrationsTable); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, qAppliedMigrations)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	done := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, nil, err
+		}
+		done[version] = at
+	}
+	return migrations, done, rows.Err()
+}
+
+// withMigrationLock holds a MySQL named lock on a dedicated connection so
+// that two instances starting together don't migrate concurrently.
+func withMigrationLock(ctx context.Context, db *sqlx.DB, fn func(*sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, migrationLockTimeout).Scan(&got); err != nil {
+		return err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return errors.New("timed out waiting for migration lock")
+	}
+	defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+
+	return fn(conn)
+}
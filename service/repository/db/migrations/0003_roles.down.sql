@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS admin_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
+ALTER TABLE admins DROP COLUMN active;
@@ -0,0 +1,63 @@
+ALTER TABLE admins
+  ADD COLUMN active TINYINT(1) NOT NULL DEFAULT 1 AFTER password_hash;
+
+CREATE TABLE roles (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(100) NOT NULL,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_roles_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE role_permissions (
+  role_id BIGINT NOT NULL,
+  permission VARCHAR(100) NOT NULL,
+  PRIMARY KEY (role_id, permission),
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE admin_roles (
+  admin_id BIGINT NOT NULL,
+  role_id BIGINT NOT NULL,
+  PRIMARY KEY (admin_id, role_id),
+  FOREIGN KEY (admin_id) REFERENCES admins(id) ON DELETE CASCADE,
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO roles (name, description) VALUES
+  ('head_librarian', 'Full access, including staff management'),
+  ('cataloguer', 'Maintains the book catalog'),
+  ('circulation', 'Circulation desk: members, issues and returns');
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r
+JOIN (
+  SELECT 'catalog:write' AS permission UNION ALL
+  SELECT 'catalog:delete' UNION ALL
+  SELECT 'members:read' UNION ALL
+  SELECT 'members:write' UNION ALL
+  SELECT 'members:delete' UNION ALL
+  SELECT 'circulation:issue' UNION ALL
+  SELECT 'circulation:return' UNION ALL
+  SELECT 'staff:manage'
+) p
+WHERE r.name = 'head_librarian';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, 'catalog:write' FROM roles r WHERE r.name = 'cataloguer';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r
+JOIN (
+  SELECT 'members:read' AS permission UNION ALL
+  SELECT 'members:write' UNION ALL
+  SELECT 'circulation:issue' UNION ALL
+  SELECT 'circulation:return'
+) p
+WHERE r.name = 'circulation';
+
+-- accounts created before roles existed keep full access
+INSERT INTO admin_roles (admin_id, role_id)
+SELECT a.id, r.id FROM admins a JOIN roles r ON r.name = 'head_librarian';
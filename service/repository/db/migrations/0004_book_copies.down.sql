@@ -0,0 +1,13 @@
+ALTER TABLE books
+  ADD COLUMN copies INT DEFAULT 1 AFTER author,
+  ADD COLUMN available INT DEFAULT 1 AFTER copies;
+
+UPDATE books b
+SET b.copies = (SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status NOT IN ('lost', 'withdrawn')),
+    b.available = (SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available');
+
+ALTER TABLE issues
+  DROP FOREIGN KEY fk_issues_copy,
+  DROP COLUMN copy_id;
+
+DROP TABLE IF EXISTS book_copies;
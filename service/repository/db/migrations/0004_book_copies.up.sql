@@ -0,0 +1,52 @@
+CREATE TABLE book_copies (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  book_id BIGINT NOT NULL,
+  barcode VARCHAR(64) NOT NULL,
+  accession_no VARCHAR(64) NULL DEFAULT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'available',
+  item_condition VARCHAR(20) NOT NULL DEFAULT 'good',
+  location VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_book_copies_barcode (barcode),
+  UNIQUE KEY uq_book_copies_accession (accession_no),
+  KEY idx_book_copies_book_status (book_id, status),
+  FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- one physical copy per counted copy, with generated barcodes B<book>-<n>
+SET @max_copies = (SELECT COALESCE(MAX(copies), 0) FROM books);
+SET SESSION cte_max_recursion_depth = GREATEST(1000, @max_copies + 1);
+
+INSERT INTO book_copies (book_id, barcode, status)
+WITH RECURSIVE seq (n) AS (
+  SELECT 1
+  UNION ALL
+  SELECT n + 1 FROM seq WHERE n < @max_copies
+)
+SELECT b.id, CONCAT('B', b.id, '-', seq.n), 'available'
+FROM books b
+JOIN seq ON seq.n <= b.copies;
+
+ALTER TABLE issues
+  ADD COLUMN copy_id BIGINT NULL DEFAULT NULL AFTER book_id,
+  ADD CONSTRAINT fk_issues_copy FOREIGN KEY (copy_id) REFERENCES book_copies(id);
+
+-- hand each open loan its own copy of the book
+UPDATE issues i
+JOIN (
+  SELECT id, book_id, ROW_NUMBER() OVER (PARTITION BY book_id ORDER BY id) AS rn
+  FROM issues
+  WHERE returned_at IS NULL
+) open_loans ON open_loans.id = i.id
+JOIN book_copies c ON c.book_id = open_loans.book_id
+  AND c.barcode = CONCAT('B', open_loans.book_id, '-', open_loans.rn)
+SET i.copy_id = c.id;
+
+UPDATE book_copies c
+JOIN issues i ON i.copy_id = c.id AND i.returned_at IS NULL
+SET c.status = 'on_loan';
+
+ALTER TABLE books
+  DROP COLUMN copies,
+  DROP COLUMN available;
@@ -0,0 +1,2 @@
+UPDATE book_copies SET status = 'available' WHERE status = 'on_hold';
+DROP TABLE IF EXISTS holds;
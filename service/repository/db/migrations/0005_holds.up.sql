@@ -0,0 +1,17 @@
+CREATE TABLE holds (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  book_id BIGINT NOT NULL,
+  member_id BIGINT NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'waiting',
+  copy_id BIGINT NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  ready_at TIMESTAMP NULL DEFAULT NULL,
+  expires_at TIMESTAMP NULL DEFAULT NULL,
+  closed_at TIMESTAMP NULL DEFAULT NULL,
+  KEY idx_holds_queue (book_id, status, id),
+  KEY idx_holds_member (member_id, status),
+  KEY idx_holds_expiry (status, expires_at),
+  FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE,
+  FOREIGN KEY (copy_id) REFERENCES book_copies(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE issues
+  DROP COLUMN last_renewed_at,
+  DROP COLUMN renewal_count;
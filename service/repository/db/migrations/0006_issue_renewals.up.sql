@@ -0,0 +1,3 @@
+ALTER TABLE issues
+  ADD COLUMN renewal_count INT NOT NULL DEFAULT 0 AFTER due_date,
+  ADD COLUMN last_renewed_at TIMESTAMP NULL DEFAULT NULL AFTER renewal_count;
@@ -0,0 +1,7 @@
+DELETE FROM role_permissions WHERE permission = 'policies:manage';
+
+ALTER TABLE members
+  DROP FOREIGN KEY fk_members_category,
+  DROP COLUMN category;
+
+DROP TABLE IF EXISTS loan_policies;
@@ -0,0 +1,26 @@
+CREATE TABLE loan_policies (
+  category VARCHAR(32) PRIMARY KEY,
+  loan_days INT NOT NULL,
+  max_loans INT NOT NULL,
+  max_renewals INT NOT NULL,
+  renew_overdue_max_days INT NOT NULL DEFAULT 0,
+  fine_per_day DECIMAL(10,2) NOT NULL,
+  grace_days INT NOT NULL DEFAULT 0,
+  fine_cap DECIMAL(10,2) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO loan_policies
+  (category, loan_days, max_loans, max_renewals, renew_overdue_max_days, fine_per_day, grace_days, fine_cap)
+VALUES
+  ('student', 14, 5, 2, 0, 10.00, 0, 200.00),
+  ('faculty', 60, 20, 5, 0, 5.00, 3, 500.00),
+  ('staff', 30, 10, 3, 0, 5.00, 2, 300.00),
+  ('guest', 7, 2, 0, 0, 20.00, 0, 100.00);
+
+ALTER TABLE members
+  ADD COLUMN category VARCHAR(32) NOT NULL DEFAULT 'student' AFTER roll_no,
+  ADD CONSTRAINT fk_members_category FOREIGN KEY (category) REFERENCES loan_policies(category) ON UPDATE CASCADE;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'policies:manage' FROM roles WHERE name = 'head_librarian';
@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission IN ('fines:collect', 'fines:waive');
+DROP TABLE IF EXISTS fine_transactions;
+DROP TABLE IF EXISTS fines;
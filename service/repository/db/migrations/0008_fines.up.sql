@@ -0,0 +1,57 @@
+CREATE TABLE fines (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  member_id BIGINT NOT NULL,
+  issue_id BIGINT NULL DEFAULT NULL,
+  kind VARCHAR(20) NOT NULL,
+  amount DECIMAL(10,2) NOT NULL,
+  paid DECIMAL(10,2) NOT NULL DEFAULT 0,
+  waived DECIMAL(10,2) NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'open',
+  note VARCHAR(255) NOT NULL DEFAULT '',
+  created_by BIGINT NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  KEY idx_fines_member_status (member_id, status),
+  FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE,
+  FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE SET NULL,
+  FOREIGN KEY (created_by) REFERENCES admins(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE fine_transactions (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  fine_id BIGINT NOT NULL,
+  member_id BIGINT NOT NULL,
+  kind VARCHAR(20) NOT NULL,
+  amount DECIMAL(10,2) NOT NULL,
+  method VARCHAR(20) NOT NULL DEFAULT '',
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  receipt_no VARCHAR(32) NULL DEFAULT NULL,
+  staff_id BIGINT NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_fine_transactions_receipt (receipt_no),
+  KEY idx_fine_transactions_member (member_id, created_at),
+  FOREIGN KEY (fine_id) REFERENCES fines(id) ON DELETE CASCADE,
+  FOREIGN KEY (staff_id) REFERENCES admins(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- issues.fine_paid used to hold the fine computed at return time; carry those
+-- over as overdue charges settled by a 'legacy' payment so balances stay zero
+-- and fine_paid keeps meaning "money received".
+INSERT INTO fines (member_id, issue_id, kind, amount, paid, status, note, created_at)
+SELECT member_id, id, 'overdue', fine_paid, fine_paid, 'paid', 'migrated from issues.fine_paid', returned_at
+FROM issues
+WHERE fine_paid > 0;
+
+INSERT INTO fine_transactions (fine_id, member_id, kind, amount, method, created_at)
+SELECT id, member_id, 'payment', amount, 'legacy', created_at
+FROM fines
+WHERE note = 'migrated from issues.fine_paid';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission
+FROM roles r
+JOIN (SELECT 'fines:collect' AS permission UNION ALL SELECT 'fines:waive') p
+WHERE r.name = 'head_librarian';
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'fines:collect' FROM roles WHERE name = 'circulation';
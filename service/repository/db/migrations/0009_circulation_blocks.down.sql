@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE permission = 'circulation:override';
+DROP TABLE IF EXISTS circulation_overrides;
+ALTER TABLE members DROP COLUMN expires_at;
+ALTER TABLE loan_policies
+  DROP COLUMN block_on_overdue,
+  DROP COLUMN max_fine_balance;
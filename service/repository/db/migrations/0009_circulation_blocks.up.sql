@@ -0,0 +1,24 @@
+ALTER TABLE loan_policies
+  ADD COLUMN max_fine_balance DECIMAL(10,2) NOT NULL DEFAULT 50.00 AFTER fine_cap,
+  ADD COLUMN block_on_overdue TINYINT(1) NOT NULL DEFAULT 1 AFTER max_fine_balance;
+
+ALTER TABLE members
+  ADD COLUMN expires_at DATE NULL DEFAULT NULL AFTER category;
+
+CREATE TABLE circulation_overrides (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  issue_id BIGINT NULL DEFAULT NULL,
+  member_id BIGINT NOT NULL,
+  book_id BIGINT NOT NULL,
+  staff_id BIGINT NULL DEFAULT NULL,
+  reasons VARCHAR(255) NOT NULL,
+  justification TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_circulation_overrides_member (member_id, created_at),
+  FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE SET NULL,
+  FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE,
+  FOREIGN KEY (staff_id) REFERENCES admins(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'circulation:override' FROM roles WHERE name = 'head_librarian';
@@ -0,0 +1,18 @@
+CREATE TABLE notices (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  issue_id BIGINT NOT NULL,
+  member_id BIGINT NOT NULL,
+  kind VARCHAR(20) NOT NULL,
+  level INT NOT NULL DEFAULT 0,
+  channel VARCHAR(20) NOT NULL,
+  recipient VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  error TEXT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  sent_at DATETIME NULL DEFAULT NULL,
+  UNIQUE KEY uq_notices_issue_kind_level (issue_id, kind, level),
+  KEY idx_notices_member (member_id, created_at),
+  FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE CASCADE,
+  FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,43 @@
+CREATE TABLE webhooks (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  url VARCHAR(1024) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  events VARCHAR(1024) NOT NULL DEFAULT '*',
+  active TINYINT(1) NOT NULL DEFAULT 1,
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE webhook_deliveries (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  webhook_id BIGINT NOT NULL,
+  event_id VARCHAR(64) NOT NULL,
+  event_type VARCHAR(64) NOT NULL,
+  payload JSON NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_status_code INT NULL DEFAULT NULL,
+  last_error TEXT NULL,
+  delivered_at DATETIME NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_webhook_deliveries_due (status, next_attempt_at),
+  KEY idx_webhook_deliveries_webhook (webhook_id, id),
+  FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE webhook_attempts (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  delivery_id BIGINT NOT NULL,
+  status_code INT NULL DEFAULT NULL,
+  error TEXT NULL,
+  response_body TEXT NULL,
+  duration_ms INT NOT NULL DEFAULT 0,
+  attempted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  KEY idx_webhook_attempts_delivery (delivery_id),
+  FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'webhooks:manage' FROM roles WHERE name = 'head_librarian';
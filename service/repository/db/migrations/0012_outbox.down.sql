@@ -0,0 +1,3 @@
+DELETE FROM role_permissions WHERE permission = 'events:read';
+ALTER TABLE webhook_deliveries DROP INDEX uq_webhook_deliveries_event;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,21 @@
+CREATE TABLE outbox (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  event_id VARCHAR(64) NOT NULL,
+  event_type VARCHAR(64) NOT NULL,
+  aggregate_type VARCHAR(32) NOT NULL,
+  aggregate_id BIGINT NOT NULL,
+  payload JSON NOT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  dispatched_at DATETIME(6) NULL DEFAULT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NULL,
+  UNIQUE KEY uq_outbox_event (event_id),
+  KEY idx_outbox_pending (dispatched_at, id),
+  KEY idx_outbox_aggregate (aggregate_type, aggregate_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+ALTER TABLE webhook_deliveries
+  ADD UNIQUE KEY uq_webhook_deliveries_event (webhook_id, event_id);
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'events:read' FROM roles WHERE name = 'head_librarian';
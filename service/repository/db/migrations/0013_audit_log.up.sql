@@ -0,0 +1,24 @@
+CREATE TABLE audit_log (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  occurred_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  actor_id BIGINT NULL DEFAULT NULL,
+  actor VARCHAR(100) NOT NULL DEFAULT '',
+  action VARCHAR(64) NOT NULL,
+  entity_type VARCHAR(32) NOT NULL,
+  entity_id VARCHAR(64) NOT NULL DEFAULT '',
+  before_json JSON NULL,
+  after_json JSON NULL,
+  diff JSON NULL,
+  method VARCHAR(10) NOT NULL,
+  path VARCHAR(255) NOT NULL,
+  status INT NOT NULL,
+  request_id VARCHAR(64) NOT NULL DEFAULT '',
+  ip VARCHAR(45) NOT NULL DEFAULT '',
+  KEY idx_audit_log_time (occurred_at),
+  KEY idx_audit_log_actor (actor_id, id),
+  KEY idx_audit_log_entity (entity_type, entity_id, id),
+  KEY idx_audit_log_request (request_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO role_permissions (role_id, permission)
+SELECT id, 'audit:read' FROM roles WHERE name = 'head_librarian';
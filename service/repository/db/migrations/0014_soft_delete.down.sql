@@ -0,0 +1,33 @@
+ALTER TABLE notices
+  DROP FOREIGN KEY fk_notices_issue,
+  DROP FOREIGN KEY fk_notices_member;
+ALTER TABLE notices
+  ADD CONSTRAINT notices_ibfk_1 FOREIGN KEY (issue_id) REFERENCES issues(id) ON DELETE CASCADE,
+  ADD CONSTRAINT notices_ibfk_2 FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE;
+
+ALTER TABLE circulation_overrides DROP FOREIGN KEY fk_circulation_overrides_member;
+ALTER TABLE circulation_overrides
+  ADD CONSTRAINT circulation_overrides_ibfk_2 FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE;
+
+ALTER TABLE fines DROP FOREIGN KEY fk_fines_member;
+ALTER TABLE fines
+  ADD CONSTRAINT fines_ibfk_1 FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE;
+
+ALTER TABLE issues
+  DROP FOREIGN KEY fk_issues_book,
+  DROP FOREIGN KEY fk_issues_member;
+ALTER TABLE issues
+  ADD CONSTRAINT issues_ibfk_1 FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  ADD CONSTRAINT issues_ibfk_2 FOREIGN KEY (member_id) REFERENCES members(id) ON DELETE CASCADE;
+
+-- soft deleted rows become hard deletes again
+DELETE FROM members WHERE deleted_at IS NOT NULL;
+DELETE FROM books WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE members
+  DROP KEY idx_members_deleted,
+  DROP COLUMN anonymized_at,
+  DROP COLUMN deleted_at;
+ALTER TABLE books
+  DROP KEY idx_books_deleted,
+  DROP COLUMN deleted_at;
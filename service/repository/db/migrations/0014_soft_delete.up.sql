@@ -0,0 +1,32 @@
+ALTER TABLE books
+  ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+  ADD KEY idx_books_deleted (deleted_at);
+
+ALTER TABLE members
+  ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+  ADD COLUMN anonymized_at DATETIME NULL DEFAULT NULL,
+  ADD KEY idx_members_deleted (deleted_at);
+
+-- circulation and fine history must outlive the book or member; rows are
+-- soft deleted now and the constraints refuse a hard delete that would
+-- orphan history
+ALTER TABLE issues
+  DROP FOREIGN KEY issues_ibfk_1,
+  DROP FOREIGN KEY issues_ibfk_2;
+ALTER TABLE issues
+  ADD CONSTRAINT fk_issues_book FOREIGN KEY (book_id) REFERENCES books(id),
+  ADD CONSTRAINT fk_issues_member FOREIGN KEY (member_id) REFERENCES members(id);
+
+ALTER TABLE fines DROP FOREIGN KEY fines_ibfk_1;
+ALTER TABLE fines ADD CONSTRAINT fk_fines_member FOREIGN KEY (member_id) REFERENCES members(id);
+
+ALTER TABLE circulation_overrides DROP FOREIGN KEY circulation_overrides_ibfk_2;
+ALTER TABLE circulation_overrides
+  ADD CONSTRAINT fk_circulation_overrides_member FOREIGN KEY (member_id) REFERENCES members(id);
+
+ALTER TABLE notices
+  DROP FOREIGN KEY notices_ibfk_1,
+  DROP FOREIGN KEY notices_ibfk_2;
+ALTER TABLE notices
+  ADD CONSTRAINT fk_notices_issue FOREIGN KEY (issue_id) REFERENCES issues(id),
+  ADD CONSTRAINT fk_notices_member FOREIGN KEY (member_id) REFERENCES members(id);
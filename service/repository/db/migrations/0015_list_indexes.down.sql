@@ -0,0 +1,12 @@
+ALTER TABLE issues
+  DROP KEY idx_issues_issued,
+  DROP KEY idx_issues_due;
+
+ALTER TABLE members
+  DROP KEY idx_members_name,
+  DROP KEY idx_members_created;
+
+ALTER TABLE books
+  DROP KEY idx_books_title,
+  DROP KEY idx_books_author,
+  DROP KEY idx_books_created;
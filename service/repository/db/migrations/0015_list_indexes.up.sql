@@ -0,0 +1,14 @@
+-- keyset pagination walks these in order; deleted_at leads because every
+-- list filters on deleted_at IS NULL
+ALTER TABLE books
+  ADD KEY idx_books_title (deleted_at, title),
+  ADD KEY idx_books_author (deleted_at, author),
+  ADD KEY idx_books_created (deleted_at, created_at);
+
+ALTER TABLE members
+  ADD KEY idx_members_name (deleted_at, name),
+  ADD KEY idx_members_created (deleted_at, created_at);
+
+ALTER TABLE issues
+  ADD KEY idx_issues_issued (issued_at),
+  ADD KEY idx_issues_due (due_date);
@@ -0,0 +1,4 @@
+ALTER TABLE books
+  DROP KEY ft_books_title_author,
+  DROP KEY ft_books_title,
+  DROP KEY ft_books_author;
@@ -0,0 +1,5 @@
+-- InnoDB builds one FULLTEXT index per statement. MATCH() needs an index
+-- over exactly its columns, hence one for both fields and one for each.
+ALTER TABLE books ADD FULLTEXT KEY ft_books_title_author (title, author);
+ALTER TABLE books ADD FULLTEXT KEY ft_books_title (title);
+ALTER TABLE books ADD FULLTEXT KEY ft_books_author (author);
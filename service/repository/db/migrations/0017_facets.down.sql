@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS book_subjects;
+
+ALTER TABLE books
+  DROP KEY idx_books_language,
+  DROP KEY idx_books_format,
+  DROP KEY idx_books_year,
+  DROP COLUMN language,
+  DROP COLUMN format,
+  DROP COLUMN publication_year;
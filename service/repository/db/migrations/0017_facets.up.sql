@@ -0,0 +1,15 @@
+ALTER TABLE books
+  ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT '',
+  ADD COLUMN format VARCHAR(16) NOT NULL DEFAULT 'print',
+  ADD COLUMN publication_year SMALLINT NULL DEFAULT NULL,
+  ADD KEY idx_books_language (deleted_at, language),
+  ADD KEY idx_books_format (deleted_at, format),
+  ADD KEY idx_books_year (deleted_at, publication_year);
+
+CREATE TABLE IF NOT EXISTS book_subjects (
+  book_id BIGINT NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  PRIMARY KEY (book_id, subject),
+  KEY idx_book_subjects_subject (subject),
+  FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
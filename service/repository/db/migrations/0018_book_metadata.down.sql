@@ -0,0 +1,12 @@
+ALTER TABLE books DROP KEY ft_books_text;
+ALTER TABLE books ADD FULLTEXT KEY ft_books_title_author (title, author);
+
+ALTER TABLE books
+  DROP KEY uq_books_isbn,
+  DROP KEY idx_books_call_number,
+  DROP COLUMN isbn,
+  DROP COLUMN publisher,
+  DROP COLUMN edition,
+  DROP COLUMN page_count,
+  DROP COLUMN description,
+  DROP COLUMN call_number;
@@ -0,0 +1,14 @@
+-- isbn holds the normalized ISBN-13; NULL for items without one
+ALTER TABLE books
+  ADD COLUMN isbn CHAR(13) NULL DEFAULT NULL,
+  ADD COLUMN publisher VARCHAR(255) NOT NULL DEFAULT '',
+  ADD COLUMN edition VARCHAR(64) NOT NULL DEFAULT '',
+  ADD COLUMN page_count INT NULL DEFAULT NULL,
+  ADD COLUMN description TEXT NULL,
+  ADD COLUMN call_number VARCHAR(64) NOT NULL DEFAULT '',
+  ADD UNIQUE KEY uq_books_isbn (isbn),
+  ADD KEY idx_books_call_number (call_number);
+
+-- general search now covers the description as well
+ALTER TABLE books DROP KEY ft_books_title_author;
+ALTER TABLE books ADD FULLTEXT KEY ft_books_text (title, author, description);
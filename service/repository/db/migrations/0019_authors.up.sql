@@ -0,0 +1,43 @@
+-- name_key is the name lowercased with only letters and digits kept, so
+-- "J.R.R. Tolkien" and "J. R. R. Tolkien" are one author. The keys made
+-- here strip only common punctuation; the server rekeys authors in Go
+-- after migrating (handler.RekeyAuthors)
+CREATE TABLE IF NOT EXISTS authors (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  name_key VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_authors_name_key (name_key),
+  KEY idx_authors_name (name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS book_contributors (
+  book_id BIGINT NOT NULL,
+  author_id BIGINT NOT NULL,
+  role VARCHAR(16) NOT NULL,
+  position INT NOT NULL DEFAULT 0,
+  PRIMARY KEY (book_id, author_id, role),
+  KEY idx_book_contributors_author (author_id, role),
+  FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+  FOREIGN KEY (author_id) REFERENCES authors(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- every existing books.author becomes one author; strings naming several
+-- people are split by editing the book's contributors afterwards
+INSERT IGNORE INTO authors (name, name_key)
+SELECT MIN(TRIM(author)), k
+FROM (
+  SELECT author,
+    LOWER(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(author),
+      ' ', ''), '.', ''), ',', ''), '-', ''), '''', ''), '(', ''), ')', ''), ';', '')) AS k
+  FROM books
+) names
+WHERE k <> ''
+GROUP BY k;
+
+INSERT IGNORE INTO book_contributors (book_id, author_id, role, position)
+SELECT b.id, a.id, 'author', 0
+FROM books b
+JOIN authors a ON a.name_key = LOWER(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(TRIM(b.author),
+  ' ', ''), '.', ''), ',', ''), '-', ''), '''', ''), '(', ''), ')', ''), ';', ''));
@@ -0,0 +1,2 @@
+DROP INDEX idx_members_roll_no ON members;
+DROP TABLE IF EXISTS import_jobs;
@@ -0,0 +1,23 @@
+CREATE TABLE import_jobs (
+  id BIGINT AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(16) NOT NULL,
+  format VARCHAR(8) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'running',
+  dry_run BOOLEAN NOT NULL DEFAULT FALSE,
+  bytes_total BIGINT NOT NULL DEFAULT 0,
+  bytes_read BIGINT NOT NULL DEFAULT 0,
+  processed INT NOT NULL DEFAULT 0,
+  created INT NOT NULL DEFAULT 0,
+  updated INT NOT NULL DEFAULT 0,
+  skipped INT NOT NULL DEFAULT 0,
+  ignored_columns JSON NULL,
+  row_errors JSON NULL,
+  error VARCHAR(1000) NOT NULL DEFAULT '',
+  created_by BIGINT NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  finished_at TIMESTAMP NULL DEFAULT NULL,
+  KEY idx_import_jobs_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE INDEX idx_members_roll_no ON members (roll_no);
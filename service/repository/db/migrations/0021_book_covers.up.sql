@@ -0,0 +1,10 @@
+CREATE TABLE book_covers (
+  book_id BIGINT PRIMARY KEY,
+  etag VARCHAR(64) NOT NULL,
+  content_type VARCHAR(32) NOT NULL,
+  width INT NOT NULL,
+  height INT NOT NULL,
+  bytes INT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
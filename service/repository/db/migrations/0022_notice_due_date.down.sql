@@ -0,0 +1,10 @@
+-- keep the latest notice of each kind and level per loan
+DELETE n FROM notices n
+JOIN notices newer ON newer.issue_id = n.issue_id
+  AND newer.kind = n.kind
+  AND newer.level = n.level
+  AND newer.id > n.id;
+
+ALTER TABLE notices ADD UNIQUE KEY uq_notices_issue_kind_level (issue_id, kind, level);
+ALTER TABLE notices DROP KEY uq_notices_issue_due_kind_level;
+ALTER TABLE notices DROP COLUMN due_date;
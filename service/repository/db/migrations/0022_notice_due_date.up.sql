@@ -0,0 +1,13 @@
+-- a renewed loan has a new due date and needs its reminders again, so a
+-- notice is unique per due date rather than per loan
+ALTER TABLE notices ADD COLUMN due_date DATE NULL AFTER member_id;
+
+UPDATE notices n
+JOIN issues i ON i.id = n.issue_id
+SET n.due_date = COALESCE(i.due_date, DATE(n.created_at));
+
+ALTER TABLE notices MODIFY due_date DATE NOT NULL;
+
+-- the new key is added first: the issue_id foreign key needs an index
+ALTER TABLE notices ADD UNIQUE KEY uq_notices_issue_due_kind_level (issue_id, due_date, kind, level);
+ALTER TABLE notices DROP KEY uq_notices_issue_kind_level;
@@ -0,0 +1,6 @@
+-- dead events become pending again
+ALTER TABLE outbox
+  ADD KEY idx_outbox_pending (dispatched_at, id),
+  DROP KEY idx_outbox_status,
+  DROP COLUMN next_attempt_at,
+  DROP COLUMN status;
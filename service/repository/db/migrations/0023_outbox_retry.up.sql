@@ -0,0 +1,11 @@
+-- a failing event is retried at next_attempt_at and set aside as dead once
+-- it has failed too often, so one bad event can't hold the queue forever
+ALTER TABLE outbox
+  ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'pending' AFTER payload,
+  ADD COLUMN next_attempt_at DATETIME(6) NULL DEFAULT NULL AFTER attempts;
+
+UPDATE outbox SET status = 'dispatched' WHERE dispatched_at IS NOT NULL;
+
+ALTER TABLE outbox
+  ADD KEY idx_outbox_status (status, id),
+  DROP KEY idx_outbox_pending;
@@ -0,0 +1,2 @@
+ALTER TABLE webhook_deliveries
+  DROP KEY idx_webhook_deliveries_event;
@@ -0,0 +1,4 @@
+-- the purge finds the deliveries of a member's events by event_id to
+-- redact them
+ALTER TABLE webhook_deliveries
+  ADD KEY idx_webhook_deliveries_event (event_id);
@@ -0,0 +1,3 @@
+ALTER TABLE issues
+  DROP KEY idx_issues_due_sort,
+  DROP COLUMN due_sort;
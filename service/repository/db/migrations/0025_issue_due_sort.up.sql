@@ -0,0 +1,5 @@
+-- loans without a due date sort last; keeping the stand-in date in a stored
+-- column lets the due date sort and its keyset conditions use an index
+ALTER TABLE issues
+  ADD COLUMN due_sort DATE AS (COALESCE(due_date, '9999-12-31')) STORED,
+  ADD KEY idx_issues_due_sort (due_sort, id);
@@ -1,61 +1,808 @@
 package db
 
+// bookColumns derives copies and available from book_copies instead of
+// storing counters on books.
+const bookColumns = `b.id, b.title, b.author, b.isbn, b.publisher, b.publication_year, b.edition,
+	b.language, b.format, b.page_count, COALESCE(b.description, '') AS description, b.call_number,
+	(SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status NOT IN ('lost', 'withdrawn')) AS copies,
+	(SELECT COUNT(*) FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available') AS available,
+	b.created_at, b.updated_at, b.deleted_at`
+
+// The list queries end inside their WHERE clause so the repository can
+// append the keyset condition, ORDER BY and LIMIT for the requested sort.
+const bookListFilter = `b.deleted_at IS NULL
+	AND (? = '' OR EXISTS (SELECT 1 FROM book_contributors bc JOIN authors a ON a.id = bc.author_id
+		WHERE bc.book_id = b.id AND a.name = ?))
+	AND (? IS NULL OR b.created_at >= ?)
+	AND (? IS NULL OR b.created_at < ?)
+	AND (? IS NULL OR EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available') = ?)`
+
+const memberListFilter = `deleted_at IS NULL
+	AND (? = '' OR category = ?)
+	AND (? IS NULL OR created_at >= ?)
+	AND (? IS NULL OR created_at < ?)`
+
+const issueListFilter = `(? = 0 OR member_id = ?)
+	AND (? = 0 OR book_id = ?)
+	AND (? <> 'active' OR returned_at IS NULL)
+	AND (? <> 'returned' OR returned_at IS NOT NULL)
+	AND (? <> 'overdue' OR (returned_at IS NULL AND due_date < ?))`
+
+const memberColumns = `id, name, email, roll_no, category, expires_at, created_at, updated_at, deleted_at, anonymized_at`
+
 const (
-	QCreateBook = `INSERT INTO books (title, author, copies, available)
-	VALUES (?, ?, ?, ?)`
-	QGetBookByID = `SELECT id, title, author, copies, available, created_at, updated_at
-	FROM books
-	WHERE id = ?
+	QCreateBook = `INSERT INTO books (title, author, isbn, publisher, publication_year, edition,
+	language, format, page_count, description, call_number)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	QGetBookByID = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE b.id = ?
+	AND b.deleted_at IS NULL
 	LIMIT 1`
-	QGetAllBooks = `SELECT id, title, author, copies, available, created_at, updated_at
-	FROM books
-	ORDER BY id DESC`
-	QSearchBooks = `SELECT id, title, author, copies, available, created_at, updated_at
-	FROM books
-	WHERE title LIKE ? OR author LIKE ?
-	ORDER BY id DESC`
+	QGetBookByIDWithDeleted = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE b.id = ?
+	LIMIT 1`
+	QGetBookByIDForUpdate = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE b.id = ?
+	AND b.deleted_at IS NULL
+	LIMIT 1
+	FOR UPDATE`
+	QListBooks = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE ` + bookListFilter
+	QCountBooks = `SELECT COUNT(*)
+	FROM books b
+	WHERE ` + bookListFilter
+	QGetCatalogEntries = `SELECT b.id, b.title, COALESCE(GROUP_CONCAT(a.name ORDER BY bc.position SEPARATOR '\n'), '') AS authors
+	FROM books b
+	LEFT JOIN book_contributors bc ON bc.book_id = b.id AND bc.role = 'author'
+	LEFT JOIN authors a ON a.id = bc.author_id
+	WHERE b.deleted_at IS NULL
+	GROUP BY b.id, b.title`
+	QGetBookByISBN = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE b.isbn = ?
+	LIMIT 1`
+	QGetDeletedBooks = `SELECT ` + bookColumns + `
+	FROM books b
+	WHERE b.deleted_at IS NOT NULL
+	ORDER BY b.deleted_at DESC`
+	// QSearchBooks ends inside its WHERE clause; the repository adds a
+	// condition per search term, then the ordering by relevance.
+	QSearchBooks = `SELECT ` + bookColumns + `,
+	MATCH(b.title, b.author, b.description) AGAINST (? IN BOOLEAN MODE) + MATCH(b.title) AGAINST (? IN BOOLEAN MODE) AS score
+	FROM books b
+	WHERE b.deleted_at IS NULL`
+	// The facet queries count the books a search matched per value; the
+	// repository puts the search conditions in place of %s.
+	QFacetAuthors = `SELECT a.name AS value, COUNT(*) AS count
+	FROM books b
+	JOIN book_contributors bc ON bc.book_id = b.id AND bc.role = 'author'
+	JOIN authors a ON a.id = bc.author_id
+	WHERE b.deleted_at IS NULL%s
+	GROUP BY a.name
+	ORDER BY count DESC, value
+	LIMIT ?`
+	QFacetSubjects = `SELECT s.subject AS value, COUNT(*) AS count
+	FROM books b
+	JOIN book_subjects s ON s.book_id = b.id
+	WHERE b.deleted_at IS NULL%s
+	GROUP BY s.subject
+	ORDER BY count DESC, value
+	LIMIT ?`
+	QFacetYears = `SELECT CONCAT(FLOOR(b.publication_year / 10) * 10, '-', FLOOR(b.publication_year / 10) * 10 + 9) AS value,
+	COUNT(*) AS count
+	FROM books b
+	WHERE b.deleted_at IS NULL
+	AND b.publication_year IS NOT NULL%s
+	GROUP BY value
+	ORDER BY value DESC
+	LIMIT ?`
+	QFacetLanguages = `SELECT b.language AS value, COUNT(*) AS count
+	FROM books b
+	WHERE b.deleted_at IS NULL
+	AND b.language <> ''%s
+	GROUP BY b.language
+	ORDER BY count DESC, value
+	LIMIT ?`
+	QFacetFormats = `SELECT b.format AS value, COUNT(*) AS count
+	FROM books b
+	WHERE b.deleted_at IS NULL%s
+	GROUP BY b.format
+	ORDER BY count DESC, value
+	LIMIT ?`
+	QFacetAvailability = `SELECT IF(EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available'),
+	'available', 'unavailable') AS value,
+	COUNT(*) AS count
+	FROM books b
+	WHERE b.deleted_at IS NULL%s
+	GROUP BY value
+	ORDER BY value
+	LIMIT ?`
 	QUpdateBook = `UPDATE books
-	SET title = ?, author = ?, copies = ?, available = ?
+	SET title = ?, author = ?, isbn = ?, publisher = ?, publication_year = ?, edition = ?,
+	language = ?, format = ?, page_count = ?, description = ?, call_number = ?
 	WHERE id = ?`
-	QDeleteBook = `DELETE FROM books
+	QGetBookSubjects = `SELECT subject
+	FROM book_subjects
+	WHERE book_id = ?
+	ORDER BY subject`
+	QDeleteBookSubjects = `DELETE FROM book_subjects
+	WHERE book_id = ?`
+	QAddBookSubject = `INSERT IGNORE INTO book_subjects (book_id, subject)
+	VALUES (?, ?)`
+	QGetBookContributors = `SELECT bc.author_id, a.name, bc.role
+	FROM book_contributors bc
+	JOIN authors a ON a.id = bc.author_id
+	WHERE bc.book_id = ?
+	ORDER BY bc.position, bc.role`
+	QDeleteBookContributors = `DELETE FROM book_contributors
+	WHERE book_id = ?`
+	QAddBookContributor = `INSERT IGNORE INTO book_contributors (book_id, author_id, role, position)
+	VALUES (?, ?, ?, ?)`
+	QSetBookAuthor = `UPDATE books
+	SET author = ?
 	WHERE id = ?`
-	QChangeAvailability = `UPDATE books
-	SET available = available + ?
+	QSoftDeleteBook = `UPDATE books
+	SET deleted_at = ?
+	WHERE id = ?
+	AND deleted_at IS NULL`
+	QRestoreBook = `UPDATE books
+	SET deleted_at = NULL
 	WHERE id = ?
-	AND available + ? >= 0`
+	AND deleted_at IS NOT NULL`
+	QGetPurgeableBooks = `SELECT b.id
+	FROM books b
+	WHERE b.deleted_at < ?
+	AND NOT EXISTS (SELECT 1 FROM issues i WHERE i.book_id = b.id)
+	AND NOT EXISTS (SELECT 1 FROM holds h WHERE h.book_id = b.id)`
+	QPurgeBook = `DELETE FROM books
+	WHERE id = ?
+	AND deleted_at IS NOT NULL`
 	QCreateMember = `
-	INSERT INTO members (name, email, roll_no)
-	VALUES (?, ?, ?)`
-	QGetMemberByID = `SELECT id, name, email, roll_no, created_at, updated_at
+	INSERT INTO members (name, email, roll_no, category, expires_at)
+	VALUES (?, ?, ?, ?, ?)`
+	QGetMemberByID = `SELECT ` + memberColumns + `
 	FROM members
 	WHERE id = ?
+	AND deleted_at IS NULL
 	LIMIT 1`
-	QGetAllMembers = `SELECT id, name, email, roll_no, created_at, updated_at
+	QGetMemberByIDWithDeleted = `SELECT ` + memberColumns + `
 	FROM members
-	ORDER BY id DESC`
+	WHERE id = ?
+	LIMIT 1`
+	QGetMembersByRollNo = `SELECT ` + memberColumns + `
+	FROM members
+	WHERE roll_no = ?
+	AND anonymized_at IS NULL
+	ORDER BY id`
+	QListMembers = `SELECT ` + memberColumns + `
+	FROM members
+	WHERE ` + memberListFilter
+	QCountMembers = `SELECT COUNT(*)
+	FROM members
+	WHERE ` + memberListFilter
+	QGetDeletedMembers = `SELECT ` + memberColumns + `
+	FROM members
+	WHERE deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC`
 	QUpdateMember = `UPDATE members
-	SET name = ?, email = ?, roll_no = ?
-	WHERE id = ?`
-	QDeleteMember = `DELETE FROM members
+	SET name = ?, email = ?, roll_no = ?, category = ?, expires_at = ?
 	WHERE id = ?`
-	QCreateIssue = `INSERT INTO issues (book_id, member_id, due_date)
-	VALUES (?, ?, ?)`
-	QGetActiveIssueByBookAndMember = `SELECT id, book_id, member_id, issued_at, due_date, returned_at, fine_paid
+	QSoftDeleteMember = `UPDATE members
+	SET deleted_at = ?
+	WHERE id = ?
+	AND deleted_at IS NULL`
+	QRestoreMember = `UPDATE members
+	SET deleted_at = NULL
+	WHERE id = ?
+	AND deleted_at IS NOT NULL
+	AND anonymized_at IS NULL`
+	QGetMembersToAnonymize = `SELECT id
+	FROM members
+	WHERE deleted_at < ?
+	AND anonymized_at IS NULL`
+	QAnonymizeMember = `UPDATE members
+	SET name = 'Deleted member', email = '', roll_no = CONCAT('deleted-', id), expires_at = NULL, anonymized_at = ?
+	WHERE id = ?
+	AND deleted_at IS NOT NULL
+	AND anonymized_at IS NULL`
+	QRedactMemberAudit = `UPDATE audit_log
+	SET before_json = JSON_REPLACE(before_json, '$.name', 'Deleted member', '$.email', '', '$.roll_no', CONCAT('deleted-', entity_id)),
+	after_json = JSON_REPLACE(after_json, '$.name', 'Deleted member', '$.email', '', '$.roll_no', CONCAT('deleted-', entity_id)),
+	diff = JSON_REPLACE(diff, '$.name', 'redacted', '$.email', 'redacted', '$.roll_no', 'redacted')
+	WHERE entity_type = 'member'
+	AND entity_id = ?`
+	QRedactMemberEvents = `UPDATE outbox
+	SET payload = JSON_REPLACE(payload, '$.name', 'Deleted member', '$.email', '', '$.roll_no', CONCAT('deleted-', aggregate_id))
+	WHERE aggregate_type = 'member'
+	AND aggregate_id = ?`
+	QRedactMemberDeliveries = `UPDATE webhook_deliveries d
+	JOIN outbox o ON o.event_id = d.event_id
+	SET d.payload = JSON_REPLACE(d.payload, '$.data.name', 'Deleted member', '$.data.email', '', '$.data.roll_no', CONCAT('deleted-', o.aggregate_id))
+	WHERE o.aggregate_type = 'member'
+	AND o.aggregate_id = ?`
+	QRedactMemberNotices = `UPDATE notices
+	SET recipient = ''
+	WHERE member_id = ?`
+	QCountActiveIssuesByBook = `SELECT COUNT(*)
+	FROM issues
+	WHERE book_id = ?
+	AND returned_at IS NULL`
+	QCreateIssue = `INSERT INTO issues (book_id, copy_id, member_id, due_date)
+	VALUES (?, ?, ?, ?)`
+	QGetActiveIssueByBookAndMember = `SELECT ` + issueColumns + `
 	FROM issues
 	WHERE book_id = ?
 	AND member_id = ?
 	AND returned_at IS NULL
 	LIMIT 1`
-	QGetIssuesByMember = `SELECT id, book_id, member_id, issued_at, due_date, returned_at, fine_paid
+	QCountActiveIssuesByMember = `SELECT COUNT(*)
 	FROM issues
 	WHERE member_id = ?
-	ORDER BY issued_at DESC`
-	QGetIssueByID = `SELECT id, book_id, member_id, issued_at, due_date, returned_at, fine_paid
+	AND returned_at IS NULL`
+	QCountOverdueIssuesByMember = `SELECT COUNT(*)
+	FROM issues
+	WHERE member_id = ?
+	AND returned_at IS NULL
+	AND due_date < ?`
+	QListIssues = `SELECT ` + issueColumns + `
+	FROM issues
+	WHERE ` + issueListFilter
+	QCountIssues = `SELECT COUNT(*)
+	FROM issues
+	WHERE ` + issueListFilter
+	QGetIssueByID = `SELECT ` + issueColumns + `
 	FROM issues
 	WHERE id = ?
 	LIMIT 1`
+	QGetIssueByIDForUpdate = `SELECT ` + issueColumns + `
+	FROM issues
+	WHERE id = ?
+	LIMIT 1
+	FOR UPDATE`
+	QGetActiveIssueByCopy = `SELECT ` + issueColumns + `
+	FROM issues
+	WHERE copy_id = ?
+	AND returned_at IS NULL
+	LIMIT 1
+	FOR UPDATE`
+	QRenewIssue = `UPDATE issues
+	SET due_date = ?, renewal_count = renewal_count + 1, last_renewed_at = ?
+	WHERE id = ?
+	AND returned_at IS NULL`
 	QReturnIssue = `UPDATE issues
-	SET returned_at = ?, fine_paid = ?
+	SET returned_at = ?
 	WHERE id = ?
 	AND returned_at IS NULL`
+	QAddIssueFinePaid = `UPDATE issues
+	SET fine_paid = fine_paid + ?
+	WHERE id = ?`
+)
+
+const (
+	QCreateAdmin = `INSERT INTO admins (username, password_hash)
+	VALUES (?, ?)`
+	QGetAdminByID = `SELECT id, username, password_hash, active, created_at, updated_at
+	FROM admins
+	WHERE id = ?
+	LIMIT 1`
+	QGetAdminByUsername = `SELECT id, username, password_hash, active, created_at, updated_at
+	FROM admins
+	WHERE username = ?
+	LIMIT 1`
+	QGetAllAdmins = `SELECT id, username, password_hash, active, created_at, updated_at
+	FROM admins
+	ORDER BY id`
+	QSetAdminActive = `UPDATE admins
+	SET active = ?
+	WHERE id = ?`
+	QUpdateAdminPassword = `UPDATE admins
+	SET password_hash = ?
+	WHERE id = ?`
+	QCreateSession = `INSERT INTO admin_sessions (admin_id, token_hash, expires_at)
+	VALUES (?, ?, ?)`
+	QGetActiveSessionByToken = `SELECT id, admin_id, token_hash, expires_at, revoked_at, created_at
+	FROM admin_sessions
+	WHERE token_hash = ?
+	AND revoked_at IS NULL
+	AND expires_at > ?
+	LIMIT 1`
+	QRevokeSession = `UPDATE admin_sessions
+	SET revoked_at = ?
+	WHERE token_hash = ?
+	AND revoked_at IS NULL`
+	QRevokeSessionsByAdmin = `UPDATE admin_sessions
+	SET revoked_at = ?
+	WHERE admin_id = ?
+	AND revoked_at IS NULL`
+)
+
+const (
+	QGetAdminRoleNames = `SELECT r.name
+	FROM admin_roles ar
+	JOIN roles r ON r.id = ar.role_id
+	WHERE ar.admin_id = ?
+	ORDER BY r.name`
+	QGetAdminPermissions = `SELECT DISTINCT rp.permission
+	FROM admin_roles ar
+	JOIN role_permissions rp ON rp.role_id = ar.role_id
+	WHERE ar.admin_id = ?
+	ORDER BY rp.permission`
+	QCountActiveAdminsWithPermissionForUpdate = `SELECT COUNT(DISTINCT a.id)
+	FROM admins a
+	JOIN admin_roles ar ON ar.admin_id = a.id
+	JOIN role_permissions rp ON rp.role_id = ar.role_id
+	WHERE a.active = 1 AND rp.permission = ?
+	FOR UPDATE`
+	QClearAdminRoles = `DELETE FROM admin_roles
+	WHERE admin_id = ?`
+	QAddAdminRole = `INSERT INTO admin_roles (admin_id, role_id)
+	VALUES (?, ?)`
+	QCreateRole = `INSERT INTO roles (name, description)
+	VALUES (?, ?)`
+	QGetRoleByID = `SELECT id, name, description, created_at
+	FROM roles
+	WHERE id = ?
+	LIMIT 1`
+	QGetRoleByName = `SELECT id, name, description, created_at
+	FROM roles
+	WHERE name = ?
+	LIMIT 1`
+	QGetAllRoles = `SELECT id, name, description, created_at
+	FROM roles
+	ORDER BY name`
+	QUpdateRole = `UPDATE roles
+	SET name = ?, description = ?
+	WHERE id = ?`
+	QDeleteRole = `DELETE FROM roles
+	WHERE id = ?`
+	QGetRolePermissions = `SELECT permission
+	FROM role_permissions
+	WHERE role_id = ?
+	ORDER BY permission`
+	QClearRolePermissions = `DELETE FROM role_permissions
+	WHERE role_id = ?`
+	QAddRolePermission = `INSERT INTO role_permissions (role_id, permission)
+	VALUES (?, ?)`
+)
+
+const authorColumns = `a.id, a.name,
+	(SELECT COUNT(DISTINCT bc.book_id) FROM book_contributors bc JOIN books b ON b.id = bc.book_id
+		WHERE bc.author_id = a.id AND b.deleted_at IS NULL) AS books,
+	a.created_at, a.updated_at`
+
+const (
+	QCreateAuthor = `INSERT INTO authors (name, name_key)
+	VALUES (?, ?)`
+	QGetAuthorByID = `SELECT ` + authorColumns + `
+	FROM authors a
+	WHERE a.id = ?
+	LIMIT 1`
+	QGetAuthorByIDForUpdate = `SELECT ` + authorColumns + `
+	FROM authors a
+	WHERE a.id = ?
+	LIMIT 1
+	FOR UPDATE`
+	QGetAuthorByKey = `SELECT ` + authorColumns + `
+	FROM authors a
+	WHERE a.name_key = ?
+	LIMIT 1`
+	QGetAllAuthors = `SELECT ` + authorColumns + `
+	FROM authors a
+	ORDER BY a.id`
+	QGetAuthorKeys = `SELECT id, name, name_key
+	FROM authors
+	ORDER BY id`
+	// QListAuthors ends inside its WHERE clause like the other lists.
+	QListAuthors = `SELECT ` + authorColumns + `
+	FROM authors a
+	WHERE (? = '' OR a.name LIKE ? ESCAPE '!')`
+	QCountAuthors = `SELECT COUNT(*)
+	FROM authors a
+	WHERE (? = '' OR a.name LIKE ? ESCAPE '!')`
+	QUpdateAuthor = `UPDATE authors
+	SET name = ?, name_key = ?
+	WHERE id = ?`
+	QDeleteAuthor = `DELETE FROM authors
+	WHERE id = ?`
+	QGetAuthorWorks = `SELECT b.id AS book_id, b.title, b.publication_year, bc.role
+	FROM book_contributors bc
+	JOIN books b ON b.id = bc.book_id
+	WHERE bc.author_id = ?
+	AND b.deleted_at IS NULL
+	ORDER BY b.publication_year IS NULL, b.publication_year, b.title, bc.role`
+	QGetAuthorBookIDs = `SELECT DISTINCT book_id
+	FROM book_contributors
+	WHERE author_id = ?
+	ORDER BY book_id`
+	// QMoveContributions credits another author with every role of one;
+	// roles the target already has on a book are left to the delete.
+	QMoveContributions = `INSERT IGNORE INTO book_contributors (book_id, author_id, role, position)
+	SELECT book_id, ?, role, position
+	FROM book_contributors
+	WHERE author_id = ?`
+	QDeleteAuthorContributions = `DELETE FROM book_contributors
+	WHERE author_id = ?`
+)
+
+const (
+	QCreateCopy = `INSERT INTO book_copies (book_id, barcode, accession_no, status, item_condition, location)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	QGetCopyByID = `SELECT ` + copyColumns + `
+	FROM book_copies
+	WHERE id = ?
+	LIMIT 1`
+	QGetCopyByBarcode = `SELECT ` + copyColumns + `
+	FROM book_copies
+	WHERE barcode = ?
+	LIMIT 1`
+	QGetCopyByBarcodeForUpdate = `SELECT ` + copyColumns + `
+	FROM book_copies
+	WHERE barcode = ?
+	LIMIT 1
+	FOR UPDATE`
+	QGetCopiesByBook = `SELECT ` + copyColumns + `
+	FROM book_copies
+	WHERE book_id = ?
+	ORDER BY id`
+	QCountCopiesByBook = `SELECT COUNT(*)
+	FROM book_copies
+	WHERE book_id = ?`
+	QClaimAvailableCopy = `SELECT ` + copyColumns + `
+	FROM book_copies
+	WHERE book_id = ?
+	AND status = 'available'
+	ORDER BY id
+	LIMIT 1
+	FOR UPDATE`
+	QUpdateCopy = `UPDATE book_copies
+	SET barcode = ?, accession_no = ?, item_condition = ?, location = ?
+	WHERE id = ?`
+	QSetCopyStatus = `UPDATE book_copies
+	SET status = ?
+	WHERE id = ?
+	AND status = ?`
+)
+
+const issueColumns = `id, book_id, copy_id, member_id, issued_at, due_date, renewal_count, last_renewed_at, returned_at, fine_paid`
+
+const copyColumns = `id, book_id, barcode, accession_no, status, item_condition, location, created_at, updated_at`
+
+const (
+	QCreateHold = `INSERT INTO holds (book_id, member_id, status)
+	VALUES (?, ?, 'waiting')`
+	QGetHoldByID = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE id = ?
+	LIMIT 1`
+	QGetHoldByIDForUpdate = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE id = ?
+	LIMIT 1
+	FOR UPDATE`
+	QGetHoldQueueByBook = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE book_id = ?
+	AND status IN ('waiting', 'ready')
+	ORDER BY id`
+	QGetHoldsByMember = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE member_id = ?
+	ORDER BY id DESC`
+	QGetOpenHoldByBookAndMember = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE book_id = ?
+	AND member_id = ?
+	AND status IN ('waiting', 'ready')
+	LIMIT 1
+	FOR UPDATE`
+	QGetNextWaitingHold = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE book_id = ?
+	AND status = 'waiting'
+	ORDER BY id
+	LIMIT 1
+	FOR UPDATE`
+	QGetReadyHoldByCopy = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE copy_id = ?
+	AND status = 'ready'
+	LIMIT 1
+	FOR UPDATE`
+	QGetExpiredReadyHolds = `SELECT ` + holdColumns + `
+	FROM holds
+	WHERE status = 'ready'
+	AND expires_at < ?
+	ORDER BY id
+	FOR UPDATE`
+	QCountWaitingHoldsAhead = `SELECT COUNT(*)
+	FROM holds
+	WHERE book_id = ?
+	AND status = 'waiting'
+	AND id <= ?`
+	QCountWaitingHolds = `SELECT COUNT(*)
+	FROM holds
+	WHERE book_id = ?
+	AND status = 'waiting'`
+	QMarkHoldReady = `UPDATE holds
+	SET status = 'ready', copy_id = ?, ready_at = ?, expires_at = ?
+	WHERE id = ?
+	AND status = 'waiting'`
+	QCloseHold = `UPDATE holds
+	SET status = ?, closed_at = ?
+	WHERE id = ?
+	AND status IN ('waiting', 'ready')`
+)
+
+const holdColumns = `id, book_id, member_id, status, copy_id, created_at, ready_at, expires_at, closed_at`
+
+const (
+	QGetAllLoanPolicies = `SELECT ` + policyColumns + `
+	FROM loan_policies
+	ORDER BY category`
+	QGetLoanPolicy = `SELECT ` + policyColumns + `
+	FROM loan_policies
+	WHERE category = ?
+	LIMIT 1`
+	QUpsertLoanPolicy = `INSERT INTO loan_policies
+	(category, loan_days, max_loans, max_renewals, renew_overdue_max_days, fine_per_day, grace_days, fine_cap,
+	max_fine_balance, block_on_overdue)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+	loan_days = VALUES(loan_days),
+	max_loans = VALUES(max_loans),
+	max_renewals = VALUES(max_renewals),
+	renew_overdue_max_days = VALUES(renew_overdue_max_days),
+	fine_per_day = VALUES(fine_per_day),
+	grace_days = VALUES(grace_days),
+	fine_cap = VALUES(fine_cap),
+	max_fine_balance = VALUES(max_fine_balance),
+	block_on_overdue = VALUES(block_on_overdue)`
+	QDeleteLoanPolicy = `DELETE FROM loan_policies
+	WHERE category = ?`
+	QCountMembersInCategory = `SELECT COUNT(*)
+	FROM members
+	WHERE category = ?`
+)
+
+const policyColumns = `category, loan_days, max_loans, max_renewals, renew_overdue_max_days, fine_per_day, grace_days, fine_cap,
+	max_fine_balance, block_on_overdue, updated_at`
+
+const (
+	QCreateFine = `INSERT INTO fines (member_id, issue_id, kind, amount, note, created_by)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	QGetFineByID = `SELECT ` + fineColumns + `
+	FROM fines
+	WHERE id = ?
+	LIMIT 1`
+	QGetFineByIDForUpdate = `SELECT ` + fineColumns + `
+	FROM fines
+	WHERE id = ?
+	LIMIT 1
+	FOR UPDATE`
+	QGetFinesByMember = `SELECT ` + fineColumns + `
+	FROM fines
+	WHERE member_id = ?
+	ORDER BY id DESC`
+	QGetOpenFinesByMemberForUpdate = `SELECT ` + fineColumns + `
+	FROM fines
+	WHERE member_id = ?
+	AND status = 'open'
+	ORDER BY id
+	FOR UPDATE`
+	QGetOpenFinesByMember = `SELECT ` + fineColumns + `
+	FROM fines
+	WHERE member_id = ?
+	AND status = 'open'
+	ORDER BY id`
+	QApplyFineTransaction = `UPDATE fines
+	SET status = CASE
+		WHEN amount - (paid + ?) - (waived + ?) > 0 THEN 'open'
+		WHEN paid + ? > 0 THEN 'paid'
+		ELSE 'waived'
+	END,
+	paid = paid + ?,
+	waived = waived + ?
+	WHERE id = ?
+	AND status = 'open'`
+	QCreateFineTransaction = `INSERT INTO fine_transactions (fine_id, member_id, kind, amount, method, reason, receipt_no, staff_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	QGetTransactionsByFine = `SELECT ` + fineTransactionColumns + `
+	FROM fine_transactions
+	WHERE fine_id = ?
+	ORDER BY id`
+	QGetTransactionsByReceipt = `SELECT ` + fineTransactionColumns + `
+	FROM fine_transactions
+	WHERE receipt_no = ?
+	ORDER BY id`
+)
+
+const fineColumns = `id, member_id, issue_id, kind, amount, paid, waived, status, note, created_by, created_at, updated_at`
+
+const fineTransactionColumns = `id, fine_id, member_id, kind, amount, method, reason, receipt_no, staff_id, created_at`
+
+const (
+	QCreateOverride = `INSERT INTO circulation_overrides (issue_id, member_id, book_id, staff_id, reasons, justification)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	QGetOverridesByMember = `SELECT id, issue_id, member_id, book_id, staff_id, reasons, justification, created_at
+	FROM circulation_overrides
+	WHERE member_id = ?
+	ORDER BY id DESC`
+)
+
+const noticeColumns = `id, issue_id, member_id, DATE_FORMAT(due_date, '%Y-%m-%d') AS due_date, kind, level, channel, recipient, subject, status, error, created_at, sent_at`
+
+const (
+	QGetLoansDueBefore = `SELECT i.id AS issue_id, i.member_id, m.name AS member_name, m.email,
+	i.book_id, b.title, b.author, DATE_FORMAT(i.due_date, '%Y-%m-%d') AS due_date
+	FROM issues i
+	JOIN members m ON m.id = i.member_id
+	JOIN books b ON b.id = i.book_id
+	WHERE i.returned_at IS NULL
+	AND i.due_date IS NOT NULL
+	AND i.due_date <= ?
+	ORDER BY i.due_date, i.id`
+	QClaimNotice = `INSERT IGNORE INTO notices (issue_id, member_id, due_date, kind, level, channel, recipient, subject, status)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'pending')`
+	QFinishNotice = `UPDATE notices
+	SET status = ?, error = ?, sent_at = ?
+	WHERE id = ?`
+	QReleaseNotice = `DELETE FROM notices
+	WHERE id = ?
+	AND status = 'pending'`
+	QGetMaxNoticeLevel = `SELECT COALESCE(MAX(level), -1)
+	FROM notices
+	WHERE issue_id = ?
+	AND due_date = ?
+	AND kind = ?`
+	QGetNoticesByMember = `SELECT ` + noticeColumns + `
+	FROM notices
+	WHERE member_id = ?
+	ORDER BY id DESC`
+)
+
+const webhookColumns = `id, url, secret, events, active, description, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, delivered_at, created_at`
+
+const (
+	QCreateWebhook = `INSERT INTO webhooks (url, secret, events, active, description)
+	VALUES (?, ?, ?, ?, ?)`
+	QGetWebhookByID = `SELECT ` + webhookColumns + `
+	FROM webhooks
+	WHERE id = ?`
+	QGetAllWebhooks = `SELECT ` + webhookColumns + `
+	FROM webhooks
+	ORDER BY id`
+	QGetActiveWebhooks = `SELECT ` + webhookColumns + `
+	FROM webhooks
+	WHERE active = 1
+	ORDER BY id`
+	QUpdateWebhook = `UPDATE webhooks
+	SET url = ?, events = ?, active = ?, description = ?
+	WHERE id = ?`
+	QDeleteWebhook = `DELETE FROM webhooks
+	WHERE id = ?`
+
+	QCreateWebhookDelivery = `INSERT IGNORE INTO webhook_deliveries (webhook_id, event_id, event_type, payload, next_attempt_at)
+	VALUES (?, ?, ?, ?, ?)`
+	QGetWebhookDeliveryByID = `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_deliveries
+	WHERE id = ?`
+	QGetWebhookDeliveriesByWebhook = `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_deliveries
+	WHERE webhook_id = ?
+	AND (? = '' OR status = ?)
+	ORDER BY id DESC
+	LIMIT ?`
+	QGetDueWebhookDeliveries = `SELECT ` + webhookDeliveryColumns + `
+	FROM webhook_deliveries
+	WHERE status = 'pending'
+	AND next_attempt_at <= ?
+	ORDER BY next_attempt_at, id
+	LIMIT ?`
+	QLeaseWebhookDelivery = `UPDATE webhook_deliveries
+	SET next_attempt_at = ?
+	WHERE id = ?
+	AND status = 'pending'
+	AND next_attempt_at <= ?`
+	QFinishWebhookDelivery = `UPDATE webhook_deliveries
+	SET status = ?, attempts = attempts + 1, next_attempt_at = ?,
+	last_status_code = ?, last_error = ?, delivered_at = ?
+	WHERE id = ?`
+	QRetryWebhookDelivery = `UPDATE webhook_deliveries
+	SET status = 'pending', next_attempt_at = ?
+	WHERE id = ?
+	AND status <> 'delivered'`
+	QCreateWebhookAttempt = `INSERT INTO webhook_attempts (delivery_id, status_code, error, response_body, duration_ms)
+	VALUES (?, ?, ?, ?, ?)`
+	QGetWebhookAttempts = `SELECT id, delivery_id, status_code, error, response_body, duration_ms, attempted_at
+	FROM webhook_attempts
+	WHERE delivery_id = ?
+	ORDER BY id`
+)
+
+const outboxColumns = `id, event_id, event_type, aggregate_type, aggregate_id, payload, status, created_at, dispatched_at,
+	attempts, next_attempt_at, last_error`
+
+const (
+	QAppendOutbox = `INSERT INTO outbox (event_id, event_type, aggregate_type, aggregate_id, payload, created_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	QGetPendingOutboxForUpdate = `SELECT ` + outboxColumns + `
+	FROM outbox
+	WHERE status = 'pending'
+	ORDER BY id
+	LIMIT ?
+	FOR UPDATE SKIP LOCKED`
+	QMarkOutboxDispatched = `UPDATE outbox
+	SET status = 'dispatched', dispatched_at = ?, attempts = attempts + 1, next_attempt_at = NULL, last_error = NULL
+	WHERE id = ?`
+	QMarkOutboxFailed = `UPDATE outbox
+	SET attempts = attempts + 1, next_attempt_at = ?, last_error = ?
+	WHERE id = ?`
+	QMarkOutboxDead = `UPDATE outbox
+	SET status = 'dead', attempts = attempts + 1, next_attempt_at = NULL, last_error = ?
+	WHERE id = ?`
+	QGetOutboxAfter = `SELECT ` + outboxColumns + `
+	FROM outbox
+	WHERE id > ?
+	AND (? = '' OR event_type = ?)
+	AND (? = '' OR aggregate_type = ?)
+	AND (? = 0 OR aggregate_id = ?)
+	ORDER BY id
+	LIMIT ?`
+)
+
+const auditColumns = `id, occurred_at, actor_id, actor, action, entity_type, entity_id, before_json, after_json, diff,
+	method, path, status, request_id, ip`
+
+const (
+	QAppendAudit = `INSERT INTO audit_log (actor_id, actor, action, entity_type, entity_id, before_json, after_json, diff,
+	method, path, status, request_id, ip)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	QFindAudit = `SELECT ` + auditColumns + `
+	FROM audit_log
+	WHERE (? = 0 OR actor_id = ?)
+	AND (? = '' OR action = ?)
+	AND (? = '' OR entity_type = ?)
+	AND (? = '' OR entity_id = ?)
+	AND (? = '' OR request_id = ?)
+	AND (? IS NULL OR occurred_at >= ?)
+	AND (? IS NULL OR occurred_at < ?)
+	AND (? = 0 OR id < ?)
+	ORDER BY id DESC
+	LIMIT ?`
+)
+
+const importJobColumns = `id, kind, format, status, dry_run, bytes_total, bytes_read, processed, created, updated, skipped,
+	ignored_columns, row_errors, error, created_by, created_at, updated_at, finished_at`
+
+const (
+	QCreateImportJob = `INSERT INTO import_jobs (kind, format, dry_run, bytes_total, ignored_columns, created_by)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	QGetImportJobByID = `SELECT ` + importJobColumns + `
+	FROM import_jobs
+	WHERE id = ?`
+	QUpdateImportJob = `UPDATE import_jobs
+	SET status = ?, bytes_read = ?, processed = ?, created = ?, updated = ?, skipped = ?, ignored_columns = ?,
+	row_errors = ?, error = ?, finished_at = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?`
+)
+
+const (
+	QGetBookCover = `SELECT bc.book_id, bc.etag, bc.content_type, bc.width, bc.height, bc.bytes, bc.updated_at
+	FROM book_covers bc
+	JOIN books b ON b.id = bc.book_id
+	WHERE bc.book_id = ?
+	AND b.deleted_at IS NULL`
+	QSetBookCover = `INSERT INTO book_covers (book_id, etag, content_type, width, height, bytes)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE etag = VALUES(etag), content_type = VALUES(content_type), width = VALUES(width),
+	height = VALUES(height), bytes = VALUES(bytes), updated_at = CURRENT_TIMESTAMP`
+	QDeleteBookCover = `DELETE FROM book_covers
+	WHERE book_id = ?`
 )
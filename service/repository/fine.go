@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type FineRepo interface {
+	Create(f *models.Fine) (int64, error)
+	GetByID(id int64) (*models.Fine, error)
+	GetByIDForUpdate(id int64) (*models.Fine, error)
+	GetByMember(memberID int64) ([]models.Fine, error)
+	GetOpenByMember(memberID int64) ([]models.Fine, error)
+	GetOpenByMemberForUpdate(memberID int64) ([]models.Fine, error)
+	Apply(fineID int64, paid, waived float64) (bool, error)
+	AddTransaction(t *models.FineTransaction) (int64, error)
+	GetTransactions(fineID int64) ([]models.FineTransaction, error)
+	GetTransactionsByReceipt(receiptNo string) ([]models.FineTransaction, error)
+}
+
+type fineRepository struct {
+	db queryer
+}
+
+func (r *fineRepository) Create(f *models.Fine) (int64, error) {
+	res, err := r.db.Exec(db.QCreateFine, f.MemberID, f.IssueID, f.Kind, f.Amount, f.Note, f.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *fineRepository) get(query string, args ...interface{}) (*models.Fine, error) {
+	var f models.Fine
+	if err := r.db.Get(&f, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *fineRepository) GetByID(id int64) (*models.Fine, error) {
+	return r.get(db.QGetFineByID, id)
+}
+
+func (r *fineRepository) GetByIDForUpdate(id int64) (*models.Fine, error) {
+	return r.get(db.QGetFineByIDForUpdate, id)
+}
+
+func (r *fineRepository) list(query string, args ...interface{}) ([]models.Fine, error) {
+	fines := []models.Fine{}
+	if err := r.db.Select(&fines, query, args...); err != nil {
+		return nil, err
+	}
+	return fines, nil
+}
+
+func (r *fineRepository) GetByMember(memberID int64) ([]models.Fine, error) {
+	return r.list(db.QGetFinesByMember, memberID)
+}
+
+func (r *fineRepository) GetOpenByMember(memberID int64) ([]models.Fine, error) {
+	return r.list(db.QGetOpenFinesByMember, memberID)
+}
+
+func (r *fineRepository) GetOpenByMemberForUpdate(memberID int64) ([]models.Fine, error) {
+	return r.list(db.QGetOpenFinesByMemberForUpdate, memberID)
+}
+
+// Apply adds a payment and/or waiver to an open fine and settles its status.
+func (r *fineRepository) Apply(fineID int64, paid, waived float64) (bool, error) {
+	res, err := r.db.Exec(db.QApplyFineTransaction, paid, waived, paid, paid, waived, fineID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *fineRepository) AddTransaction(t *models.FineTransaction) (int64, error) {
+	res, err := r.db.Exec(db.QCreateFineTransaction, t.FineID, t.MemberID, t.Kind, t.Amount,
+		t.Method, t.Reason, t.ReceiptNo, t.StaffID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *fineRepository) GetTransactions(fineID int64) ([]models.FineTransaction, error) {
+	txns := []models.FineTransaction{}
+	if err := r.db.Select(&txns, db.QGetTransactionsByFine, fineID); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+func (r *fineRepository) GetTransactionsByReceipt(receiptNo string) ([]models.FineTransaction, error) {
+	txns := []models.FineTransaction{}
+	if err := r.db.Select(&txns, db.QGetTransactionsByReceipt, receiptNo); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
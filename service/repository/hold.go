@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type HoldRepo interface {
+	Create(h *models.Hold) (int64, error)
+	GetByID(id int64) (*models.Hold, error)
+	GetByIDForUpdate(id int64) (*models.Hold, error)
+	GetQueueByBook(bookID int64) ([]models.Hold, error)
+	GetByMember(memberID int64) ([]models.Hold, error)
+	GetOpenByBookAndMemberForUpdate(bookID, memberID int64) (*models.Hold, error)
+	NextWaitingForUpdate(bookID int64) (*models.Hold, error)
+	GetReadyByCopyForUpdate(copyID int64) (*models.Hold, error)
+	GetExpiredReadyForUpdate(now time.Time) ([]models.Hold, error)
+	Position(h *models.Hold) (int, error)
+	CountWaiting(bookID int64) (int, error)
+	MarkReady(id, copyID int64, readyAt, expiresAt time.Time) (bool, error)
+	Close(id int64, status string, at time.Time) (bool, error)
+}
+
+type holdRepository struct {
+	db queryer
+}
+
+func (r *holdRepository) Create(h *models.Hold) (int64, error) {
+	res, err := r.db.Exec(db.QCreateHold, h.BookID, h.MemberID)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *holdRepository) get(query string, args ...interface{}) (*models.Hold, error) {
+	var h models.Hold
+	if err := r.db.Get(&h, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *holdRepository) GetByID(id int64) (*models.Hold, error) {
+	return r.get(db.QGetHoldByID, id)
+}
+
+func (r *holdRepository) GetByIDForUpdate(id int64) (*models.Hold, error) {
+	return r.get(db.QGetHoldByIDForUpdate, id)
+}
+
+func (r *holdRepository) GetOpenByBookAndMemberForUpdate(bookID, memberID int64) (*models.Hold, error) {
+	return r.get(db.QGetOpenHoldByBookAndMember, bookID, memberID)
+}
+
+func (r *holdRepository) NextWaitingForUpdate(bookID int64) (*models.Hold, error) {
+	return r.get(db.QGetNextWaitingHold, bookID)
+}
+
+func (r *holdRepository) GetReadyByCopyForUpdate(copyID int64) (*models.Hold, error) {
+	return r.get(db.QGetReadyHoldByCopy, copyID)
+}
+
+func (r *holdRepository) GetQueueByBook(bookID int64) ([]models.Hold, error) {
+	holds := []models.Hold{}
+	if err := r.db.Select(&holds, db.QGetHoldQueueByBook, bookID); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *holdRepository) GetByMember(memberID int64) ([]models.Hold, error) {
+	holds := []models.Hold{}
+	if err := r.db.Select(&holds, db.QGetHoldsByMember, memberID); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+func (r *holdRepository) GetExpiredReadyForUpdate(now time.Time) ([]models.Hold, error) {
+	holds := []models.Hold{}
+	if err := r.db.Select(&holds, db.QGetExpiredReadyHolds, now); err != nil {
+		return nil, err
+	}
+	return holds, nil
+}
+
+// Position is the 1-based place of a waiting hold in its book's queue.
+func (r *holdRepository) Position(h *models.Hold) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountWaitingHoldsAhead, h.BookID, h.ID); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *holdRepository) CountWaiting(bookID int64) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountWaitingHolds, bookID); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *holdRepository) MarkReady(id, copyID int64, readyAt, expiresAt time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QMarkHoldReady, copyID, readyAt, expiresAt, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *holdRepository) Close(id int64, status string, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QCloseHold, status, at, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type ImportRepo interface {
+	Create(j *models.ImportJob) (int64, error)
+	GetByID(id int64) (*models.ImportJob, error)
+	Update(j *models.ImportJob) error
+}
+
+type importRepository struct {
+	db queryer
+}
+
+func (r *importRepository) Create(j *models.ImportJob) (int64, error) {
+	res, err := r.db.Exec(db.QCreateImportJob, j.Kind, j.Format, j.DryRun, j.BytesTotal, nullJSON(j.IgnoredColumns), j.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *importRepository) GetByID(id int64) (*models.ImportJob, error) {
+	var j models.ImportJob
+	if err := r.db.Get(&j, db.QGetImportJobByID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Update saves a job's progress and, once FinishedAt is set, its outcome.
+func (r *importRepository) Update(j *models.ImportJob) error {
+	_, err := r.db.Exec(db.QUpdateImportJob, j.Status, j.BytesRead, j.Processed, j.Created, j.Updated, j.Skipped,
+		nullJSON(j.IgnoredColumns), nullJSON(j.RowErrors), j.Error, j.FinishedAt, j.ID)
+	return err
+}
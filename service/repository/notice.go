@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type NoticeRepo interface {
+	GetLoansDueBefore(date string) ([]models.DueLoan, error)
+	Claim(n *models.Notice) (int64, bool, error)
+	Finish(id int64, status string, errMsg *string, sentAt *time.Time) error
+	Release(id int64) error
+	MaxLevel(issueID int64, dueDate, kind string) (int, error)
+	GetByMember(memberID int64) ([]models.Notice, error)
+}
+
+type noticeRepository struct {
+	db queryer
+}
+
+func (r *noticeRepository) GetLoansDueBefore(date string) ([]models.DueLoan, error) {
+	loans := []models.DueLoan{}
+	if err := r.db.Select(&loans, db.QGetLoansDueBefore, date); err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// Claim records a pending notice. It reports false when a notice of the
+// same kind and level already exists for the loan and due date, so two
+// runners never send it twice.
+func (r *noticeRepository) Claim(n *models.Notice) (int64, bool, error) {
+	res, err := r.db.Exec(db.QClaimNotice, n.IssueID, n.MemberID, n.DueDate, n.Kind, n.Level, n.Channel, n.Recipient, n.Subject)
+	if err != nil {
+		return 0, false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return 0, false, err
+	}
+	id, err := res.LastInsertId()
+	return id, err == nil, err
+}
+
+func (r *noticeRepository) Finish(id int64, status string, errMsg *string, sentAt *time.Time) error {
+	_, err := r.db.Exec(db.QFinishNotice, status, errMsg, sentAt, id)
+	return err
+}
+
+func (r *noticeRepository) Release(id int64) error {
+	_, err := r.db.Exec(db.QReleaseNotice, id)
+	return err
+}
+
+// MaxLevel is the highest level of kind sent, or being sent, for the loan
+// while it was due on dueDate; -1 when there is none.
+func (r *noticeRepository) MaxLevel(issueID int64, dueDate, kind string) (int, error) {
+	var level int
+	err := r.db.Get(&level, db.QGetMaxNoticeLevel, issueID, dueDate, kind)
+	return level, err
+}
+
+func (r *noticeRepository) GetByMember(memberID int64) ([]models.Notice, error) {
+	notices := []models.Notice{}
+	if err := r.db.Select(&notices, db.QGetNoticesByMember, memberID); err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"time"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type OutboxRepo interface {
+	Append(e *models.Event) (int64, error)
+	GetPendingForUpdate(limit int) ([]models.Event, error)
+	MarkDispatched(id int64, at time.Time) error
+	MarkFailed(id int64, errMsg string, next time.Time) error
+	MarkDead(id int64, errMsg string) error
+	GetAfter(afterSeq int64, eventType, aggregateType string, aggregateID int64, limit int) ([]models.Event, error)
+}
+
+type outboxRepository struct {
+	db queryer
+}
+
+func (r *outboxRepository) Append(e *models.Event) (int64, error) {
+	res, err := r.db.Exec(db.QAppendOutbox, e.ID, e.Type, e.AggregateType, e.AggregateID, e.Data, e.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetPendingForUpdate locks the oldest pending events, including those
+// waiting for a retry, skipping rows another dispatcher already holds.
+func (r *outboxRepository) GetPendingForUpdate(limit int) ([]models.Event, error) {
+	events := []models.Event{}
+	if err := r.db.Select(&events, db.QGetPendingOutboxForUpdate, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkDispatched(id int64, at time.Time) error {
+	_, err := r.db.Exec(db.QMarkOutboxDispatched, at, id)
+	return err
+}
+
+// MarkFailed records a failed attempt; the event is retried from next.
+func (r *outboxRepository) MarkFailed(id int64, errMsg string, next time.Time) error {
+	_, err := r.db.Exec(db.QMarkOutboxFailed, next, errMsg, id)
+	return err
+}
+
+// MarkDead records a last failed attempt and takes the event out of the
+// queue.
+func (r *outboxRepository) MarkDead(id int64, errMsg string) error {
+	_, err := r.db.Exec(db.QMarkOutboxDead, errMsg, id)
+	return err
+}
+
+func (r *outboxRepository) GetAfter(afterSeq int64, eventType, aggregateType string, aggregateID int64, limit int) ([]models.Event, error) {
+	events := []models.Event{}
+	err := r.db.Select(&events, db.QGetOutboxAfter, afterSeq,
+		eventType, eventType, aggregateType, aggregateType, aggregateID, aggregateID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
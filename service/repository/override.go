@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type OverrideRepo interface {
+	Create(o *models.CirculationOverride) (int64, error)
+	GetByMember(memberID int64) ([]models.CirculationOverride, error)
+}
+
+type overrideRepository struct {
+	db queryer
+}
+
+func (r *overrideRepository) Create(o *models.CirculationOverride) (int64, error) {
+	res, err := r.db.Exec(db.QCreateOverride, o.IssueID, o.MemberID, o.BookID, o.StaffID, o.Reasons, o.Justification)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *overrideRepository) GetByMember(memberID int64) ([]models.CirculationOverride, error) {
+	overrides := []models.CirculationOverride{}
+	if err := r.db.Select(&overrides, db.QGetOverridesByMember, memberID); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"library-management/service/models"
+)
+
+// ErrInvalidPage reports a sort key or cursor the list does not accept.
+var ErrInvalidPage = errors.New("invalid page request")
+
+const (
+	sortText = iota
+	sortTime
+	sortDate
+)
+
+// sortKey is a column a list may be ordered by. kind says how a cursor
+// value is turned back into a query argument.
+type sortKey struct {
+	column string
+	kind   int
+}
+
+// cursor is the position after the last row of a page: the sort it was
+// taken under, that row's sort value and its id, which breaks ties.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int64  `json:"id"`
+}
+
+func encodeCursor(sort, value string, id int64) string {
+	b, _ := json.Marshal(cursor{Sort: sort, Value: value, ID: id})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s, sort string) (*cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidPage, c.Sort)
+	}
+	return &c, nil
+}
+
+// pageQuery completes query, which must end inside its WHERE clause, with
+// the keyset condition for opts.Cursor, the ORDER BY for opts.Sort and a
+// LIMIT one past opts.Limit so the caller can tell whether a next page
+// exists. "id" is always a valid sort key.
+func pageQuery(query string, args []interface{}, keys map[string]sortKey, idColumn string, opts models.ListOptions) (string, []interface{}, error) {
+	name := strings.TrimPrefix(opts.Sort, "-")
+	desc := strings.HasPrefix(opts.Sort, "-")
+	key, ok := keys[name]
+	if name != "id" && !ok {
+		return "", nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidPage, name)
+	}
+
+	cmp, dir := ">", "ASC"
+	if desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return "", nil, err
+		}
+		if name == "id" {
+			query += fmt.Sprintf("\n\tAND %s %s ?", idColumn, cmp)
+			args = append(args, c.ID)
+		} else {
+			v, err := cursorValue(key.kind, c.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			query += fmt.Sprintf("\n\tAND (%[1]s %[2]s ? OR (%[1]s = ? AND %[3]s %[2]s ?))", key.column, cmp, idColumn)
+			args = append(args, v, v, c.ID)
+		}
+	}
+
+	if name == "id" {
+		query += fmt.Sprintf("\n\tORDER BY %s %s", idColumn, dir)
+	} else {
+		query += fmt.Sprintf("\n\tORDER BY %s %s, %s %s", key.column, dir, idColumn, dir)
+	}
+	query += "\n\tLIMIT ?"
+	args = append(args, opts.Limit+1)
+	return query, args, nil
+}
+
+func cursorValue(kind int, v string) (interface{}, error) {
+	switch kind {
+	case sortTime:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		return t, nil
+	case sortDate:
+		if len(v) < 10 {
+			return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		return v[:10], nil
+	}
+	return v, nil
+}
+
+// nextCursor trims the extra row pageQuery asked for and returns the cursor
+// for the page after items, or "" when this is the last one.
+func nextCursor[T any](items []T, opts models.ListOptions, key func(*T) (string, int64)) ([]T, string) {
+	if len(items) <= opts.Limit {
+		return items, ""
+	}
+	items = items[:opts.Limit]
+	value, id := key(&items[len(items)-1])
+	return items, encodeCursor(opts.Sort, value, id)
+}
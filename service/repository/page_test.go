@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"library-management/service/models"
+)
+
+func TestIssueCursorWithoutDueDate(t *testing.T) {
+	issues := []models.Issue{{ID: 3}, {ID: 7}}
+	opts := models.ListOptions{Limit: 1, Sort: "due_date"}
+	_, next := nextCursor(issues, opts, issueCursorKey(opts.Sort))
+
+	opts.Cursor = next
+	query, args, err := pageQuery("SELECT id FROM issues\n\tWHERE 1 = 1", nil, issueSorts, "id", opts)
+	if err != nil {
+		t.Fatalf("cursor after a loan without due date: %v", err)
+	}
+	col := "due_sort"
+	if !strings.Contains(query, "AND ("+col+" > ? OR ("+col+" = ? AND id > ?))") {
+		t.Errorf("keyset condition does not use %s:\n%s", col, query)
+	}
+	if !strings.Contains(query, "ORDER BY "+col+" ASC, id ASC") {
+		t.Errorf("order does not use %s:\n%s", col, query)
+	}
+	if want := []interface{}{noDueDate, noDueDate, int64(3), 2}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
+
+func TestIssueCursorDueDateFromDatetime(t *testing.T) {
+	// with parseTime the driver scans DATE columns as timestamps
+	due := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	opts := models.ListOptions{Limit: 5, Sort: "-due_date", Cursor: encodeCursor("-due_date", due, 9)}
+	_, args, err := pageQuery("SELECT id FROM issues\n\tWHERE 1 = 1", nil, issueSorts, "id", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0] != "2026-10-18" {
+		t.Errorf("cursor value = %v, want 2026-10-18", args[0])
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	keys := map[string]sortKey{
+		"title":      {column: "title", kind: sortText},
+		"created_at": {column: "created_at", kind: sortTime},
+	}
+	created := time.Date(2026, 10, 1, 9, 30, 0, 5000, time.UTC)
+	base := "SELECT id FROM books\n\tWHERE deleted_at IS NULL"
+	tests := []struct {
+		name  string
+		opts  models.ListOptions
+		where string
+		order string
+		args  []interface{}
+	}{
+		{
+			name:  "first page by id",
+			opts:  models.ListOptions{Limit: 20, Sort: "id"},
+			order: "ORDER BY id ASC",
+			args:  []interface{}{21},
+		},
+		{
+			name:  "next page by id descending",
+			opts:  models.ListOptions{Limit: 20, Sort: "-id", Cursor: encodeCursor("-id", "", 40)},
+			where: "AND id < ?",
+			order: "ORDER BY id DESC",
+			args:  []interface{}{int64(40), 21},
+		},
+		{
+			name:  "next page by title",
+			opts:  models.ListOptions{Limit: 5, Sort: "title", Cursor: encodeCursor("title", "Dune", 3)},
+			where: "AND (title > ? OR (title = ? AND id > ?))",
+			order: "ORDER BY title ASC, id ASC",
+			args:  []interface{}{"Dune", "Dune", int64(3), 6},
+		},
+		{
+			name:  "next page by time descending",
+			opts:  models.ListOptions{Limit: 5, Sort: "-created_at", Cursor: encodeCursor("-created_at", created.Format(time.RFC3339Nano), 9)},
+			where: "AND (created_at < ? OR (created_at = ? AND id < ?))",
+			order: "ORDER BY created_at DESC, id DESC",
+			args:  []interface{}{created, created, int64(9), 6},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := pageQuery(base, nil, keys, "id", tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := base
+			if tt.where != "" {
+				want += "\n\t" + tt.where
+			}
+			want += "\n\t" + tt.order + "\n\tLIMIT ?"
+			if query != want {
+				t.Errorf("query:\n%s\nwant:\n%s", query, want)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %#v, want %#v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestPageQueryRejects(t *testing.T) {
+	keys := map[string]sortKey{
+		"title":      {column: "title", kind: sortText},
+		"created_at": {column: "created_at", kind: sortTime},
+	}
+	tests := map[string]models.ListOptions{
+		"unknown sort":         {Limit: 5, Sort: "isbn"},
+		"cursor not base64":    {Limit: 5, Sort: "title", Cursor: "%%%"},
+		"cursor not json":      {Limit: 5, Sort: "title", Cursor: "bm90IGpzb24"},
+		"cursor of other sort": {Limit: 5, Sort: "-title", Cursor: encodeCursor("title", "Dune", 3)},
+		"bad time in cursor":   {Limit: 5, Sort: "created_at", Cursor: encodeCursor("created_at", "yesterday", 3)},
+	}
+	for name, opts := range tests {
+		if _, _, err := pageQuery("SELECT id FROM books WHERE 1 = 1", nil, keys, "id", opts); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("%s: got %v, want ErrInvalidPage", name, err)
+		}
+	}
+}
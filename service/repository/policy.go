@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type PolicyRepo interface {
+	GetAll() ([]models.LoanPolicy, error)
+	GetByCategory(category string) (*models.LoanPolicy, error)
+	Upsert(p *models.LoanPolicy) error
+	Delete(category string) error
+	CountMembers(category string) (int, error)
+}
+
+type policyRepository struct {
+	db queryer
+}
+
+func (r *policyRepository) GetAll() ([]models.LoanPolicy, error) {
+	policies := []models.LoanPolicy{}
+	if err := r.db.Select(&policies, db.QGetAllLoanPolicies); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *policyRepository) GetByCategory(category string) (*models.LoanPolicy, error) {
+	var p models.LoanPolicy
+	if err := r.db.Get(&p, db.QGetLoanPolicy, category); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *policyRepository) Upsert(p *models.LoanPolicy) error {
+	_, err := r.db.Exec(db.QUpsertLoanPolicy, p.Category, p.LoanDays, p.MaxLoans, p.MaxRenewals,
+		p.RenewOverdueMaxDays, p.FinePerDay, p.GraceDays, p.FineCap, p.MaxFineBalance, p.BlockOnOverdue)
+	return err
+}
+
+func (r *policyRepository) Delete(category string) error {
+	_, err := r.db.Exec(db.QDeleteLoanPolicy, category)
+	return err
+}
+
+func (r *policyRepository) CountMembers(category string) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountMembersInCategory, category); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
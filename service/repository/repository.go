@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,49 +16,156 @@ import (
 type BookRepo interface {
 	Create(b *models.Book) (int64, error)
 	GetByID(id int64) (*models.Book, error)
-	GetAll() ([]models.Book, error)
-	Search(qry string) ([]models.Book, error)
+	GetByIDForUpdate(id int64) (*models.Book, error)
+	List(f models.BookFilter) (models.Page[models.Book], error)
+	Search(q models.TextQuery) ([]models.ScoredBook, error)
+	Facets(q models.TextQuery, limit int) (*models.Facets, error)
+	GetSubjects(id int64) ([]string, error)
+	GetCatalogEntries() ([]models.CatalogEntry, error)
+	GetByISBN(isbn string) (*models.Book, error)
+	SetSubjects(id int64, subjects []string) error
+	GetContributors(id int64) ([]models.Contributor, error)
+	SetContributors(id int64, contributors []models.Contributor) error
+	SetAuthor(id int64, author string) error
+	GetCover(id int64) (*models.BookCover, error)
+	SetCover(c *models.BookCover) error
+	DeleteCover(id int64) error
 	Update(b *models.Book) error
-	Delete(id int64) error
-	ChangeAvailability(id int64, delta int) (bool, error)
+	GetByIDWithDeleted(id int64) (*models.Book, error)
+	GetDeleted() ([]models.Book, error)
+	SoftDelete(id int64, at time.Time) (bool, error)
+	Restore(id int64) (bool, error)
+	GetPurgeable(before time.Time) ([]int64, error)
+	Purge(id int64) (bool, error)
 }
 
 type MemberRepo interface {
 	Create(m *models.Member) (int64, error)
 	GetByID(id int64) (*models.Member, error)
-	GetAll() ([]models.Member, error)
+	GetByRollNo(rollNo string) ([]models.Member, error)
+	List(f models.MemberFilter) (models.Page[models.Member], error)
 	Update(m *models.Member) error
-	Delete(id int64) error
+	GetByIDWithDeleted(id int64) (*models.Member, error)
+	GetDeleted() ([]models.Member, error)
+	SoftDelete(id int64, at time.Time) (bool, error)
+	Restore(id int64) (bool, error)
+	GetToAnonymize(before time.Time) ([]int64, error)
+	Anonymize(id int64, at time.Time) (bool, error)
+	// RedactHistory clears an anonymized member's details from the audit
+	// log, the outbox, webhook deliveries and notices.
+	RedactHistory(id int64) error
 }
 
 type IssueRepo interface {
 	Create(issue *models.Issue) (int64, error)
 	GetActiveByBookAndMember(bookID, memberID int64) (*models.Issue, error)
-	GetByMember(memberID int64) ([]models.Issue, error)
+	List(f models.IssueFilter, today string) (models.Page[models.Issue], error)
+	CountActiveByMember(memberID int64) (int, error)
+	CountActiveByBook(bookID int64) (int, error)
+	CountOverdueByMember(memberID int64, today string) (int, error)
 	GetByID(id int64) (*models.Issue, error)
-	Return(issueID int64, returnedAt time.Time, fine float64) (bool, error)
+	GetByIDForUpdate(id int64) (*models.Issue, error)
+	GetActiveByCopyForUpdate(copyID int64) (*models.Issue, error)
+	Renew(issueID int64, dueDate string, renewedAt time.Time) (bool, error)
+	Return(issueID int64, returnedAt time.Time) (bool, error)
+	AddFinePaid(issueID int64, amount float64) error
+}
+
+// queryer is satisfied by both *sqlx.DB and *sqlx.Tx, so the same
+// repositories can run standalone or inside a unit of work.
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	Select(dest interface{}, query string, args ...interface{}) error
 }
 
 type Repo struct {
-	BookRepo   BookRepo
-	MemberRepo MemberRepo
-	IssueRepo  IssueRepo
+	BookRepo     BookRepo
+	MemberRepo   MemberRepo
+	IssueRepo    IssueRepo
+	AdminRepo    AdminRepo
+	SessionRepo  SessionRepo
+	RoleRepo     RoleRepo
+	CopyRepo     CopyRepo
+	HoldRepo     HoldRepo
+	PolicyRepo   PolicyRepo
+	FineRepo     FineRepo
+	OverrideRepo OverrideRepo
+	NoticeRepo   NoticeRepo
+	WebhookRepo  WebhookRepo
+	OutboxRepo   OutboxRepo
+	AuditRepo    AuditRepo
+	AuthorRepo   AuthorRepo
+	ImportRepo   ImportRepo
+
+	dbx *sqlx.DB
+	tx  *sqlx.Tx
 }
 
 func NewRepo(dbx *sqlx.DB) *Repo {
+	r := newRepo(dbx)
+	r.dbx = dbx
+	return r
+}
+
+func newRepo(q queryer) *Repo {
 	return &Repo{
-		BookRepo:   &bookRepository{db: dbx},
-		MemberRepo: &memberRepository{db: dbx},
-		IssueRepo:  &issueRepository{db: dbx},
+		BookRepo:     &bookRepository{db: q},
+		MemberRepo:   &memberRepository{db: q},
+		IssueRepo:    &issueRepository{db: q},
+		AdminRepo:    &adminRepository{db: q},
+		SessionRepo:  &sessionRepository{db: q},
+		RoleRepo:     &roleRepository{db: q},
+		CopyRepo:     &copyRepository{db: q},
+		HoldRepo:     &holdRepository{db: q},
+		PolicyRepo:   &policyRepository{db: q},
+		FineRepo:     &fineRepository{db: q},
+		OverrideRepo: &overrideRepository{db: q},
+		NoticeRepo:   &noticeRepository{db: q},
+		WebhookRepo:  &webhookRepository{db: q},
+		OutboxRepo:   &outboxRepository{db: q},
+		AuditRepo:    &auditRepository{db: q},
+		AuthorRepo:   &authorRepository{db: q},
+		ImportRepo:   &importRepository{db: q},
 	}
 }
 
+// WithTx runs fn against a Repo whose repositories all share one
+// transaction. The transaction is committed if fn returns nil and rolled
+// back otherwise. Nested calls reuse the outer transaction.
+func (r *Repo) WithTx(ctx context.Context, fn func(*Repo) error) (err error) {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.dbx.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	txRepo := newRepo(tx)
+	txRepo.dbx = r.dbx
+	txRepo.tx = tx
+	return fn(txRepo)
+}
+
 type bookRepository struct {
-	db *sqlx.DB
+	db queryer
 }
 
 func (r *bookRepository) Create(b *models.Book) (int64, error) {
-	res, err := r.db.Exec(db.QCreateBook, b.Title, b.Author, b.Copies, b.Available)
+	res, err := r.db.Exec(db.QCreateBook, b.Title, b.Author, b.ISBN, b.Publisher, b.PublicationYear, b.Edition,
+		b.Language, b.Format, b.PageCount, b.Description, b.CallNumber)
 	if err != nil {
 		return 0, err
 	}
@@ -73,35 +183,220 @@ func (r *bookRepository) GetByID(id int64) (*models.Book, error) {
 	return &b, nil
 }
 
-func (r *bookRepository) GetAll() ([]models.Book, error) {
-	var books []models.Book
-	if err := r.db.Select(&books, db.QGetAllBooks); err != nil {
+func (r *bookRepository) GetByIDForUpdate(id int64) (*models.Book, error) {
+	var b models.Book
+	if err := r.db.Get(&b, db.QGetBookByIDForUpdate, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
-	return books, nil
+	return &b, nil
 }
 
-func (r *bookRepository) Search(qry string) ([]models.Book, error) {
-	like := "%" + qry + "%"
-	var books []models.Book
-	if err := r.db.Select(&books, db.QSearchBooks, like, like); err != nil {
-		return nil, err
+var bookSorts = map[string]sortKey{
+	"title":      {column: "b.title"},
+	"author":     {column: "b.author"},
+	"created_at": {column: "b.created_at", kind: sortTime},
+}
+
+func (r *bookRepository) List(f models.BookFilter) (models.Page[models.Book], error) {
+	page := models.Page[models.Book]{Items: []models.Book{}}
+	args := []interface{}{
+		f.Author, f.Author,
+		f.CreatedFrom, f.CreatedFrom,
+		f.CreatedTo, f.CreatedTo,
+		f.Available, f.Available,
 	}
-	return books, nil
+	if f.Cursor == "" {
+		var total int
+		if err := r.db.Get(&total, db.QCountBooks, args...); err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	query, args, err := pageQuery(db.QListBooks, args, bookSorts, "b.id", f.ListOptions)
+	if err != nil {
+		return page, err
+	}
+	if err := r.db.Select(&page.Items, query, args...); err != nil {
+		return page, err
+	}
+	page.Items, page.NextCursor = nextCursor(page.Items, f.ListOptions, func(b *models.Book) (string, int64) {
+		switch strings.TrimPrefix(f.Sort, "-") {
+		case "title":
+			return b.Title, b.ID
+		case "author":
+			return b.Author, b.ID
+		case "created_at":
+			return b.CreatedAt.Format(time.RFC3339Nano), b.ID
+		}
+		return "", b.ID
+	})
+	return page, nil
 }
 
 func (r *bookRepository) Update(b *models.Book) error {
-	_, err := r.db.Exec(db.QUpdateBook, b.Title, b.Author, b.Copies, b.Available, b.ID)
+	_, err := r.db.Exec(db.QUpdateBook, b.Title, b.Author, b.ISBN, b.Publisher, b.PublicationYear, b.Edition,
+		b.Language, b.Format, b.PageCount, b.Description, b.CallNumber, b.ID)
+	return err
+}
+
+// GetByISBN also finds soft deleted books, which keep their ISBN.
+func (r *bookRepository) GetByISBN(isbn string) (*models.Book, error) {
+	var b models.Book
+	if err := r.db.Get(&b, db.QGetBookByISBN, isbn); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *bookRepository) GetCatalogEntries() ([]models.CatalogEntry, error) {
+	var rows []struct {
+		ID      int64  `db:"id"`
+		Title   string `db:"title"`
+		Authors string `db:"authors"`
+	}
+	if err := r.db.Select(&rows, db.QGetCatalogEntries); err != nil {
+		return nil, err
+	}
+	entries := make([]models.CatalogEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = models.CatalogEntry{ID: row.ID, Title: row.Title}
+		if row.Authors != "" {
+			entries[i].Authors = strings.Split(row.Authors, "\n")
+		}
+	}
+	return entries, nil
+}
+
+func (r *bookRepository) GetContributors(id int64) ([]models.Contributor, error) {
+	contributors := []models.Contributor{}
+	if err := r.db.Select(&contributors, db.QGetBookContributors, id); err != nil {
+		return nil, err
+	}
+	return contributors, nil
+}
+
+// SetContributors replaces the contributors of a book, keeping their order.
+// Every contributor must have an AuthorID.
+func (r *bookRepository) SetContributors(id int64, contributors []models.Contributor) error {
+	if _, err := r.db.Exec(db.QDeleteBookContributors, id); err != nil {
+		return err
+	}
+	for i, c := range contributors {
+		if _, err := r.db.Exec(db.QAddBookContributor, id, c.AuthorID, c.Role, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bookRepository) SetAuthor(id int64, author string) error {
+	_, err := r.db.Exec(db.QSetBookAuthor, author, id)
+	return err
+}
+
+func (r *bookRepository) GetCover(id int64) (*models.BookCover, error) {
+	var c models.BookCover
+	if err := r.db.Get(&c, db.QGetBookCover, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *bookRepository) SetCover(c *models.BookCover) error {
+	_, err := r.db.Exec(db.QSetBookCover, c.BookID, c.ETag, c.ContentType, c.Width, c.Height, c.Bytes)
 	return err
 }
 
-func (r *bookRepository) Delete(id int64) error {
-	_, err := r.db.Exec(db.QDeleteBook, id)
+func (r *bookRepository) DeleteCover(id int64) error {
+	_, err := r.db.Exec(db.QDeleteBookCover, id)
 	return err
 }
 
-func (r *bookRepository) ChangeAvailability(id int64, delta int) (bool, error) {
-	res, err := r.db.Exec(db.QChangeAvailability, delta, id, delta)
+func (r *bookRepository) GetSubjects(id int64) ([]string, error) {
+	subjects := []string{}
+	if err := r.db.Select(&subjects, db.QGetBookSubjects, id); err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (r *bookRepository) SetSubjects(id int64, subjects []string) error {
+	if _, err := r.db.Exec(db.QDeleteBookSubjects, id); err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		if _, err := r.db.Exec(db.QAddBookSubject, id, subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *bookRepository) GetByIDWithDeleted(id int64) (*models.Book, error) {
+	var b models.Book
+	if err := r.db.Get(&b, db.QGetBookByIDWithDeleted, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *bookRepository) GetDeleted() ([]models.Book, error) {
+	books := []models.Book{}
+	if err := r.db.Select(&books, db.QGetDeletedBooks); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+func (r *bookRepository) SoftDelete(id int64, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QSoftDeleteBook, at, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *bookRepository) Restore(id int64) (bool, error) {
+	res, err := r.db.Exec(db.QRestoreBook, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// GetPurgeable lists books deleted before the cutoff that never circulated
+// or were held, which are the only ones that can be removed for good.
+func (r *bookRepository) GetPurgeable(before time.Time) ([]int64, error) {
+	ids := []int64{}
+	if err := r.db.Select(&ids, db.QGetPurgeableBooks, before); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *bookRepository) Purge(id int64) (bool, error) {
+	res, err := r.db.Exec(db.QPurgeBook, id)
 	if err != nil {
 		return false, err
 	}
@@ -113,11 +408,11 @@ func (r *bookRepository) ChangeAvailability(id int64, delta int) (bool, error) {
 }
 
 type memberRepository struct {
-	db *sqlx.DB
+	db queryer
 }
 
 func (r *memberRepository) Create(m *models.Member) (int64, error) {
-	res, err := r.db.Exec(db.QCreateMember, m.Name, m.Email, m.RollNo)
+	res, err := r.db.Exec(db.QCreateMember, m.Name, m.Email, m.RollNo, m.Category, m.ExpiresAt)
 	if err != nil {
 		return 0, err
 	}
@@ -135,30 +430,142 @@ func (r *memberRepository) GetByID(id int64) (*models.Member, error) {
 	return &m, nil
 }
 
-func (r *memberRepository) GetAll() ([]models.Member, error) {
-	var members []models.Member
-	if err := r.db.Select(&members, db.QGetAllMembers); err != nil {
+// GetByRollNo returns the members, deleted ones too, with a roll number.
+// Roll numbers aren't unique in the schema, so there may be several.
+func (r *memberRepository) GetByRollNo(rollNo string) ([]models.Member, error) {
+	members := []models.Member{}
+	if err := r.db.Select(&members, db.QGetMembersByRollNo, rollNo); err != nil {
 		return nil, err
 	}
 	return members, nil
 }
 
+var memberSorts = map[string]sortKey{
+	"name":       {column: "name"},
+	"created_at": {column: "created_at", kind: sortTime},
+}
+
+func (r *memberRepository) List(f models.MemberFilter) (models.Page[models.Member], error) {
+	page := models.Page[models.Member]{Items: []models.Member{}}
+	args := []interface{}{
+		f.Category, f.Category,
+		f.CreatedFrom, f.CreatedFrom,
+		f.CreatedTo, f.CreatedTo,
+	}
+	if f.Cursor == "" {
+		var total int
+		if err := r.db.Get(&total, db.QCountMembers, args...); err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	query, args, err := pageQuery(db.QListMembers, args, memberSorts, "id", f.ListOptions)
+	if err != nil {
+		return page, err
+	}
+	if err := r.db.Select(&page.Items, query, args...); err != nil {
+		return page, err
+	}
+	page.Items, page.NextCursor = nextCursor(page.Items, f.ListOptions, func(m *models.Member) (string, int64) {
+		switch strings.TrimPrefix(f.Sort, "-") {
+		case "name":
+			return m.Name, m.ID
+		case "created_at":
+			return m.CreatedAt.Format(time.RFC3339Nano), m.ID
+		}
+		return "", m.ID
+	})
+	return page, nil
+}
+
 func (r *memberRepository) Update(m *models.Member) error {
-	_, err := r.db.Exec(db.QUpdateMember, m.Name, m.Email, m.RollNo, m.ID)
+	_, err := r.db.Exec(db.QUpdateMember, m.Name, m.Email, m.RollNo, m.Category, m.ExpiresAt, m.ID)
 	return err
 }
 
-func (r *memberRepository) Delete(id int64) error {
-	_, err := r.db.Exec(db.QDeleteMember, id)
-	return err
+func (r *memberRepository) GetByIDWithDeleted(id int64) (*models.Member, error) {
+	var m models.Member
+	if err := r.db.Get(&m, db.QGetMemberByIDWithDeleted, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *memberRepository) GetDeleted() ([]models.Member, error) {
+	members := []models.Member{}
+	if err := r.db.Select(&members, db.QGetDeletedMembers); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *memberRepository) SoftDelete(id int64, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QSoftDeleteMember, at, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *memberRepository) Restore(id int64) (bool, error) {
+	res, err := r.db.Exec(db.QRestoreMember, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *memberRepository) GetToAnonymize(before time.Time) ([]int64, error) {
+	ids := []int64{}
+	if err := r.db.Select(&ids, db.QGetMembersToAnonymize, before); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *memberRepository) Anonymize(id int64, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QAnonymizeMember, at, id)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *memberRepository) RedactHistory(id int64) error {
+	// audit_log.entity_id is a string; compare it as one to use its index
+	if _, err := r.db.Exec(db.QRedactMemberAudit, strconv.FormatInt(id, 10)); err != nil {
+		return err
+	}
+	for _, q := range []string{db.QRedactMemberEvents, db.QRedactMemberDeliveries, db.QRedactMemberNotices} {
+		if _, err := r.db.Exec(q, id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 type issueRepository struct {
-	db *sqlx.DB
+	db queryer
 }
 
 func (r *issueRepository) Create(issue *models.Issue) (int64, error) {
-	res, err := r.db.Exec(db.QCreateIssue, issue.BookID, issue.MemberID, issue.DueDate)
+	res, err := r.db.Exec(db.QCreateIssue, issue.BookID, issue.CopyID, issue.MemberID, issue.DueDate)
 	if err != nil {
 		return 0, err
 	}
@@ -176,12 +583,86 @@ func (r *issueRepository) GetActiveByBookAndMember(bookID, memberID int64) (*mod
 	return &it, nil
 }
 
-func (r *issueRepository) GetByMember(memberID int64) ([]models.Issue, error) {
-	var issues []models.Issue
-	if err := r.db.Select(&issues, db.QGetIssuesByMember, memberID); err != nil {
-		return nil, err
+// noDueDate stands in for a missing due date when sorting, so loans without
+// one come last in ascending order and keyset conditions still match them.
+// The indexed issues.due_sort column (migration 0025) holds due_date or
+// this date.
+const noDueDate = "9999-12-31"
+
+var issueSorts = map[string]sortKey{
+	"issued_at": {column: "issued_at", kind: sortTime},
+	"due_date":  {column: "due_sort", kind: sortDate},
+}
+
+// List pages through loans. today decides which open loans count as overdue
+// for the "overdue" status filter.
+func (r *issueRepository) List(f models.IssueFilter, today string) (models.Page[models.Issue], error) {
+	page := models.Page[models.Issue]{Items: []models.Issue{}}
+	args := []interface{}{
+		f.MemberID, f.MemberID,
+		f.BookID, f.BookID,
+		f.Status,
+		f.Status,
+		f.Status, today,
+	}
+	if f.Cursor == "" {
+		var total int
+		if err := r.db.Get(&total, db.QCountIssues, args...); err != nil {
+			return page, err
+		}
+		page.Total = &total
+	}
+
+	query, args, err := pageQuery(db.QListIssues, args, issueSorts, "id", f.ListOptions)
+	if err != nil {
+		return page, err
+	}
+	if err := r.db.Select(&page.Items, query, args...); err != nil {
+		return page, err
+	}
+	page.Items, page.NextCursor = nextCursor(page.Items, f.ListOptions, issueCursorKey(f.Sort))
+	return page, nil
+}
+
+// issueCursorKey returns a loan's value for sort in the form the sort's
+// column compares it, and its id.
+func issueCursorKey(sort string) func(*models.Issue) (string, int64) {
+	return func(it *models.Issue) (string, int64) {
+		switch strings.TrimPrefix(sort, "-") {
+		case "issued_at":
+			return it.IssuedAt.Format(time.RFC3339Nano), it.ID
+		case "due_date":
+			if it.DueDate != nil && *it.DueDate != "" {
+				return *it.DueDate, it.ID
+			}
+			return noDueDate, it.ID
+		}
+		return "", it.ID
+	}
+}
+
+func (r *issueRepository) CountActiveByBook(bookID int64) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountActiveIssuesByBook, bookID); err != nil {
+		return 0, err
 	}
-	return issues, nil
+	return n, nil
+}
+
+func (r *issueRepository) CountActiveByMember(memberID int64) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountActiveIssuesByMember, memberID); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (r *issueRepository) CountOverdueByMember(memberID int64, today string) (int, error) {
+	var n int
+	if err := r.db.Get(&n, db.QCountOverdueIssuesByMember, memberID, today); err != nil {
+		return 0, err
+	}
+	return n, nil
 }
 
 func (r *issueRepository) GetByID(id int64) (*models.Issue, error) {
@@ -195,8 +676,42 @@ func (r *issueRepository) GetByID(id int64) (*models.Issue, error) {
 	return &it, nil
 }
 
-func (r *issueRepository) Return(issueID int64, returnedAt time.Time, fine float64) (bool, error) {
-	res, err := r.db.Exec(db.QReturnIssue, returnedAt, fine, issueID)
+func (r *issueRepository) GetByIDForUpdate(id int64) (*models.Issue, error) {
+	var it models.Issue
+	if err := r.db.Get(&it, db.QGetIssueByIDForUpdate, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &it, nil
+}
+
+func (r *issueRepository) GetActiveByCopyForUpdate(copyID int64) (*models.Issue, error) {
+	var it models.Issue
+	if err := r.db.Get(&it, db.QGetActiveIssueByCopy, copyID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &it, nil
+}
+
+func (r *issueRepository) Renew(issueID int64, dueDate string, renewedAt time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QRenewIssue, dueDate, renewedAt, issueID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *issueRepository) Return(issueID int64, returnedAt time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QReturnIssue, returnedAt, issueID)
 	if err != nil {
 		return false, err
 	}
@@ -206,3 +721,8 @@ func (r *issueRepository) Return(issueID int64, returnedAt time.Time, fine float
 	}
 	return rows > 0, nil
 }
+
+func (r *issueRepository) AddFinePaid(issueID int64, amount float64) error {
+	_, err := r.db.Exec(db.QAddIssueFinePaid, amount, issueID)
+	return err
+}
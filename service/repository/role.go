@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type RoleRepo interface {
+	Create(role *models.Role) (int64, error)
+	GetByID(id int64) (*models.Role, error)
+	GetByName(name string) (*models.Role, error)
+	GetAll() ([]models.Role, error)
+	Update(role *models.Role) error
+	Delete(id int64) error
+	GetPermissions(id int64) ([]string, error)
+	SetPermissions(id int64, permissions []string) error
+}
+
+type roleRepository struct {
+	db queryer
+}
+
+func (r *roleRepository) Create(role *models.Role) (int64, error) {
+	res, err := r.db.Exec(db.QCreateRole, role.Name, role.Description)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *roleRepository) GetByID(id int64) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Get(&role, db.QGetRoleByID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) GetByName(name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Get(&role, db.QGetRoleByName, name); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) GetAll() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Select(&roles, db.QGetAllRoles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) Update(role *models.Role) error {
+	_, err := r.db.Exec(db.QUpdateRole, role.Name, role.Description, role.ID)
+	return err
+}
+
+func (r *roleRepository) Delete(id int64) error {
+	_, err := r.db.Exec(db.QDeleteRole, id)
+	return err
+}
+
+func (r *roleRepository) GetPermissions(id int64) ([]string, error) {
+	perms := []string{}
+	if err := r.db.Select(&perms, db.QGetRolePermissions, id); err != nil {
+		return nil, err
+	}
+	return perms, nil
+}
+
+func (r *roleRepository) SetPermissions(id int64, permissions []string) error {
+	if _, err := r.db.Exec(db.QClearRolePermissions, id); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if _, err := r.db.Exec(db.QAddRolePermission, id, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+var textColumns = map[string][]string{
+	"":       {"b.title", "b.author", "b.description"},
+	"title":  {"b.title"},
+	"author": {"b.author"},
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// Search runs a full-text query. Match conditions go through the FULLTEXT
+// indexes on (title, author, description), title and author; Like
+// conditions cover words too short to be indexed and treat % and _
+// literally.
+func (r *bookRepository) Search(q models.TextQuery) ([]models.ScoredBook, error) {
+	where, whereArgs := textConditions(q)
+	query := db.QSearchBooks + where + "\n\tORDER BY score DESC, b.id DESC\n\tLIMIT ?"
+	args := append([]interface{}{q.Rank, q.Rank}, whereArgs...)
+	args = append(args, q.Limit)
+
+	books := []models.ScoredBook{}
+	if err := r.db.Select(&books, query, args...); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// Facets counts the books q matches by author, subject, decade, language,
+// format and availability, keeping the top limit values of each.
+func (r *bookRepository) Facets(q models.TextQuery, limit int) (*models.Facets, error) {
+	where, args := textConditions(q)
+	args = append(args, limit)
+
+	f := &models.Facets{}
+	for query, dst := range map[string]*[]models.FacetCount{
+		db.QFacetAuthors:      &f.Author,
+		db.QFacetSubjects:     &f.Subject,
+		db.QFacetYears:        &f.Year,
+		db.QFacetLanguages:    &f.Language,
+		db.QFacetFormats:      &f.Format,
+		db.QFacetAvailability: &f.Availability,
+	} {
+		*dst = []models.FacetCount{}
+		if err := r.db.Select(dst, fmt.Sprintf(query, where), args...); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// textConditions renders the match, like and facet conditions of q as
+// "AND ..." lines for a query over books b.
+func textConditions(q models.TextQuery) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	for _, m := range q.Match {
+		cond := fmt.Sprintf("MATCH(%s) AGAINST (? IN BOOLEAN MODE)", strings.Join(textColumns[m.Field], ", "))
+		if m.Exclude {
+			cond = "NOT " + cond
+		}
+		conds = append(conds, cond)
+		args = append(args, m.Expr)
+	}
+	for _, m := range q.Like {
+		pattern := "%" + likeEscaper.Replace(m.Expr) + "%"
+		var ors []string
+		for _, col := range textColumns[m.Field] {
+			ors = append(ors, col+" LIKE ? ESCAPE '!'")
+			args = append(args, pattern)
+		}
+		cond := "(" + strings.Join(ors, " OR ") + ")"
+		if m.Exclude {
+			cond = "NOT " + cond
+		}
+		conds = append(conds, cond)
+	}
+
+	for _, n := range q.ISBN {
+		conds = append(conds, "b.isbn = ?")
+		args = append(args, n)
+	}
+	for _, n := range q.NotISBN {
+		conds = append(conds, "NOT (b.isbn <=> ?)")
+		args = append(args, n)
+	}
+
+	f := q.Filter
+	in := func(expr string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		conds = append(conds, fmt.Sprintf(expr, strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")))
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	in(`EXISTS (SELECT 1 FROM book_contributors ac JOIN authors au ON au.id = ac.author_id
+		WHERE ac.book_id = b.id AND ac.role = 'author' AND au.name IN (%s))`, f.Authors)
+	in("EXISTS (SELECT 1 FROM book_subjects bs WHERE bs.book_id = b.id AND bs.subject IN (%s))", f.Subjects)
+	in("b.language IN (%s)", f.Languages)
+	in("b.format IN (%s)", f.Formats)
+	if f.YearFrom != 0 {
+		conds = append(conds, "b.publication_year >= ?")
+		args = append(args, f.YearFrom)
+	}
+	if f.YearTo != 0 {
+		conds = append(conds, "b.publication_year <= ?")
+		args = append(args, f.YearTo)
+	}
+	if f.Available != nil {
+		conds = append(conds, "EXISTS (SELECT 1 FROM book_copies c WHERE c.book_id = b.id AND c.status = 'available') = ?")
+		args = append(args, *f.Available)
+	}
+
+	var where strings.Builder
+	for _, c := range conds {
+		where.WriteString("\n\tAND ")
+		where.WriteString(c)
+	}
+	return where.String(), args
+}
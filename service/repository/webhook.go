@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"library-management/service/models"
+	db "library-management/service/repository/db"
+)
+
+type WebhookRepo interface {
+	Create(w *models.Webhook) (int64, error)
+	GetByID(id int64) (*models.Webhook, error)
+	GetAll() ([]models.Webhook, error)
+	GetActive() ([]models.Webhook, error)
+	Update(w *models.Webhook) error
+	Delete(id int64) error
+
+	CreateDelivery(d *models.WebhookDelivery) (int64, error)
+	GetDelivery(id int64) (*models.WebhookDelivery, error)
+	GetDeliveries(webhookID int64, status string, limit int) ([]models.WebhookDelivery, error)
+	GetDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	LeaseDelivery(id int64, now, until time.Time) (bool, error)
+	FinishDelivery(d *models.WebhookDelivery) error
+	RetryDelivery(id int64, at time.Time) (bool, error)
+	AddAttempt(a *models.WebhookAttempt) (int64, error)
+	GetAttempts(deliveryID int64) ([]models.WebhookAttempt, error)
+}
+
+type webhookRepository struct {
+	db queryer
+}
+
+func (r *webhookRepository) Create(w *models.Webhook) (int64, error) {
+	res, err := r.db.Exec(db.QCreateWebhook, w.URL, w.Secret, w.Events, w.Active, w.Description)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *webhookRepository) GetByID(id int64) (*models.Webhook, error) {
+	var w models.Webhook
+	if err := r.db.Get(&w, db.QGetWebhookByID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *webhookRepository) GetAll() ([]models.Webhook, error) {
+	hooks := []models.Webhook{}
+	if err := r.db.Select(&hooks, db.QGetAllWebhooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *webhookRepository) GetActive() ([]models.Webhook, error) {
+	hooks := []models.Webhook{}
+	if err := r.db.Select(&hooks, db.QGetActiveWebhooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *webhookRepository) Update(w *models.Webhook) error {
+	_, err := r.db.Exec(db.QUpdateWebhook, w.URL, w.Events, w.Active, w.Description, w.ID)
+	return err
+}
+
+func (r *webhookRepository) Delete(id int64) error {
+	_, err := r.db.Exec(db.QDeleteWebhook, id)
+	return err
+}
+
+func (r *webhookRepository) CreateDelivery(d *models.WebhookDelivery) (int64, error) {
+	res, err := r.db.Exec(db.QCreateWebhookDelivery, d.WebhookID, d.EventID, d.EventType, d.Payload, d.NextAttemptAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *webhookRepository) GetDelivery(id int64) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	if err := r.db.Get(&d, db.QGetWebhookDeliveryByID, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *webhookRepository) GetDeliveries(webhookID int64, status string, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	if err := r.db.Select(&deliveries, db.QGetWebhookDeliveriesByWebhook, webhookID, status, status, limit); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+	if err := r.db.Select(&deliveries, db.QGetDueWebhookDeliveries, now, limit); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// LeaseDelivery pushes next_attempt_at to until so that other runners skip
+// the delivery while it is being sent. It reports false if another runner
+// got there first.
+func (r *webhookRepository) LeaseDelivery(id int64, now, until time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QLeaseWebhookDelivery, until, id, now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *webhookRepository) FinishDelivery(d *models.WebhookDelivery) error {
+	_, err := r.db.Exec(db.QFinishWebhookDelivery, d.Status, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt, d.ID)
+	return err
+}
+
+func (r *webhookRepository) RetryDelivery(id int64, at time.Time) (bool, error) {
+	res, err := r.db.Exec(db.QRetryWebhookDelivery, at, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *webhookRepository) AddAttempt(a *models.WebhookAttempt) (int64, error) {
+	res, err := r.db.Exec(db.QCreateWebhookAttempt, a.DeliveryID, a.StatusCode, a.Error, a.ResponseBody, a.DurationMS)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (r *webhookRepository) GetAttempts(deliveryID int64) ([]models.WebhookAttempt, error) {
+	attempts := []models.WebhookAttempt{}
+	if err := r.db.Select(&attempts, db.QGetWebhookAttempts, deliveryID); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
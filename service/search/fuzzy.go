@@ -0,0 +1,40 @@
+package search
+
+// FuzzyIndex adds spelling correction to another index. A query with words
+// the catalog doesn't contain gets a DidYouMean; when it also finds nothing,
+// the corrected query is run instead and Corrected is set.
+type FuzzyIndex struct {
+	index     SearchIndex
+	suggester *Suggester
+}
+
+func NewFuzzyIndex(index SearchIndex, suggester *Suggester) *FuzzyIndex {
+	return &FuzzyIndex{index: index, suggester: suggester}
+}
+
+func (x *FuzzyIndex) Search(req Request) (*Result, error) {
+	res, err := x.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	corrected, ok := x.suggester.Correct(req.Query)
+	if !ok {
+		return res, nil
+	}
+	res.DidYouMean = corrected.String()
+	if len(res.Hits) > 0 {
+		return res, nil
+	}
+
+	req.Query = corrected
+	retry, err := x.index.Search(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(retry.Hits) == 0 {
+		return res, nil
+	}
+	retry.DidYouMean = res.DidYouMean
+	retry.Corrected = true
+	return retry, nil
+}
@@ -0,0 +1,142 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+type span struct{ start, end int }
+
+// Highlight HTML-escapes text and wraps every occurrence of the given words
+// or phrases in <mark> tags. A word matches at the start of a word in text,
+// so "tolk" marks the first letters of "Tolkien". ok is false when nothing
+// matched.
+func Highlight(text string, words []string) (string, bool) {
+	spans := matches(text, words)
+	if len(spans) == 0 {
+		return html.EscapeString(text), false
+	}
+	return mark(text, spans), true
+}
+
+// matches finds the byte ranges of text that match words, sorted and with
+// overlaps merged.
+func matches(text string, words []string) []span {
+	lower := strings.ToLower(text)
+	var spans []span
+	for _, w := range words {
+		w = strings.ToLower(w)
+		if w == "" || len(lower) != len(text) {
+			// lowercasing changed byte offsets; skip rather than mark
+			// the wrong bytes
+			continue
+		}
+		for i := 0; i < len(lower); {
+			j := strings.Index(lower[i:], w)
+			if j < 0 {
+				break
+			}
+			start := i + j
+			if wordStart(lower, start) {
+				spans = append(spans, span{start, start + len(w)})
+			}
+			i = start + len(w)
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.start <= last.end {
+			if s.end > last.end {
+				last.end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func wordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev)
+}
+
+func mark(text string, spans []span) string {
+	var b strings.Builder
+	prev := 0
+	for _, s := range spans {
+		b.WriteString(html.EscapeString(text[prev:s.start]))
+		b.WriteString(markOpen)
+		b.WriteString(html.EscapeString(text[s.start:s.end]))
+		b.WriteString(markClose)
+		prev = s.end
+	}
+	b.WriteString(html.EscapeString(text[prev:]))
+	return b.String()
+}
+
+// Snippet highlights words like Highlight, but only in a window of about
+// width bytes of text around the first match, cut at spaces and marked
+// with "…" where text was left out.
+func Snippet(text string, words []string, width int) (string, bool) {
+	spans := matches(text, words)
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	start := spans[0].start - width/3
+	if start < 0 {
+		start = 0
+	} else if i := strings.IndexByte(text[start:spans[0].start], ' '); i >= 0 {
+		start += i + 1
+	}
+	end := start + width
+	if end < spans[0].end {
+		end = spans[0].end
+	}
+	if end > len(text) {
+		end = len(text)
+	} else if i := strings.LastIndexByte(text[spans[0].end:end], ' '); i >= 0 {
+		end = spans[0].end + i
+	}
+
+	// without a space to cut at, at least don't split a rune
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start++
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+
+	var inside []span
+	for _, s := range spans {
+		if s.start >= start && s.end <= end {
+			inside = append(inside, span{s.start - start, s.end - start})
+		}
+	}
+	out := mark(text[start:end], inside)
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out, true
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"library-management/service/models"
+	"library-management/service/repository"
+)
+
+// Hit is a book that matched, with its relevance score and the matched
+// fields highlighted.
+type Hit struct {
+	Book       models.Book       `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+const (
+	// facetValues is how many values the author and subject facets list.
+	facetValues = 20
+	// snippetLen is roughly how many bytes of the description a hit shows.
+	snippetLen = 160
+)
+
+// Request is one search. Filter narrows the hits by facet values; with
+// Facets set the result also counts every match by facet.
+type Request struct {
+	Query  Query
+	Filter models.FacetFilter
+	Limit  int
+	Facets bool
+}
+
+// Result holds the hits for a search. DidYouMean is a respelled query when
+// some words aren't in the catalog; Corrected says the hits are for it.
+type Result struct {
+	Hits       []Hit          `json:"hits"`
+	Facets     *models.Facets `json:"facets,omitempty"`
+	DidYouMean string         `json:"did_you_mean,omitempty"`
+	Corrected  bool           `json:"corrected,omitempty"`
+}
+
+type SearchIndex interface {
+	Search(req Request) (*Result, error)
+}
+
+// MySQLIndex searches through the FULLTEXT indexes on books.
+type MySQLIndex struct {
+	repo *repository.Repo
+}
+
+func NewMySQLIndex(repo *repository.Repo) *MySQLIndex {
+	return &MySQLIndex{repo: repo}
+}
+
+// Search needs a query or a facet filter; with neither it finds nothing
+// rather than the whole catalog.
+func (x *MySQLIndex) Search(req Request) (*Result, error) {
+	q := req.Query
+	if q.Empty() && req.Filter.Empty() {
+		return &Result{Hits: []Hit{}}, nil
+	}
+
+	tq := models.TextQuery{Rank: q.Ranking(), Filter: req.Filter, Limit: req.Limit}
+	for _, field := range []string{FieldAny, FieldTitle, FieldAuthor} {
+		for _, exclude := range []bool{false, true} {
+			if expr := q.BooleanMode(field, exclude); expr != "" {
+				tq.Match = append(tq.Match, models.TextMatch{Field: field, Expr: expr, Exclude: exclude})
+			}
+		}
+	}
+	for _, t := range q.Short() {
+		tq.Like = append(tq.Like, models.TextMatch{Field: t.Field, Expr: t.Text, Exclude: t.Exclude})
+	}
+	tq.ISBN, tq.NotISBN = q.ISBNs(false), q.ISBNs(true)
+
+	books, err := x.repo.BookRepo.Search(tq)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Hits: make([]Hit, len(books))}
+	for i, b := range books {
+		res.Hits[i] = Hit{Book: b.Book, Score: b.Score, Highlights: Highlights(q, b.Book)}
+	}
+	if req.Facets {
+		if res.Facets, err = x.repo.BookRepo.Facets(tq, facetValues); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Highlights marks the query terms in the book's title and author, and in
+// a snippet of its description. Fields without a match are left out.
+func Highlights(q Query, b models.Book) map[string]string {
+	h := map[string]string{}
+	for field, text := range map[string]string{FieldTitle: b.Title, FieldAuthor: b.Author} {
+		if s, ok := Highlight(text, q.Wanted(field)); ok {
+			h[field] = s
+		}
+	}
+	if s, ok := Snippet(b.Description, q.Wanted(FieldAny), snippetLen); ok {
+		h["description"] = s
+	}
+	return h
+}
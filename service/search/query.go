@@ -0,0 +1,245 @@
+// Package search parses catalog queries and runs them against a
+// SearchIndex. The default index is MySQL FULLTEXT over books.title,
+// books.author and books.description, which InnoDB keeps current on every
+// insert, update and (soft) delete.
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"library-management/service/isbn"
+)
+
+const (
+	FieldAny    = ""
+	FieldTitle  = "title"
+	FieldAuthor = "author"
+	FieldISBN   = "isbn"
+)
+
+// MinTokenLen matches InnoDB's default innodb_ft_min_token_size; shorter
+// words are not in the FULLTEXT index.
+const MinTokenLen = 3
+
+// stopwords is InnoDB's default stopword list. These words are not indexed
+// either.
+var stopwords = map[string]bool{
+	"a": true, "about": true, "an": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "com": true, "de": true, "en": true, "for": true,
+	"from": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"la": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "what": true, "when": true, "where": true,
+	"who": true, "will": true, "with": true, "und": true, "www": true,
+}
+
+// Term is one part of a query: a word, or a phrase when it was quoted.
+// Exclude is set for terms written with a leading "-".
+type Term struct {
+	Field   string
+	Text    string
+	Phrase  bool
+	Exclude bool
+}
+
+type Query struct {
+	Raw   string
+	Terms []Term
+}
+
+// Parse splits a query such as
+//
+//	dune author:herbert -"children of" title:"god emperor"
+//
+// into terms. Unknown field prefixes are searched as plain words. A word
+// that is a valid ISBN, with or without the isbn: prefix, becomes an ISBN
+// term holding the 13-digit form.
+func Parse(s string) Query {
+	q := Query{Raw: s}
+	rest := strings.TrimSpace(s)
+	for rest != "" {
+		var t Term
+		if rest[0] == '-' {
+			t.Exclude = true
+			rest = rest[1:]
+		}
+		if i := strings.IndexByte(rest, ':'); i > 0 && !strings.ContainsAny(rest[:i], " \t\"") {
+			switch f := strings.ToLower(rest[:i]); f {
+			case FieldTitle, FieldAuthor, FieldISBN:
+				t.Field = f
+				rest = rest[i+1:]
+			}
+		}
+
+		var text string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+			t.Phrase = true
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+
+		if !t.Phrase && (t.Field == FieldAny || t.Field == FieldISBN) {
+			if n, err := isbn.Normalize(text); err == nil {
+				t.Field, t.Text = FieldISBN, n
+				q.Terms = append(q.Terms, t)
+				continue
+			}
+		}
+		if t.Field == FieldISBN {
+			// not a valid ISBN, so nothing can have it
+			t.Text = isbn.Clean(text)
+			q.Terms = append(q.Terms, t)
+			continue
+		}
+
+		words := Tokens(text)
+		if len(words) == 0 {
+			continue
+		}
+		if t.Phrase && len(words) > 1 {
+			t.Text = strings.Join(words, " ")
+			q.Terms = append(q.Terms, t)
+			continue
+		}
+		// an unquoted word can hold punctuation ("le-guin", "o'brien") that
+		// splits it into several index tokens
+		t.Phrase = false
+		for _, w := range words {
+			t.Text = w
+			q.Terms = append(q.Terms, t)
+		}
+	}
+	return q
+}
+
+// Tokens lowercases s and splits it into words on anything that is not a
+// letter or digit, the same way the FULLTEXT parser does.
+func Tokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Empty reports whether the query has nothing to look for.
+func (q Query) Empty() bool {
+	for _, t := range q.Terms {
+		if !t.Exclude {
+			return false
+		}
+	}
+	return true
+}
+
+// Short returns the single-word terms below MinTokenLen ("go", "c") and
+// stopwords. They are not in the FULLTEXT index and have to be matched
+// another way.
+func (q Query) Short() []Term {
+	var short []Term
+	for _, t := range q.Terms {
+		if t.Field != FieldISBN && isShort(t) {
+			short = append(short, t)
+		}
+	}
+	return short
+}
+
+func isShort(t Term) bool {
+	return !t.Phrase && (utf8.RuneCountInString(t.Text) < MinTokenLen || stopwords[t.Text])
+}
+
+// BooleanMode renders the terms for field as a MySQL boolean-mode
+// expression. With exclude false it holds the wanted terms, all required,
+// single words also matching as a prefix ("tolk" finds "Tolkien"); with
+// exclude true it holds the excluded ones, any of which rules a book out.
+// Short words are left out.
+func (q Query) BooleanMode(field string, exclude bool) string {
+	op := "+"
+	if exclude {
+		op = ""
+	}
+	var parts []string
+	for _, t := range q.Terms {
+		if t.Field != field || t.Exclude != exclude || isShort(t) {
+			continue
+		}
+		if t.Phrase {
+			parts = append(parts, op+`"`+t.Text+`"`)
+		} else {
+			parts = append(parts, op+t.Text+"*")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Ranking renders every wanted term, whatever its field, without operators.
+// Matched against the title it lifts books whose title holds the words.
+func (q Query) Ranking() string {
+	var parts []string
+	for _, t := range q.Terms {
+		if t.Exclude || t.Field == FieldISBN || isShort(t) {
+			continue
+		}
+		if t.Phrase {
+			parts = append(parts, `"`+t.Text+`"`)
+		} else {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Wanted returns the text of the terms that apply to field, for
+// highlighting; terms without a field apply everywhere.
+func (q Query) Wanted(field string) []string {
+	var words []string
+	for _, t := range q.Terms {
+		if !t.Exclude && (t.Field == FieldAny || t.Field == field) {
+			words = append(words, t.Text)
+		}
+	}
+	return words
+}
+
+// ISBNs returns the ISBN terms with the given exclude flag.
+func (q Query) ISBNs(exclude bool) []string {
+	var out []string
+	for _, t := range q.Terms {
+		if t.Field == FieldISBN && t.Exclude == exclude {
+			out = append(out, t.Text)
+		}
+	}
+	return out
+}
+
+// String renders the query back in the syntax Parse reads.
+func (q Query) String() string {
+	parts := make([]string, len(q.Terms))
+	for i, t := range q.Terms {
+		var b strings.Builder
+		if t.Exclude {
+			b.WriteByte('-')
+		}
+		if t.Field != FieldAny {
+			b.WriteString(t.Field + ":")
+		}
+		if t.Phrase {
+			b.WriteString(`"` + t.Text + `"`)
+		} else {
+			b.WriteString(t.Text)
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, " ")
+}
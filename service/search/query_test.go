@@ -0,0 +1,65 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Term
+	}{
+		{`dune`, []Term{{Text: "dune"}}},
+		{`Dune Messiah`, []Term{{Text: "dune"}, {Text: "messiah"}}},
+		{`author:herbert -"children of" title:"god emperor"`, []Term{
+			{Field: FieldAuthor, Text: "herbert"},
+			{Text: "children of", Phrase: true, Exclude: true},
+			{Field: FieldTitle, Text: "god emperor", Phrase: true},
+		}},
+		{`AUTHOR:Le-Guin`, []Term{{Field: FieldAuthor, Text: "le"}, {Field: FieldAuthor, Text: "guin"}}},
+		{`"dune"`, []Term{{Text: "dune"}}},
+		{`"unterminated phrase`, []Term{{Text: "unterminated phrase", Phrase: true}}},
+		{`genre:scifi`, []Term{{Text: "genre"}, {Text: "scifi"}}},
+		{`0-306-40615-2`, []Term{{Field: FieldISBN, Text: "9780306406157"}}},
+		{`-isbn:978-0-306-40615-7`, []Term{{Field: FieldISBN, Text: "9780306406157", Exclude: true}}},
+		{`isbn:123-45`, []Term{{Field: FieldISBN, Text: "12345"}}},
+		{`  "" -- `, nil},
+	}
+	for _, tt := range tests {
+		got := Parse(tt.in)
+		if got.Raw != tt.in {
+			t.Errorf("Parse(%q).Raw = %q", tt.in, got.Raw)
+		}
+		if !reflect.DeepEqual(got.Terms, tt.want) {
+			t.Errorf("Parse(%q).Terms = %+v, want %+v", tt.in, got.Terms, tt.want)
+		}
+	}
+}
+
+func TestBooleanMode(t *testing.T) {
+	q := Parse(`tolk "lord of the rings" -silmarillion -"unfinished tales" author:tolkien go the`)
+	tests := []struct {
+		field   string
+		exclude bool
+		want    string
+	}{
+		{FieldAny, false, `+tolk* +"lord of the rings"`},
+		{FieldAny, true, `silmarillion* "unfinished tales"`},
+		{FieldAuthor, false, `+tolkien*`},
+		{FieldTitle, false, ``},
+	}
+	for _, tt := range tests {
+		if got := q.BooleanMode(tt.field, tt.exclude); got != tt.want {
+			t.Errorf("BooleanMode(%q, %v) = %q, want %q", tt.field, tt.exclude, got, tt.want)
+		}
+	}
+
+	var short []string
+	for _, term := range q.Short() {
+		short = append(short, term.Text)
+	}
+	if !reflect.DeepEqual(short, []string{"go", "the"}) {
+		t.Errorf("Short() = %v, want [go the]", short)
+	}
+}
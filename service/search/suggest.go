@@ -0,0 +1,333 @@
+package search
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"library-management/service/models"
+)
+
+const (
+	// candidates is how many words sharing the most trigrams with a
+	// misspelling are checked for edit distance.
+	candidates = 200
+	// minShared is the lowest trigram similarity (shared over all distinct
+	// trigrams of both words) a candidate needs. It is low because a single
+	// typo changes up to three trigrams.
+	minShared = 0.1
+)
+
+// Suggestion is one autocomplete entry: a title, with the book it belongs
+// to, or an author, with how many books they have.
+type Suggestion struct {
+	Text   string `json:"text"`
+	Field  string `json:"field"`
+	BookID int64  `json:"book_id,omitempty"`
+	Books  int    `json:"books,omitempty"`
+}
+
+// Suggester keeps the catalog's titles and authors in memory for
+// autocomplete and spelling correction. Readers always see a complete
+// snapshot; Rebuild swaps in a new one.
+type Suggester struct {
+	load func() ([]models.CatalogEntry, error)
+	snap atomic.Pointer[snapshot]
+
+	mu      sync.Mutex
+	running bool
+	pending bool
+}
+
+func NewSuggester(load func() ([]models.CatalogEntry, error)) *Suggester {
+	s := &Suggester{load: load}
+	s.snap.Store(&snapshot{})
+	return s
+}
+
+// Rebuild reloads the catalog and replaces the index.
+func (s *Suggester) Rebuild() error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.snap.Store(buildSnapshot(entries))
+	return nil
+}
+
+// Invalidate schedules a rebuild in the background. Calls that arrive while
+// one is running are folded into a single follow-up rebuild.
+func (s *Suggester) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		s.pending = true
+		return
+	}
+	s.running = true
+	go s.rebuildLoop()
+}
+
+func (s *Suggester) rebuildLoop() {
+	for {
+		if err := s.Rebuild(); err != nil {
+			log.Printf("rebuild suggestion index: %v", err)
+		}
+		s.mu.Lock()
+		if !s.pending {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.pending = false
+		s.mu.Unlock()
+	}
+}
+
+// Complete returns titles and authors that contain prefix at the start of
+// one of their words, e.g. "lord of" or "rin" for "The Lord of the Rings".
+// Entries that start with it come first, then authors with more books. When
+// nothing matches, the misspelled words of prefix are corrected first.
+func (s *Suggester) Complete(prefix string, limit int) []Suggestion {
+	snap := s.snap.Load()
+	key := strings.Join(Tokens(prefix), " ")
+	if key == "" {
+		return []Suggestion{}
+	}
+	found := snap.complete(key, limit)
+	if len(found) == 0 {
+		words := Tokens(prefix)
+		changed := false
+		for i := range words {
+			if w, ok := snap.correct(words[i]); ok {
+				words[i], changed = w, true
+			}
+		}
+		if changed {
+			found = snap.complete(strings.Join(words, " "), limit)
+		}
+	}
+	return found
+}
+
+// Correct replaces the words of q that appear nowhere in the catalog with
+// the closest word that does. ok is false when there was nothing to fix.
+func (s *Suggester) Correct(q Query) (Query, bool) {
+	snap := s.snap.Load()
+	out := Query{Terms: make([]Term, len(q.Terms))}
+	changed := false
+	for i, t := range q.Terms {
+		out.Terms[i] = t
+		if t.Phrase || t.Field == FieldISBN || isShort(t) {
+			continue
+		}
+		if w, ok := snap.correct(t.Text); ok {
+			out.Terms[i].Text = w
+			changed = true
+		}
+	}
+	out.Raw = out.String()
+	return out, changed
+}
+
+type completion struct {
+	key   string // normalized text from one of the entry's words to the end
+	entry int
+	start bool // key begins at the entry's first word
+}
+
+type suggestEntry struct {
+	Suggestion
+	text string
+}
+
+type snapshot struct {
+	entries  []suggestEntry
+	keys     []completion
+	words    map[string]int
+	trigrams map[string][]string
+}
+
+func buildSnapshot(books []models.CatalogEntry) *snapshot {
+	s := &snapshot{words: map[string]int{}, trigrams: map[string][]string{}}
+
+	authors := map[string]int{}
+	for _, b := range books {
+		s.entries = append(s.entries, suggestEntry{
+			Suggestion: Suggestion{Text: b.Title, Field: FieldTitle, BookID: b.ID},
+			text:       b.Title,
+		})
+		for _, name := range b.Authors {
+			key := strings.Join(Tokens(name), " ")
+			if key == "" {
+				continue
+			}
+			if i, ok := authors[key]; ok {
+				s.entries[i].Books++
+				continue
+			}
+			authors[key] = len(s.entries)
+			s.entries = append(s.entries, suggestEntry{
+				Suggestion: Suggestion{Text: name, Field: FieldAuthor, Books: 1},
+				text:       name,
+			})
+		}
+	}
+
+	for i, e := range s.entries {
+		words := Tokens(e.text)
+		for _, w := range words {
+			s.words[w]++
+		}
+		for j := range words {
+			s.keys = append(s.keys, completion{key: strings.Join(words[j:], " "), entry: i, start: j == 0})
+		}
+	}
+	sort.Slice(s.keys, func(a, b int) bool { return s.keys[a].key < s.keys[b].key })
+
+	for w := range s.words {
+		for _, g := range trigrams(w) {
+			s.trigrams[g] = append(s.trigrams[g], w)
+		}
+	}
+	return s
+}
+
+func (s *snapshot) complete(key string, limit int) []Suggestion {
+	i := sort.Search(len(s.keys), func(i int) bool { return s.keys[i].key >= key })
+
+	best := map[int]bool{} // entry -> matched at its start
+	for ; i < len(s.keys) && strings.HasPrefix(s.keys[i].key, key); i++ {
+		k := s.keys[i]
+		best[k.entry] = best[k.entry] || k.start
+	}
+
+	matched := make([]int, 0, len(best))
+	for e := range best {
+		matched = append(matched, e)
+	}
+	sort.Slice(matched, func(a, b int) bool {
+		ea, eb := s.entries[matched[a]], s.entries[matched[b]]
+		if best[matched[a]] != best[matched[b]] {
+			return best[matched[a]]
+		}
+		if ea.Books != eb.Books {
+			return ea.Books > eb.Books
+		}
+		if len(ea.text) != len(eb.text) {
+			return len(ea.text) < len(eb.text)
+		}
+		return ea.text < eb.text
+	})
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+
+	out := make([]Suggestion, len(matched))
+	for i, e := range matched {
+		out[i] = s.entries[e].Suggestion
+	}
+	return out
+}
+
+// correct finds the catalog word closest to w: among the words sharing the
+// most trigrams with it, the one fewest edits away, preferring common
+// words. Words up to 4 letters allow one edit, longer ones two.
+func (s *snapshot) correct(w string) (string, bool) {
+	if s.words[w] > 0 || utf8.RuneCountInString(w) < MinTokenLen {
+		return "", false
+	}
+
+	maxEdits := 2
+	if utf8.RuneCountInString(w) <= 4 {
+		maxEdits = 1
+	}
+
+	grams := trigrams(w)
+	shared := map[string]int{}
+	for _, g := range grams {
+		for _, c := range s.trigrams[g] {
+			shared[c]++
+		}
+	}
+	type cand struct {
+		word  string
+		score float64
+	}
+	var cands []cand
+	for c, n := range shared {
+		if d := utf8.RuneCountInString(c) - utf8.RuneCountInString(w); d > maxEdits || d < -maxEdits {
+			continue
+		}
+		score := float64(n) / float64(len(grams)+len(trigrams(c))-n)
+		if score >= minShared {
+			cands = append(cands, cand{c, score})
+		}
+	}
+	sort.Slice(cands, func(a, b int) bool {
+		if cands[a].score != cands[b].score {
+			return cands[a].score > cands[b].score
+		}
+		return cands[a].word < cands[b].word
+	})
+	if len(cands) > candidates {
+		cands = cands[:candidates]
+	}
+
+	best, bestDist := "", maxEdits+1
+	for _, c := range cands {
+		d := editDistance(w, c.word)
+		if d > maxEdits {
+			continue
+		}
+		if d < bestDist || (d == bestDist && s.words[c.word] > s.words[best]) {
+			best, bestDist = c.word, d
+		}
+	}
+	return best, best != ""
+}
+
+// trigrams splits a word padded with spaces into overlapping three-rune
+// pieces, so " to", "tol", "olk", ... "in ".
+func trigrams(w string) []string {
+	r := []rune(" " + w + " ")
+	if len(r) < 3 {
+		return nil
+	}
+	grams := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		grams = append(grams, string(r[i:i+3]))
+	}
+	return grams
+}
+
+// editDistance is the Damerau-Levenshtein distance (optimal string
+// alignment), so swapping two neighbouring letters counts as one edit:
+// "tolkein" is one edit from "tolkien".
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"testing"
+
+	"library-management/service/models"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"tolkien", "tolkien", 0},
+		{"tolkein", "tolkien", 1}, // transposition
+		{"tolkin", "tolkien", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3}, // optimal string alignment, not full Damerau
+		{"brontë", "bronte", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := editDistance(tt.b, tt.a); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	snap := buildSnapshot([]models.CatalogEntry{
+		{ID: 1, Title: "The Hobbit", Authors: []string{"J.R.R. Tolkien"}},
+		{ID: 2, Title: "The Lord of the Rings", Authors: []string{"J.R.R. Tolkien"}},
+		{ID: 3, Title: "Dune", Authors: []string{"Frank Herbert"}},
+		{ID: 4, Title: "Herbal Remedies", Authors: []string{"Jane Doe"}},
+	})
+	tests := []struct {
+		word string
+		want string
+		ok   bool
+	}{
+		{"tolkein", "tolkien", true},
+		{"tolkiennn", "tolkien", true}, // two edits for a long word
+		{"hobit", "hobbit", true},
+		{"herbet", "herbert", true},
+		{"dunes", "dune", true},
+		{"lrd", "lord", true},
+		{"dune", "", false},  // already in the catalog
+		{"dnue", "", false},  // one edit, but no trigram in common
+		{"xyzzy", "", false}, // nothing close
+		{"lrdss", "", false}, // three edits
+		{"go", "", false},    // too short to correct
+	}
+	for _, tt := range tests {
+		got, ok := snap.correct(tt.word)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("correct(%q) = %q, %v; want %q, %v", tt.word, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	s := NewSuggester(nil)
+	s.snap.Store(snap)
+	q, changed := s.Correct(Parse(`tolkein "the hobit" isbn:123`))
+	if !changed || q.Raw != `tolkien "the hobit" isbn:123` {
+		t.Errorf("Correct = %q, %v; want tolkien with the phrase and isbn untouched", q.Raw, changed)
+	}
+}
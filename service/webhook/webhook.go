@@ -0,0 +1,107 @@
+// Package webhook signs and posts event payloads to subscriber URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Library-Event"
+	HeaderDelivery  = "X-Library-Delivery"
+	HeaderTimestamp = "X-Library-Timestamp"
+	HeaderSignature = "X-Library-Signature"
+)
+
+const (
+	MaxAttempts    = 10
+	maxBackoff     = 6 * time.Hour
+	baseBackoff    = 30 * time.Second
+	maxBodyLogged  = 2048
+	defaultTimeout = 10 * time.Second
+)
+
+// Sign returns the signature header value for a delivery: the hex
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should recompute it and reject stale timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Backoff is the wait before retrying after the given number of failed
+// attempts: 30s doubling up to 6h, with +-20% jitter.
+func Backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	jitter := time.Duration(rand.Int64N(int64(d)/5*2+1)) - d/5
+	return d + jitter
+}
+
+type Result struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+type Sender struct {
+	Client *http.Client
+}
+
+func NewSender() *Sender {
+	return &Sender{Client: &http.Client{Timeout: defaultTimeout}}
+}
+
+// Send posts body to url. Anything but a 2xx response is an error; the
+// result is filled in as far as the request got.
+func (s *Sender) Send(ctx context.Context, url, secret, event string, deliveryID int64, body []byte) (Result, error) {
+	var res Result
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return res, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "library-webhooks/1")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, ts, body))
+
+	start := time.Now()
+	resp, err := s.Client.Do(req)
+	res.Duration = time.Since(start)
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+
+	res.StatusCode = resp.StatusCode
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodyLogged))
+	res.Body = string(snippet)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return res, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	want := "sha256=49f24e537407743fa4a0242bb63b94b9a47ee99cbbe071ccd8a22550ae411686"
+	if got := Sign("secret", 1700000000, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if !Verify("secret", want, 1700000000, body) {
+		t.Error("Verify rejected a good signature")
+	}
+	for name, ok := range map[string]bool{
+		"other secret":    Verify("other", want, 1700000000, body),
+		"other timestamp": Verify("secret", want, 1700000001, body),
+		"other body":      Verify("secret", want, 1700000000, []byte(`{"a":2}`)),
+	} {
+		if ok {
+			t.Errorf("Verify accepted a signature for %s", name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		base     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, 6 * time.Hour},
+		{50, 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		lo, hi := tt.base-tt.base/5, tt.base+tt.base/5
+		for i := 0; i < 100; i++ {
+			if d := Backoff(tt.attempts); d < lo || d > hi {
+				t.Fatalf("Backoff(%d) = %v, want %v +-20%%", tt.attempts, d, tt.base)
+			}
+		}
+	}
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	body := []byte(`{"type":"book.created"}`)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if !Verify("s3cret", r.Header.Get(HeaderSignature), ts, got) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get(HeaderEvent) != "book.created" || r.Header.Get(HeaderDelivery) != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("thanks"))
+	}))
+	defer srv.Close()
+
+	res, err := NewSender().Send(context.Background(), srv.URL, "s3cret", "book.created", 42, body)
+	if err != nil {
+		t.Fatalf("Send: %v (status %d)", err, res.StatusCode)
+	}
+	if res.StatusCode != http.StatusOK || res.Body != "thanks" {
+		t.Errorf("result = %+v", res)
+	}
+
+	res, err = NewSender().Send(context.Background(), srv.URL, "wrong", "book.created", 42, body)
+	if err == nil || res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad signature: got %+v, %v; want a 401 error", res, err)
+	}
+}